@@ -1,421 +1,5405 @@
 package chaincode
 
 import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"os"
+	"sort"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
+	"unicode/utf8"
 
+	"github.com/hyperledger/fabric-chaincode-go/v2/pkg/statebased"
 	"github.com/hyperledger/fabric-chaincode-go/v2/shim"
 	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+	"github.com/hyperledger/fabric-protos-go-apiv2/peer"
 )
 
-var totalCapacity uint64 // V: Global variable
+const (
+	minOwnerAge       = 18
+	maxOwnerAge       = 150
+	privateCollection = "collectionID"
+	initializedKey    = "initialized"
+	adminRoleAttr     = "role"
+	adminRoleValue    = "admin"
+	ownerIDSaltKey    = "ownerIdSalt"
+	minOwnerIDSaltLen = 16
+
+	maxAssetsPerOwnerConfig    = "maxAssetsPerOwner"
+	maxDescriptionLengthConfig = "maxDescriptionLength"
+	maxDailyIncrementConfig    = "maxDailyIncrement"
+	totalCapacityConfig        = "totalCapacity"
+	minimumOwnerAgeConfig      = "minimumOwnerAge"
+	ownerIDSchemeConfig        = "ownerIdScheme"
+	ownerSequenceConfig        = "ownerSequence"
+
+	defaultMaxDescriptionLength = 4096
+	defaultTotalCapacity        = 500
+)
+
+// Owner ID schemes selectable through SetOwnerIDScheme.
+const (
+	ownerIDSchemeCounter = iota
+	ownerIDSchemeSequential
+	ownerIDSchemeDocumentHash
+)
+
+var ownerIDSchemes = map[string]int64{
+	"counter":      ownerIDSchemeCounter,
+	"sequential":   ownerIDSchemeSequential,
+	"documentHash": ownerIDSchemeDocumentHash,
+}
+
+var logger = slog.New(slog.NewJSONHandler(os.Stderr, nil))
+
+// requiredIndexes mirrors the CouchDB index files under META-INF/statedb/couchdb/indexes,
+// which are installed with the chaincode package.
+var requiredIndexes = []string{
+	`{"index":{"fields":["amount"]},"ddoc":"indexAmountDoc","name":"indexAmount","type":"json"}`,
+	`{"index":{"fields":["assetType"]},"ddoc":"indexAssetTypeDoc","name":"indexAssetType","type":"json"}`,
+	`{"index":{"fields":["owner"]},"ddoc":"indexOwnerDoc","name":"indexOwner","type":"json"}`,
+}
 
 type FabricVulnBenchmark struct {
 	contractapi.Contract
 
-	ownerCounter int // V: Field Declaration
-}
+	ownerCounter int // V: Field Declaration (only under the opt-in "counter" owner ID scheme)
+}
+
+func (sc *FabricVulnBenchmark) InitContract(ctx contractapi.TransactionContextInterface, force bool) error {
+	stub := ctx.GetStub()
+
+	initialized, err := isInitialized(ctx)
+	if err != nil {
+		return err
+	}
+
+	if initialized {
+		if !force {
+			return errors.New("contract is already initialized")
+		}
+
+		err = requireAdmin(ctx)
+		if err != nil {
+			return err
+		}
+	}
+
+	if !initialized {
+		sc.ownerCounter = 1
+	}
+
+	// Defaults are only written when missing, so a forced re-init keeps values set through the setters.
+	defaults := []struct {
+		name  string
+		value int64
+	}{
+		{totalCapacityConfig, defaultTotalCapacity},
+		{minimumOwnerAgeConfig, minOwnerAge},
+	}
+	for _, d := range defaults {
+		_, found, err := lookupConfigInt(ctx, d.name)
+		if err != nil {
+			return err
+		}
+		if found {
+			continue
+		}
+
+		err = putConfigInt(ctx, d.name, d.value)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = stub.PutState(initializedKey, []byte("true"))
+	if err != nil {
+		return fmt.Errorf("unable to interact with world state: %w", err)
+	}
+
+	return writeAuditRecord(ctx, initializedKey)
+}
+
+// Ping is a read-only liveness check reporting the contract name and whether InitContract has run.
+func (sc *FabricVulnBenchmark) Ping(ctx contractapi.TransactionContextInterface) (string, error) {
+	initialized, err := isInitialized(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	name := sc.GetName()
+	if name == "" {
+		name = "FabricVulnBenchmark"
+	}
+
+	return fmt.Sprintf("%s initialized=%t", name, initialized), nil
+}
+
+// GetRequiredIndexes returns the CouchDB index definitions the rich queries rely on.
+func (sc *FabricVulnBenchmark) GetRequiredIndexes(ctx contractapi.TransactionContextInterface) ([]string, error) {
+	indexes := make([]string, len(requiredIndexes))
+	copy(indexes, requiredIndexes)
+
+	return indexes, nil
+}
+
+// IsInitialized reports whether InitContract has run.
+func (sc *FabricVulnBenchmark) IsInitialized(ctx contractapi.TransactionContextInterface) (bool, error) {
+	return isInitialized(ctx)
+}
+
+func (sc *FabricVulnBenchmark) GetBeforeTransaction() interface{} {
+	return logTransactionStart
+}
+
+func (sc *FabricVulnBenchmark) GetAfterTransaction() interface{} {
+	return logTransactionEnd
+}
+
+type Owner struct {
+	ID             int    `json:"id"`
+	Name           string `json:"name"`
+	Age            uint64 `json:"age"`
+	DocumentNumber string `json:"documentNumber"`
+	BoundIdentity  string `json:"boundIdentity"`
+}
+
+type Asset struct {
+	AssetType         string   `json:"assetType"`
+	ID                string   `json:"id"`
+	Description       string   `json:"description"`
+	Amount            int32    `json:"amount"`
+	Owner             string   `json:"owner"`
+	CreationTime      string   `json:"creationTime"`
+	Archived          bool     `json:"archived"`
+	MinAmount         int32    `json:"minAmount"`
+	CreatedBy         string   `json:"createdBy"`
+	Version           int      `json:"version"`
+	Frozen            bool     `json:"frozen"`
+	PendingOwner      string   `json:"pendingOwner"`
+	RetiredAt         string   `json:"retiredAt"`
+	Tags              []string `json:"tags,omitempty" metadata:",optional"`
+	PrivateCollection string   `json:"privateCollection,omitempty" metadata:",optional"`
+}
+
+type PaginatedOwnerResult struct {
+	Owners              []Owner `json:"owners"`
+	FetchedRecordsCount int32   `json:"fetchedRecordsCount"`
+	Bookmark            string  `json:"bookmark"`
+}
+
+type PaginatedAssetResult struct {
+	Assets              []Asset `json:"assets"`
+	FetchedRecordsCount int32   `json:"fetchedRecordsCount"`
+	Bookmark            string  `json:"bookmark"`
+}
+
+type AssetLookupResult struct {
+	Asset *Asset `json:"asset,omitempty" metadata:",optional"`
+	Found bool   `json:"found"`
+}
+
+type RepairReport struct {
+	AssetsScanned        int `json:"assetsScanned"`
+	PointerOwners        int `json:"pointerOwners"`
+	OutOfRangeAmounts    int `json:"outOfRangeAmounts"`
+	InvalidCreationTimes int `json:"invalidCreationTimes"`
+}
+
+type AssetIntegrityResult struct {
+	Asset *Asset `json:"asset"`
+	Valid bool   `json:"valid"`
+}
+
+type CapacityIntegrityReport struct {
+	WithinCapacity bool  `json:"withinCapacity"`
+	Total          int64 `json:"total"`
+	Capacity       int64 `json:"capacity"`
+}
+
+// AssetSummary aggregates the whole asset keyspace. CountByType serializes with sorted keys.
+type AssetSummary struct {
+	AssetCount    int            `json:"assetCount"`
+	TotalAmount   int64          `json:"totalAmount"`
+	CountByType   map[string]int `json:"countByType"`
+	UsedCapacity  int64          `json:"usedCapacity"`
+	TotalCapacity int64          `json:"totalCapacity"`
+}
+
+type OwnerStatement struct {
+	OwnerID     int     `json:"ownerId"`
+	Name        string  `json:"name,omitempty" metadata:",optional"`
+	Assets      []Asset `json:"assets"`
+	TotalAmount int64   `json:"totalAmount"`
+}
+
+type ContractConfig struct {
+	TotalCapacity     int64            `json:"totalCapacity"`
+	MinimumOwnerAge   uint64           `json:"minimumOwnerAge"`
+	PrivateCollection string           `json:"privateCollection"`
+	TypeCapacities    map[string]int64 `json:"typeCapacities"`
+}
+
+type AuditRecord struct {
+	TxID       string   `json:"txId"`
+	Operation  string   `json:"operation"`
+	ObjectType string   `json:"objectType"`
+	Key        []string `json:"key"`
+	Caller     string   `json:"caller"`
+	Timestamp  string   `json:"timestamp"`
+}
+
+type OwnershipRecord struct {
+	Owner     string `json:"owner"`
+	TxID      string `json:"txId"`
+	Timestamp string `json:"timestamp"`
+}
+
+type ValidationCode string
+
+const (
+	ValidationEmptyField    ValidationCode = "EMPTY_FIELD"
+	ValidationOutOfRange    ValidationCode = "OUT_OF_RANGE"
+	ValidationNotFound      ValidationCode = "NOT_FOUND"
+	ValidationAlreadyExists ValidationCode = "ALREADY_EXISTS"
+	ValidationInvalidFormat ValidationCode = "INVALID_FORMAT"
+)
+
+// ValidationError is returned when an input fails validation.
+// The Code prefixes the message so clients can branch on it after it crosses the chaincode boundary.
+type ValidationError struct {
+	Code    ValidationCode
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Code, e.Message)
+}
+
+type AssetInput struct {
+	ID          string `json:"id"`
+	Description string `json:"description"`
+	AssetType   string `json:"assetType"`
+	OwnerID     string `json:"ownerId"`
+	Amount      int32  `json:"amount"`
+}
+
+type AssetsCreatedEvent struct {
+	AssetIDs []string `json:"assetIds"`
+}
+
+type AssetRetiredEvent struct {
+	AssetID   string `json:"assetId"`
+	RetiredAt string `json:"retiredAt"`
+}
+
+type AssetsUpdatedEvent struct {
+	AssetType string   `json:"assetType"`
+	AssetIDs  []string `json:"assetIds"`
+}
+
+type Reservation struct {
+	ID      string `json:"id"`
+	AssetID string `json:"assetId"`
+	Amount  int32  `json:"amount"`
+}
+
+type AssetPrivateDetails struct {
+	ID          string `json:"id"`
+	Secret      string `json:"secret,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+func (sc *FabricVulnBenchmark) CreateAsset(ctx contractapi.TransactionContextInterface, assetID, description, assetType, ownerID string) error {
+	return sc.CreateAssetWithAmount(ctx, assetID, description, assetType, ownerID, 1)
+}
+
+// CreateAssetReturningTxID creates an asset like CreateAsset and returns the ID of the transaction that wrote it.
+func (sc *FabricVulnBenchmark) CreateAssetReturningTxID(ctx contractapi.TransactionContextInterface, assetID, description, assetType, ownerID string) (string, error) {
+	err := sc.CreateAsset(ctx, assetID, description, assetType, ownerID)
+	if err != nil {
+		return "", err
+	}
+
+	return ctx.GetStub().GetTxID(), nil
+}
+
+func (sc *FabricVulnBenchmark) CreateAssetWithAmount(ctx contractapi.TransactionContextInterface, assetID, description, assetType, ownerID string, amount int32) error {
+	err := sc.createAsset(ctx, assetID, description, assetType, ownerID, amount)
+	if err != nil {
+		return err
+	}
+
+	return writeTransientAssetSecret(ctx, assetID)
+}
+
+// CreateAssetsBatch creates every asset in the JSON array or none of them.
+// Entries are processed in input order, which is also the order of the returned IDs and of the AssetsCreated event.
+func (sc *FabricVulnBenchmark) CreateAssetsBatch(ctx contractapi.TransactionContextInterface, assetsJSON string) ([]string, error) {
+	var inputs []AssetInput
+	if err := decodeStrict(assetsJSON, &inputs); err != nil {
+		return nil, newValidationError(ValidationInvalidFormat, "assets must be a JSON array of asset inputs: %v", err)
+	}
+	if len(inputs) == 0 {
+		return nil, newValidationError(ValidationEmptyField, "batch must contain at least one asset")
+	}
+
+	// World state reads do not see writes from the same transaction, so checks
+	// spanning the whole batch have to be done up front.
+	seen := make(map[string]bool, len(inputs))
+	ownerCounts := make(map[string]int64)
+	var owners []string
+	typeAmounts := make(map[string]int64)
+	var assetTypes []string
+	var proposed int64
+	for _, input := range inputs {
+		if seen[input.ID] {
+			return nil, newValidationError(ValidationAlreadyExists, "asset %s appears more than once in the batch", input.ID)
+		}
+		seen[input.ID] = true
+		proposed += int64(input.Amount)
+
+		if ownerCounts[input.OwnerID] == 0 {
+			owners = append(owners, input.OwnerID)
+		}
+		ownerCounts[input.OwnerID]++
+
+		if _, ok := typeAmounts[input.AssetType]; !ok {
+			assetTypes = append(assetTypes, input.AssetType)
+		}
+		typeAmounts[input.AssetType] += int64(input.Amount)
+	}
+
+	for _, ownerID := range owners {
+		err := checkOwnerAssetLimit(ctx, ownerID, ownerCounts[ownerID])
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for _, assetType := range assetTypes {
+		err := checkTypeCapacity(ctx, assetType, typeAmounts[assetType])
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	existing, err := sumAssetAmounts(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	capacity, err := getTotalCapacity(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if existing+proposed > capacity {
+		return nil, newValidationError(ValidationOutOfRange, "batch amounts would exceed the total capacity")
+	}
+
+	assetIDs := make([]string, 0, len(inputs))
+	for _, input := range inputs {
+		err := sc.createAsset(ctx, input.ID, input.Description, input.AssetType, input.OwnerID, input.Amount)
+		if err != nil {
+			return nil, fmt.Errorf("unable to create asset %s: %w", input.ID, err)
+		}
+
+		assetIDs = append(assetIDs, input.ID)
+	}
+
+	eventBytes, err := marshalCanonical(AssetsCreatedEvent{AssetIDs: assetIDs})
+	if err != nil {
+		return nil, fmt.Errorf("unable to marshal event: %w", err)
+	}
+
+	err = ctx.GetStub().SetEvent("AssetsCreated", eventBytes)
+	if err != nil {
+		return nil, fmt.Errorf("unable to set event: %w", err)
+	}
+
+	return assetIDs, nil
+}
+
+func (sc *FabricVulnBenchmark) createAsset(ctx contractapi.TransactionContextInterface, assetID, description, assetType, ownerID string, amount int32) error {
+	_, err := validateAssetCreation(ctx, assetID, description, assetType, ownerID, amount)
+	if err != nil {
+		return err
+	}
+
+	return putNewAsset(ctx, assetID, description, assetType, ownerID, amount)
+}
+
+// putNewAsset builds an asset stamped with the transaction timestamp and the caller's identity,
+// and stores it together with its owner index entry and audit record. Callers validate it first.
+func putNewAsset(ctx contractapi.TransactionContextInterface, assetID, description, assetType, ownerID string, amount int32) error {
+	stub := ctx.GetStub()
+
+	var asset Asset
+	asset.AssetType = assetType
+	asset.Description = description
+	asset.ID = assetID
+	asset.Amount = amount
+	asset.Version = 1
+	// The owner ID, not the address of the owner record, so requireOwnerOrAdmin can resolve it.
+	asset.Owner = ownerID
+
+	creationTime, err := txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+	asset.CreationTime = creationTime
+
+	asset.CreatedBy, err = ctx.GetClientIdentity().GetID()
+	if err != nil {
+		return fmt.Errorf("unable to get client identity: %w", err)
+	}
+
+	assetKey, err := stub.CreateCompositeKey("asset", []string{asset.ID})
+	if err != nil {
+		return fmt.Errorf("unable to create composite key: %w", err)
+	}
+
+	assetBytes, err := marshalCanonical(asset)
+	if err != nil {
+		return fmt.Errorf("unable to marshal asset: %w", err)
+	}
+
+	err = stub.PutState(assetKey, []byte(assetBytes))
+	if err != nil {
+		return fmt.Errorf("unable to interact with world state: %w", err)
+	}
+
+	err = updateOwnerIndex(ctx, asset.ID, "", asset.Owner)
+	if err != nil {
+		return err
+	}
+
+	return writeAuditRecord(ctx, "asset", asset.ID)
+}
+
+// ValidateAssetCreation runs the same checks as CreateAsset without writing anything.
+func (sc *FabricVulnBenchmark) ValidateAssetCreation(ctx contractapi.TransactionContextInterface, assetID, description, assetType, ownerID string) error {
+	_, err := validateAssetCreation(ctx, assetID, description, assetType, ownerID, 1)
+
+	return err
+}
+
+// CreateAssetAutoID derives the asset ID from the transaction ID, which is identical on every endorser.
+func (sc *FabricVulnBenchmark) CreateAssetAutoID(ctx contractapi.TransactionContextInterface, description, assetType, ownerID string) (string, error) {
+	assetID := autoAssetID(ctx.GetStub().GetTxID())
+
+	err := sc.CreateAsset(ctx, assetID, description, assetType, ownerID)
+	if err != nil {
+		return "", err
+	}
+
+	return assetID, nil
+}
+
+// CreateAssetPrivate keeps the description out of the transaction arguments by reading it from the transient map.
+func (sc *FabricVulnBenchmark) CreateAssetPrivate(ctx contractapi.TransactionContextInterface, assetID, assetType, ownerID string) error {
+	stub := ctx.GetStub()
+
+	transientMap, err := stub.GetTransient()
+	if err != nil {
+		return fmt.Errorf("unable to get transient data: %w", err)
+	}
+
+	description, err := requireTransientField(transientMap, "assetDescription")
+	if err != nil {
+		return err
+	}
+
+	err = sc.CreateAssetWithAmount(ctx, assetID, "", assetType, ownerID, 1)
+	if err != nil {
+		return err
+	}
+
+	// Private data written earlier in this transaction cannot be read back, so the
+	// record is rebuilt from the transient map and overwrites any secret-only write.
+	return writeAssetPrivateDetails(ctx, privateCollection, &AssetPrivateDetails{
+		ID:          assetID,
+		Secret:      string(transientMap["assetSecret"]),
+		Description: string(description),
+	})
+}
+
+func (sc *FabricVulnBenchmark) CreateHierarchicalAsset(ctx contractapi.TransactionContextInterface, assetType, serial, description, ownerID string) error {
+	stub := ctx.GetStub()
+
+	if assetType == "" || serial == "" {
+		return newValidationError(ValidationEmptyField, "asset type and serial must not be empty")
+	}
+
+	if err := validateKeyComponent(assetType); err != nil {
+		return err
+	}
+	if err := validateKeyComponent(serial); err != nil {
+		return err
+	}
+
+	err := requireRegisteredAssetType(ctx, assetType)
+	if err != nil {
+		return err
+	}
+
+	assetKey, err := stub.CreateCompositeKey("hierarchicalAsset", []string{assetType, serial})
+	if err != nil {
+		return fmt.Errorf("unable to create composite key: %w", err)
+	}
+
+	existing, err := stub.GetState(assetKey)
+	if err != nil {
+		return fmt.Errorf("unable to interact with world state: %w", err)
+	}
+	if existing != nil {
+		return newValidationError(ValidationAlreadyExists, "cannot create world state pair with key %s/%s. Already exists", assetType, serial)
+	}
+
+	_, err = readOwner(ctx, ownerID)
+	if err != nil {
+		return err
+	}
+
+	creationTime, err := txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+
+	createdBy, err := ctx.GetClientIdentity().GetID()
+	if err != nil {
+		return fmt.Errorf("unable to get client identity: %w", err)
+	}
+
+	asset := Asset{
+		AssetType:    assetType,
+		ID:           serial,
+		Description:  description,
+		Amount:       1,
+		Owner:        ownerID,
+		CreationTime: creationTime,
+		CreatedBy:    createdBy,
+		Version:      1,
+	}
+
+	assetBytes, err := marshalCanonical(asset)
+	if err != nil {
+		return fmt.Errorf("unable to marshal asset: %w", err)
+	}
+
+	err = stub.PutState(assetKey, assetBytes)
+	if err != nil {
+		return fmt.Errorf("unable to interact with world state: %w", err)
+	}
+
+	return writeAuditRecord(ctx, "hierarchicalAsset", assetType, serial)
+}
+
+func (sc *FabricVulnBenchmark) ReadHierarchicalAsset(ctx contractapi.TransactionContextInterface, assetType, serial string) (*Asset, error) {
+	stub := ctx.GetStub()
+
+	if err := validateKeyComponent(assetType); err != nil {
+		return nil, err
+	}
+	if err := validateKeyComponent(serial); err != nil {
+		return nil, err
+	}
+
+	assetKey, err := stub.CreateCompositeKey("hierarchicalAsset", []string{assetType, serial})
+	if err != nil {
+		return nil, fmt.Errorf("unable to create composite key: %w", err)
+	}
+
+	assetBytes, err := stub.GetState(assetKey)
+	if err != nil {
+		return nil, fmt.Errorf("unable to interact with world state: %w", err)
+	}
+	if assetBytes == nil {
+		return nil, newValidationError(ValidationNotFound, "cannot read world state pair with key %s/%s. Does not exist", assetType, serial)
+	}
+
+	var asset Asset
+	err = json.Unmarshal(assetBytes, &asset)
+	if err != nil {
+		return nil, fmt.Errorf("unable to unmarshal asset: %w", err)
+	}
+
+	return &asset, nil
+}
+
+func (sc *FabricVulnBenchmark) GetHierarchicalAssetsByType(ctx contractapi.TransactionContextInterface, assetType string) ([]Asset, error) {
+	stub := ctx.GetStub()
+
+	if assetType == "" {
+		return nil, newValidationError(ValidationEmptyField, "asset type must not be empty")
+	}
+
+	if err := validateKeyComponent(assetType); err != nil {
+		return nil, err
+	}
+
+	iterator, err := stub.GetStateByPartialCompositeKey("hierarchicalAsset", []string{assetType})
+	if err != nil {
+		return nil, fmt.Errorf("unable to interact with world state: %w", err)
+	}
+	defer iterator.Close()
+
+	assets := make([]Asset, 0)
+	for iterator.HasNext() {
+		queryResponse, err := iterator.Next()
+		if err != nil {
+			return nil, fmt.Errorf("unable to get next element: %w", err)
+		}
+
+		var asset Asset
+		err = json.Unmarshal(queryResponse.GetValue(), &asset)
+		if err != nil {
+			return nil, fmt.Errorf("unable to unmarshal asset: %w", err)
+		}
+
+		assets = append(assets, asset)
+	}
+
+	return assets, nil
+}
+
+// V: Privacy leakage from private data in arguments, branch condition and returned payload
+func (sc *FabricVulnBenchmark) CreateOwner(ctx contractapi.TransactionContextInterface, name, documentNumber string) (string, error) {
+	_, err := sc.createOwner(ctx, name, documentNumber)
+	if err != nil {
+		return "", err
+	}
+
+	// V: Privacy leakage in returned payload
+	return fmt.Sprintf("Owner %s (%s) created successfully.", name, documentNumber), nil
+}
+
+// underageOwnerError is returned by createOwner when the transient age is below the configured minimum.
+type underageOwnerError struct {
+	name           string
+	documentNumber string
+	minimumAge     int64
+}
+
+func (e *underageOwnerError) Error() string {
+	return fmt.Sprintf("owner (%s, %s) must be at least %d years old", e.name, e.documentNumber, e.minimumAge)
+}
+
+// createOwner validates the transient age and writes the public owner, its private record and the document index.
+func (sc *FabricVulnBenchmark) createOwner(ctx contractapi.TransactionContextInterface, name, documentNumber string) (*Owner, error) {
+	stub := ctx.GetStub()
+
+	transientMap, err := stub.GetTransient()
+	if err != nil {
+		return nil, fmt.Errorf("unable to get transient data: %w", err)
+	}
+
+	ageBytes, err := requireTransientField(transientMap, "ownerAge")
+	if err != nil {
+		return nil, err
+	}
+
+	age, err := validateAge(string(ageBytes))
+	if err != nil {
+		return nil, err
+	}
+
+	minimumAge, err := getConfigInt(ctx, minimumOwnerAgeConfig, minOwnerAge)
+	if err != nil {
+		return nil, err
+	}
+
+	if age < uint64(minimumAge) { // V: Privacy leakage: private data in branch statement
+		return nil, &underageOwnerError{name: name, documentNumber: documentNumber, minimumAge: minimumAge}
+	}
+
+	var ownerPublic Owner
+	ownerPublic.ID, err = sc.nextOwnerID(ctx, documentNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	ownerPublic.BoundIdentity, err = ctx.GetClientIdentity().GetID()
+	if err != nil {
+		return nil, fmt.Errorf("unable to get client identity: %w", err)
+	}
+
+	ownerPublicBytes, err := marshalCanonical(ownerPublic)
+	if err != nil {
+		return nil, fmt.Errorf("unable to marshal asset: %w", err)
+	}
+
+	ownerKey, err := stub.CreateCompositeKey("owner", []string{strconv.Itoa(ownerPublic.ID)})
+	if err != nil {
+		return nil, fmt.Errorf("unable to create composite key: %w", err)
+	}
+
+	err = stub.PutState(ownerKey, ownerPublicBytes)
+	if err != nil {
+		return nil, fmt.Errorf("unable to interact with world state: %w", err)
+	}
+
+	err = writeAuditRecord(ctx, "owner", strconv.Itoa(ownerPublic.ID))
+	if err != nil {
+		return nil, err
+	}
+
+	var ownerPrivate Owner
+	ownerPrivate.Age = age
+	ownerPrivate.Name = name
+	ownerPrivate.DocumentNumber = documentNumber
+
+	ownerPrivateBytes, err := marshalCanonical(ownerPrivate)
+	if err != nil {
+		return nil, fmt.Errorf("unable to marshal asset: %w", err)
+	}
+	err = stub.PutPrivateData(privateCollection, strconv.Itoa(ownerPublic.ID), ownerPrivateBytes)
+	if err != nil {
+		return nil, fmt.Errorf("unable to store private data: %w", err)
+	}
+
+	documentKey, err := stub.CreateCompositeKey("documentHash", []string{documentHash(documentNumber)})
+	if err != nil {
+		return nil, fmt.Errorf("unable to create composite key: %w", err)
+	}
+
+	err = stub.PutPrivateData(privateCollection, documentKey, []byte(strconv.Itoa(ownerPublic.ID)))
+	if err != nil {
+		return nil, fmt.Errorf("unable to store private data: %w", err)
+	}
+
+	logger.Info("owner created", "function", "CreateOwner", "txID", stub.GetTxID(), "ownerID", ownerPublic.ID)
+
+	return &ownerPublic, nil
+}
+
+// OnboardOwnerWithAsset creates an owner and their first asset in one transaction and returns the owner ID.
+// Any failure aborts the transaction, so neither record is committed on its own.
+func (sc *FabricVulnBenchmark) OnboardOwnerWithAsset(ctx contractapi.TransactionContextInterface, name, documentNumber, assetID, description, assetType string) (string, error) {
+	// The owner written below is not readable within this transaction, so the asset checks
+	// that need an existing owner are skipped. A new owner holds no assets yet.
+	err := validateNewAsset(ctx, assetID, description, assetType, 1)
+	if err != nil {
+		return "", err
+	}
+
+	owner, err := sc.createOwner(ctx, name, documentNumber)
+	var underage *underageOwnerError
+	if errors.As(err, &underage) {
+		// The name and document number stay out of the error, as in UpdateOwner.
+		return "", newValidationError(ValidationOutOfRange, "owner must be at least %d years old", underage.minimumAge)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	ownerID := strconv.Itoa(owner.ID)
+
+	err = putNewAsset(ctx, assetID, description, assetType, ownerID, 1)
+	if err != nil {
+		return "", err
+	}
+
+	err = writeTransientAssetSecret(ctx, assetID)
+	if err != nil {
+		return "", err
+	}
+
+	return ownerID, nil
+}
+
+// UpdateOwner replaces the private name and age of an owner with the ownerName and ownerAge transient fields.
+// An empty ownerName keeps the current name.
+func (sc *FabricVulnBenchmark) UpdateOwner(ctx contractapi.TransactionContextInterface, ownerID string) error {
+	stub := ctx.GetStub()
+
+	err := verifyCollectionMembership(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = requireOwnerOrAdmin(ctx, ownerID)
+	if err != nil {
+		return err
+	}
+
+	transientMap, err := stub.GetTransient()
+	if err != nil {
+		return fmt.Errorf("unable to get transient data: %w", err)
+	}
+
+	ageBytes, err := requireTransientField(transientMap, "ownerAge")
+	if err != nil {
+		return err
+	}
+
+	age, err := validateAge(string(ageBytes))
+	if err != nil {
+		return err
+	}
+
+	minimumAge, err := getConfigInt(ctx, minimumOwnerAgeConfig, minOwnerAge)
+	if err != nil {
+		return err
+	}
+	if age < uint64(minimumAge) {
+		return newValidationError(ValidationOutOfRange, "owner must be at least %d years old", minimumAge)
+	}
+
+	ownerPrivateBytes, err := stub.GetPrivateData(privateCollection, ownerID)
+	if err != nil {
+		return fmt.Errorf("unable to read private data: %w", err)
+	}
+	if ownerPrivateBytes == nil {
+		return newValidationError(ValidationNotFound, "owner does not exist")
+	}
+
+	var ownerPrivate Owner
+	err = json.Unmarshal(ownerPrivateBytes, &ownerPrivate)
+	if err != nil {
+		return fmt.Errorf("unable to unmarshal owner: %w", err)
+	}
+
+	ownerPrivate.Age = age
+	if name := string(transientMap["ownerName"]); name != "" {
+		ownerPrivate.Name = name
+	}
+
+	ownerPrivateBytes, err = marshalCanonical(ownerPrivate)
+	if err != nil {
+		return fmt.Errorf("unable to marshal owner: %w", err)
+	}
+
+	err = stub.PutPrivateData(privateCollection, ownerID, ownerPrivateBytes)
+	if err != nil {
+		return fmt.Errorf("unable to store private data: %w", err)
+	}
+
+	err = writeAuditRecord(ctx, "owner", ownerID)
+	if err != nil {
+		return err
+	}
+
+	logger.Info("owner updated", "function", "UpdateOwner", "txID", stub.GetTxID(), "ownerID", ownerID)
+
+	return nil
+}
+
+// CheckOwnerEligibility reports whether the transient ownerAge meets the configured minimum age, writing nothing.
+func (sc *FabricVulnBenchmark) CheckOwnerEligibility(ctx contractapi.TransactionContextInterface) (bool, error) {
+	stub := ctx.GetStub()
+
+	transientMap, err := stub.GetTransient()
+	if err != nil {
+		return false, fmt.Errorf("unable to get transient data: %w", err)
+	}
+
+	ageBytes, err := requireTransientField(transientMap, "ownerAge")
+	if err != nil {
+		return false, err
+	}
+
+	age, err := validateAge(string(ageBytes))
+	if err != nil {
+		return false, err
+	}
+
+	minimumAge, err := getConfigInt(ctx, minimumOwnerAgeConfig, minOwnerAge)
+	if err != nil {
+		return false, err
+	}
+
+	return age >= uint64(minimumAge), nil
+}
+
+// IsDocumentRegistered reads the document number from the transient map and only reveals whether it is indexed.
+func (sc *FabricVulnBenchmark) IsDocumentRegistered(ctx contractapi.TransactionContextInterface) (bool, error) {
+	ownerID, err := readDocumentIndex(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	return ownerID != nil, nil
+}
+
+// GetOwnerByDocumentNumber returns the public ID of the owner registered with the transient documentNumber.
+func (sc *FabricVulnBenchmark) GetOwnerByDocumentNumber(ctx contractapi.TransactionContextInterface) (int, error) {
+	err := verifyCollectionMembership(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	ownerIDBytes, err := readDocumentIndex(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if ownerIDBytes == nil {
+		return 0, newValidationError(ValidationNotFound, "no owner is registered with this document number")
+	}
+
+	ownerID, err := strconv.Atoi(string(ownerIDBytes))
+	if err != nil {
+		return 0, fmt.Errorf("unable to parse owner ID: %w", err)
+	}
+
+	return ownerID, nil
+}
+
+func (sc *FabricVulnBenchmark) GetOwnerCount(ctx contractapi.TransactionContextInterface) (int, error) {
+	stub := ctx.GetStub()
+
+	iterator, err := stub.GetStateByPartialCompositeKey("owner", []string{})
+	if err != nil {
+		return 0, fmt.Errorf("unable to interact with world state: %w", err)
+	}
+	defer iterator.Close()
+
+	count := 0
+	for iterator.HasNext() {
+		_, err := iterator.Next()
+		if err != nil {
+			return 0, fmt.Errorf("unable to get next element: %w", err)
+		}
+		count++
+	}
+
+	return count, nil
+}
+
+func (sc *FabricVulnBenchmark) ReadOwnersPaginated(ctx contractapi.TransactionContextInterface, pageSize int32, bookmark string) (*PaginatedOwnerResult, error) {
+	stub := ctx.GetStub()
+
+	if pageSize <= 0 {
+		return nil, newValidationError(ValidationOutOfRange, "page size must be positive")
+	}
+
+	iterator, metadata, err := stub.GetStateByPartialCompositeKeyWithPagination("owner", []string{}, pageSize, bookmark)
+	if err != nil {
+		return nil, fmt.Errorf("unable to interact with world state: %w", err)
+	}
+	defer iterator.Close()
+
+	owners := make([]Owner, 0, pageSize)
+	for iterator.HasNext() {
+		queryResponse, err := iterator.Next()
+		if err != nil {
+			return nil, fmt.Errorf("unable to get next element: %w", err)
+		}
+
+		var owner Owner
+		err = json.Unmarshal(queryResponse.GetValue(), &owner)
+		if err != nil {
+			return nil, fmt.Errorf("unable to unmarshal owner: %w", err)
+		}
+
+		owners = append(owners, Owner{ID: owner.ID})
+	}
+
+	return &PaginatedOwnerResult{
+		Owners:              owners,
+		FetchedRecordsCount: metadata.GetFetchedRecordsCount(),
+		Bookmark:            metadata.GetBookmark(),
+	}, nil
+}
+
+// ReadOwnersByRange mirrors GetStateByRange semantics over the owner keyspace: startID is inclusive,
+// endID is exclusive, both compare lexically and an empty bound is open. GetStateByRange itself
+// rejects composite keys, so the range is applied while scanning the owner namespace.
+func (sc *FabricVulnBenchmark) ReadOwnersByRange(ctx contractapi.TransactionContextInterface, startID, endID string) ([]Owner, error) {
+	stub := ctx.GetStub()
+
+	if startID != "" && endID != "" && startID > endID {
+		return nil, newValidationError(ValidationOutOfRange, "start ID must not be greater than end ID")
+	}
+
+	iterator, err := stub.GetStateByPartialCompositeKey("owner", []string{})
+	if err != nil {
+		return nil, fmt.Errorf("unable to interact with world state: %w", err)
+	}
+	defer iterator.Close()
+
+	owners := make([]Owner, 0)
+	for iterator.HasNext() {
+		queryResponse, err := iterator.Next()
+		if err != nil {
+			return nil, fmt.Errorf("unable to get next element: %w", err)
+		}
+
+		_, cKeyParts, err := stub.SplitCompositeKey(queryResponse.GetKey())
+		if err != nil {
+			return nil, fmt.Errorf("unable to split key: %w", err)
+		}
+
+		ownerID := cKeyParts[0]
+		if (startID != "" && ownerID < startID) || (endID != "" && ownerID >= endID) {
+			continue
+		}
+
+		var owner Owner
+		err = json.Unmarshal(queryResponse.GetValue(), &owner)
+		if err != nil {
+			return nil, fmt.Errorf("unable to unmarshal owner: %w", err)
+		}
+
+		owners = append(owners, Owner{ID: owner.ID})
+	}
+
+	return owners, nil
+}
+
+func (sc *FabricVulnBenchmark) GetOwnersWithoutAssets(ctx contractapi.TransactionContextInterface) ([]int, error) {
+	owners, err := sc.ReadOwnersByRange(ctx, "", "")
+	if err != nil {
+		return nil, err
+	}
+
+	assets, err := scanAssets(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+
+	assetOwners := make(map[string]bool, len(assets))
+	for _, asset := range assets {
+		assetOwners[asset.Owner] = true
+	}
+
+	ownerIDs := make([]int, 0, len(owners))
+	for _, owner := range owners {
+		if !assetOwners[strconv.Itoa(owner.ID)] {
+			ownerIDs = append(ownerIDs, owner.ID)
+		}
+	}
+
+	sort.Ints(ownerIDs)
+
+	return ownerIDs, nil
+}
+
+// FindDuplicateOwnerIDs reports owner IDs that were minted more than once.
+// Owner records are keyed by ID, so a reused ID overwrites the earlier owner instead of adding a
+// second record and the public keyspace alone cannot show it. Instead an ID is reported when a
+// public owner record holds an ID other than the one in its key, or when a private document index
+// entry points at an owner whose private record now belongs to a different document number.
+// Reading the document index requires the caller to belong to the peer's organization.
+func (sc *FabricVulnBenchmark) FindDuplicateOwnerIDs(ctx contractapi.TransactionContextInterface) ([]int, error) {
+	stub := ctx.GetStub()
+
+	err := verifyCollectionMembership(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	reported := make(map[int]bool)
+
+	ownerIterator, err := stub.GetStateByPartialCompositeKey("owner", []string{})
+	if err != nil {
+		return nil, fmt.Errorf("unable to interact with world state: %w", err)
+	}
+	defer ownerIterator.Close()
+
+	for ownerIterator.HasNext() {
+		queryResponse, err := ownerIterator.Next()
+		if err != nil {
+			return nil, fmt.Errorf("unable to get next element: %w", err)
+		}
+
+		_, cKeyParts, err := stub.SplitCompositeKey(queryResponse.GetKey())
+		if err != nil {
+			return nil, fmt.Errorf("unable to split key: %w", err)
+		}
+
+		var owner Owner
+		err = json.Unmarshal(queryResponse.GetValue(), &owner)
+		if err != nil {
+			return nil, fmt.Errorf("unable to unmarshal owner: %w", err)
+		}
+
+		if strconv.Itoa(owner.ID) != cKeyParts[0] {
+			reported[owner.ID] = true
+		}
+	}
+
+	documentIterator, err := stub.GetPrivateDataByPartialCompositeKey(privateCollection, "documentHash", []string{})
+	if err != nil {
+		return nil, fmt.Errorf("unable to read private data: %w", err)
+	}
+	defer documentIterator.Close()
+
+	for documentIterator.HasNext() {
+		queryResponse, err := documentIterator.Next()
+		if err != nil {
+			return nil, fmt.Errorf("unable to get next element: %w", err)
+		}
+
+		_, cKeyParts, err := stub.SplitCompositeKey(queryResponse.GetKey())
+		if err != nil {
+			return nil, fmt.Errorf("unable to split key: %w", err)
+		}
+
+		ownerID, err := strconv.Atoi(string(queryResponse.GetValue()))
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse document index entry: %w", err)
+		}
+
+		ownerPrivateBytes, err := stub.GetPrivateData(privateCollection, strconv.Itoa(ownerID))
+		if err != nil {
+			return nil, fmt.Errorf("unable to read private data: %w", err)
+		}
+		if ownerPrivateBytes == nil {
+			reported[ownerID] = true
+			continue
+		}
+
+		var ownerPrivate Owner
+		err = json.Unmarshal(ownerPrivateBytes, &ownerPrivate)
+		if err != nil {
+			return nil, fmt.Errorf("unable to unmarshal owner: %w", err)
+		}
+
+		if documentHash(ownerPrivate.DocumentNumber) != cKeyParts[0] {
+			reported[ownerID] = true
+		}
+	}
+
+	duplicates := make([]int, 0, len(reported))
+	for id := range reported {
+		duplicates = append(duplicates, id)
+	}
+
+	sort.Ints(duplicates)
+
+	return duplicates, nil
+}
+
+// V: Non Determinism caused by concurrency (Go Routines), Math and Conversion overflow
+func (sc *FabricVulnBenchmark) UpdateAssetAmount(ctx contractapi.TransactionContextInterface, assetID, amountsJSON string) error {
+	amounts, err := parseAmountsJSON(amountsJSON)
+	if err != nil {
+		return err
+	}
+
+	asset, _, err := getAsset(ctx, assetID)
+	if err != nil {
+		return err
+	}
+	if asset == nil {
+		return newValidationError(ValidationNotFound, "cannot update world state pair with key %s. Does not exist", assetID)
+	}
+
+	err = assertNotFrozen(asset)
+	if err != nil {
+		return err
+	}
+
+	err = requireOwnerOrAdmin(ctx, asset.Owner)
+	if err != nil {
+		return err
+	}
+
+	err = assertActive(asset)
+	if err != nil {
+		return err
+	}
+
+	previousAmount := asset.Amount
+
+	totalCapacity, err := getTotalCapacity(ctx)
+	if err != nil {
+		return err
+	}
+
+	var wg sync.WaitGroup
+	for _, valueStr := range amounts {
+		wg.Add(1)
+		go func(incrementStr string) { // V: Concurrency
+			defer wg.Done()
+			value, _ := strconv.ParseInt(incrementStr, 10, 64) // V: Unhandled error leading to unsafe arithmetic
+
+			res := asset.Amount + int32(value)           // V: Math and Conversion overflow
+			if res <= int32(totalCapacity) && res >= 0 { // V: Conversion overflow
+				asset.Amount = res
+			}
+		}(valueStr)
+	}
+	wg.Wait()
+
+	if asset.Amount < asset.MinAmount {
+		return newValidationError(ValidationOutOfRange, "asset %s amount cannot drop below its minimum amount", assetID)
+	}
+
+	err = checkTypeCapacity(ctx, asset.AssetType, int64(asset.Amount)-int64(previousAmount))
+	if err != nil {
+		return err
+	}
+
+	err = recordOwnerIncrement(ctx, asset.Owner, int64(asset.Amount)-int64(previousAmount))
+	if err != nil {
+		return err
+	}
+
+	return sc.writeAsset(ctx, assetID, asset)
+}
+
+// IncrementAssetAmount applies a single signed delta using int64 arithmetic, so it cannot overflow,
+// and checks the result against the asset floor, the global capacity and the type capacity.
+func (sc *FabricVulnBenchmark) IncrementAssetAmount(ctx contractapi.TransactionContextInterface, assetID string, delta int32) (int32, error) {
+	asset, err := sc.ReadAsset(ctx, assetID)
+	if err != nil {
+		return 0, err
+	}
+
+	err = assertNotFrozen(asset)
+	if err != nil {
+		return 0, err
+	}
+
+	err = requireOwnerOrAdmin(ctx, asset.Owner)
+	if err != nil {
+		return 0, err
+	}
+
+	err = assertActive(asset)
+	if err != nil {
+		return 0, err
+	}
+
+	if delta == 0 {
+		return 0, newValidationError(ValidationOutOfRange, "delta must not be zero")
+	}
+
+	newAmount := int64(asset.Amount) + int64(delta)
+	amount, err := toInt32Checked(newAmount)
+	if err != nil {
+		return 0, newValidationError(ValidationOutOfRange, "asset %s amount would overflow", assetID)
+	}
+	if newAmount < int64(asset.MinAmount) {
+		return 0, newValidationError(ValidationOutOfRange, "asset %s amount cannot drop below its minimum amount", assetID)
+	}
+	capacity, err := getTotalCapacity(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if newAmount > capacity {
+		return 0, newValidationError(ValidationOutOfRange, "asset %s would exceed the total capacity", assetID)
+	}
+
+	err = checkTypeCapacity(ctx, asset.AssetType, int64(delta))
+	if err != nil {
+		return 0, err
+	}
+
+	err = recordOwnerIncrement(ctx, asset.Owner, int64(delta))
+	if err != nil {
+		return 0, err
+	}
+
+	asset.Amount = amount
+
+	err = sc.writeAsset(ctx, assetID, asset)
+	if err != nil {
+		return 0, err
+	}
+
+	return asset.Amount, nil
+}
+
+func (sc *FabricVulnBenchmark) SetAssetMinAmount(ctx contractapi.TransactionContextInterface, assetID string, minAmount int32) error {
+	if minAmount < 0 {
+		return newValidationError(ValidationOutOfRange, "minimum amount must not be negative")
+	}
+
+	asset, err := sc.ReadAsset(ctx, assetID)
+	if err != nil {
+		return err
+	}
+
+	err = assertNotFrozen(asset)
+	if err != nil {
+		return err
+	}
+
+	err = requireOwnerOrAdmin(ctx, asset.Owner)
+	if err != nil {
+		return err
+	}
+
+	err = assertActive(asset)
+	if err != nil {
+		return err
+	}
+
+	if asset.Amount < minAmount {
+		return newValidationError(ValidationOutOfRange, "asset %s amount is already below the requested minimum amount", assetID)
+	}
+
+	asset.MinAmount = minAmount
+
+	return sc.writeAsset(ctx, assetID, asset)
+}
+
+func (sc *FabricVulnBenchmark) TransferAmount(ctx contractapi.TransactionContextInterface, fromAssetID, toAssetID string, amount int32) error {
+	if amount <= 0 {
+		return newValidationError(ValidationOutOfRange, "amount must be positive")
+	}
+	if fromAssetID == toAssetID {
+		return errors.New("source and target assets must be different")
+	}
+
+	fromAsset, err := sc.ReadAsset(ctx, fromAssetID)
+	if err != nil {
+		return err
+	}
+
+	toAsset, err := sc.ReadAsset(ctx, toAssetID)
+	if err != nil {
+		return err
+	}
+
+	err = assertNotFrozen(fromAsset)
+	if err != nil {
+		return err
+	}
+	err = assertNotFrozen(toAsset)
+	if err != nil {
+		return err
+	}
+
+	err = requireOwnerOrAdmin(ctx, fromAsset.Owner)
+	if err != nil {
+		return err
+	}
+
+	err = assertActive(fromAsset)
+	if err != nil {
+		return err
+	}
+	err = assertActive(toAsset)
+	if err != nil {
+		return err
+	}
+
+	if fromAsset.Owner != toAsset.Owner {
+		return errors.New("assets must belong to the same owner")
+	}
+	err = assertSameType(fromAsset, toAsset)
+	if err != nil {
+		return err
+	}
+
+	if int64(fromAsset.Amount)-int64(amount) < int64(fromAsset.MinAmount) {
+		return newValidationError(ValidationOutOfRange, "asset %s has insufficient amount above its minimum amount", fromAssetID)
+	}
+	capacity, err := getTotalCapacity(ctx)
+	if err != nil {
+		return err
+	}
+	newToAmount := int64(toAsset.Amount) + int64(amount)
+	if newToAmount > capacity {
+		return newValidationError(ValidationOutOfRange, "asset %s would exceed the total capacity", toAssetID)
+	}
+	toAmount, err := toInt32Checked(newToAmount)
+	if err != nil {
+		return newValidationError(ValidationOutOfRange, "asset %s amount would overflow", toAssetID)
+	}
+
+	fromAsset.Amount -= amount
+	toAsset.Amount = toAmount
+
+	err = sc.writeAsset(ctx, fromAssetID, fromAsset)
+	if err != nil {
+		return err
+	}
+
+	return sc.writeAsset(ctx, toAssetID, toAsset)
+}
+
+// ReserveAmount moves amount out of an asset into a reservation keyed by the transaction ID.
+// The reserved amount cannot be spent until the reservation is released.
+func (sc *FabricVulnBenchmark) ReserveAmount(ctx contractapi.TransactionContextInterface, assetID string, amount int32) (string, error) {
+	stub := ctx.GetStub()
+
+	if amount <= 0 {
+		return "", newValidationError(ValidationOutOfRange, "amount must be positive")
+	}
+
+	asset, err := sc.ReadAsset(ctx, assetID)
+	if err != nil {
+		return "", err
+	}
+
+	err = assertNotFrozen(asset)
+	if err != nil {
+		return "", err
+	}
+
+	err = requireOwnerOrAdmin(ctx, asset.Owner)
+	if err != nil {
+		return "", err
+	}
+
+	err = assertActive(asset)
+	if err != nil {
+		return "", err
+	}
+
+	if int64(asset.Amount)-int64(amount) < int64(asset.MinAmount) {
+		return "", newValidationError(ValidationOutOfRange, "asset %s has insufficient amount above its minimum amount", assetID)
+	}
+
+	reservation := Reservation{ID: stub.GetTxID(), AssetID: assetID, Amount: amount}
+
+	reservationKey, err := stub.CreateCompositeKey("reservation", []string{reservation.ID})
+	if err != nil {
+		return "", fmt.Errorf("unable to create composite key: %w", err)
+	}
+
+	reservationBytes, err := marshalCanonical(reservation)
+	if err != nil {
+		return "", fmt.Errorf("unable to marshal reservation: %w", err)
+	}
+
+	err = stub.PutState(reservationKey, reservationBytes)
+	if err != nil {
+		return "", fmt.Errorf("unable to interact with world state: %w", err)
+	}
+
+	err = writeAuditRecord(ctx, "reservation", reservation.ID)
+	if err != nil {
+		return "", err
+	}
+
+	indexKey, err := stub.CreateCompositeKey("asset~reservation", []string{assetID, reservation.ID})
+	if err != nil {
+		return "", fmt.Errorf("unable to create composite key: %w", err)
+	}
+
+	err = stub.PutState(indexKey, []byte{0x00})
+	if err != nil {
+		return "", fmt.Errorf("unable to interact with world state: %w", err)
+	}
+
+	asset.Amount -= amount
+
+	err = sc.writeAsset(ctx, assetID, asset)
+	if err != nil {
+		return "", err
+	}
+
+	return reservation.ID, nil
+}
+
+// CommitReservation finalizes a reservation, consuming the reserved amount.
+func (sc *FabricVulnBenchmark) CommitReservation(ctx contractapi.TransactionContextInterface, reservationID string) error {
+	reservation, reservationKey, err := readReservation(ctx, reservationID)
+	if err != nil {
+		return err
+	}
+
+	asset, err := sc.ReadAsset(ctx, reservation.AssetID)
+	if err != nil {
+		return err
+	}
+
+	err = assertNotFrozen(asset)
+	if err != nil {
+		return err
+	}
+
+	err = requireOwnerOrAdmin(ctx, asset.Owner)
+	if err != nil {
+		return err
+	}
+
+	return deleteReservation(ctx, reservation, reservationKey)
+}
+
+// ReleaseReservation rolls back a reservation, returning the reserved amount to its asset.
+// Capacity freed by the reservation may have been used in the meantime, so the returned amount
+// is subject to the same total capacity and type capacity limits as IncrementAssetAmount.
+func (sc *FabricVulnBenchmark) ReleaseReservation(ctx contractapi.TransactionContextInterface, reservationID string) error {
+	reservation, reservationKey, err := readReservation(ctx, reservationID)
+	if err != nil {
+		return err
+	}
+
+	asset, err := sc.ReadAsset(ctx, reservation.AssetID)
+	if err != nil {
+		return err
+	}
+
+	err = assertNotFrozen(asset)
+	if err != nil {
+		return err
+	}
+
+	err = requireOwnerOrAdmin(ctx, asset.Owner)
+	if err != nil {
+		return err
+	}
+
+	err = assertActive(asset)
+	if err != nil {
+		return err
+	}
+
+	newAmount := int64(asset.Amount) + int64(reservation.Amount)
+	amount, err := toInt32Checked(newAmount)
+	if err != nil {
+		return newValidationError(ValidationOutOfRange, "asset %s amount would overflow", reservation.AssetID)
+	}
+	capacity, err := getTotalCapacity(ctx)
+	if err != nil {
+		return err
+	}
+	if newAmount > capacity {
+		return newValidationError(ValidationOutOfRange, "asset %s would exceed the total capacity", reservation.AssetID)
+	}
+
+	err = checkTypeCapacity(ctx, asset.AssetType, int64(reservation.Amount))
+	if err != nil {
+		return err
+	}
+
+	err = deleteReservation(ctx, reservation, reservationKey)
+	if err != nil {
+		return err
+	}
+
+	asset.Amount = amount
+
+	return sc.writeAsset(ctx, reservation.AssetID, asset)
+}
+
+// V: ReadAfterWrite
+func (sc *FabricVulnBenchmark) UpdateAssetDescription(ctx contractapi.TransactionContextInterface, assetID, description string) (*Asset, error) {
+	asset, _, err := getAsset(ctx, assetID)
+	if err != nil {
+		return nil, err
+	}
+	if asset == nil {
+		return nil, newValidationError(ValidationNotFound, "cannot update world state pair with key %s. Does not exist", assetID)
+	}
+
+	err = assertNotFrozen(asset)
+	if err != nil {
+		return nil, err
+	}
+
+	err = requireOwnerOrAdmin(ctx, asset.Owner)
+	if err != nil {
+		return nil, err
+	}
+
+	err = checkDescriptionLength(ctx, description)
+	if err != nil {
+		return nil, err
+	}
+
+	asset.Description = description
+
+	err = sc.writeAsset(ctx, assetID, asset)
+	if err != nil {
+		return nil, err
+	}
+
+	// V: ReadAfterWrite
+	asset, _, err = getAsset(ctx, assetID)
+	if err != nil {
+		return nil, err
+	}
+
+	return asset, nil
+}
+
+func (sc *FabricVulnBenchmark) UpdateAssetDescriptionIfVersion(ctx contractapi.TransactionContextInterface, assetID, description string, expectedVersion int) error {
+	asset, err := sc.ReadAsset(ctx, assetID)
+	if err != nil {
+		return err
+	}
+
+	err = assertNotFrozen(asset)
+	if err != nil {
+		return err
+	}
+
+	err = requireOwnerOrAdmin(ctx, asset.Owner)
+	if err != nil {
+		return err
+	}
+
+	if asset.Version != expectedVersion {
+		return fmt.Errorf("asset %s is at version %d, expected version %d", assetID, asset.Version, expectedVersion)
+	}
+
+	err = checkDescriptionLength(ctx, description)
+	if err != nil {
+		return err
+	}
+
+	asset.Description = description
+
+	return sc.writeAsset(ctx, assetID, asset)
+}
+
+func (sc *FabricVulnBenchmark) ReadAsset(ctx contractapi.TransactionContextInterface, assetID string) (*Asset, error) {
+	asset, _, err := getAsset(ctx, assetID)
+	if err != nil {
+		return nil, err
+	}
+	if asset == nil {
+		return nil, newValidationError(ValidationNotFound, "cannot read world state pair with key %s. Does not exist", assetID)
+	}
+
+	return asset, nil
+}
+
+// TouchAsset reads an asset without writing it, adding the asset key to the read set.
+// The transaction then fails MVCC validation if another transaction changes the asset before it commits.
+func (sc *FabricVulnBenchmark) TouchAsset(ctx contractapi.TransactionContextInterface, assetID string) error {
+	asset, _, err := getAsset(ctx, assetID)
+	if err != nil {
+		return err
+	}
+	if asset == nil {
+		return newValidationError(ValidationNotFound, "cannot read world state pair with key %s. Does not exist", assetID)
+	}
+
+	return nil
+}
+
+// GetAssetRaw returns the bytes stored under the asset key without unmarshaling, for debugging.
+func (sc *FabricVulnBenchmark) GetAssetRaw(ctx contractapi.TransactionContextInterface, assetID string) (string, error) {
+	asset, assetBytes, err := getAsset(ctx, assetID)
+	if err != nil {
+		return "", err
+	}
+	if asset == nil {
+		return "", newValidationError(ValidationNotFound, "cannot read world state pair with key %s. Does not exist", assetID)
+	}
+
+	return string(assetBytes), nil
+}
+
+// GetAssetField returns a single field of an asset formatted as a string.
+func (sc *FabricVulnBenchmark) GetAssetField(ctx contractapi.TransactionContextInterface, assetID, fieldName string) (string, error) {
+	asset, _, err := getAsset(ctx, assetID)
+	if err != nil {
+		return "", err
+	}
+	if asset == nil {
+		return "", newValidationError(ValidationNotFound, "cannot read world state pair with key %s. Does not exist", assetID)
+	}
+
+	switch fieldName {
+	case "assetType":
+		return asset.AssetType, nil
+	case "id":
+		return asset.ID, nil
+	case "description":
+		return asset.Description, nil
+	case "amount":
+		return strconv.FormatInt(int64(asset.Amount), 10), nil
+	case "owner":
+		return asset.Owner, nil
+	case "creationTime":
+		return asset.CreationTime, nil
+	case "archived":
+		return strconv.FormatBool(asset.Archived), nil
+	case "minAmount":
+		return strconv.FormatInt(int64(asset.MinAmount), 10), nil
+	case "createdBy":
+		return asset.CreatedBy, nil
+	case "version":
+		return strconv.Itoa(asset.Version), nil
+	case "frozen":
+		return strconv.FormatBool(asset.Frozen), nil
+	case "pendingOwner":
+		return asset.PendingOwner, nil
+	case "retiredAt":
+		return asset.RetiredAt, nil
+	case "tags":
+		return strings.Join(asset.Tags, ","), nil
+	case "privateCollection":
+		return assetCollection(asset), nil
+	default:
+		return "", newValidationError(ValidationInvalidFormat, "unknown asset field %s", fieldName)
+	}
+}
+
+// ReadAssetAtTxID returns the asset value written by the given transaction.
+func (sc *FabricVulnBenchmark) ReadAssetAtTxID(ctx contractapi.TransactionContextInterface, assetID, txID string) (*Asset, error) {
+	stub := ctx.GetStub()
+
+	if err := validateKeyComponent(assetID); err != nil {
+		return nil, err
+	}
+
+	assetKey, err := stub.CreateCompositeKey("asset", []string{assetID})
+	if err != nil {
+		return nil, fmt.Errorf("unable to create composite key: %w", err)
+	}
+
+	iterator, err := stub.GetHistoryForKey(assetKey)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get history for key: %w", err)
+	}
+	defer iterator.Close()
+
+	for iterator.HasNext() {
+		modification, err := iterator.Next()
+		if err != nil {
+			return nil, fmt.Errorf("unable to get next history element: %w", err)
+		}
+
+		if modification.GetTxId() != txID {
+			continue
+		}
+
+		if modification.GetIsDelete() {
+			return nil, newValidationError(ValidationNotFound, "asset %s was deleted by transaction %s", assetID, txID)
+		}
+
+		var asset Asset
+		err = json.Unmarshal(modification.GetValue(), &asset)
+		if err != nil {
+			return nil, fmt.Errorf("unable to unmarshal asset: %w", err)
+		}
+
+		return &asset, nil
+	}
+
+	return nil, newValidationError(ValidationNotFound, "transaction %s did not modify asset %s", txID, assetID)
+}
+
+// GetAuditLog returns the audit records of every write to an asset, oldest first.
+// Only the asset's own range of the audit~key~txid keyspace is read.
+func (sc *FabricVulnBenchmark) GetAuditLog(ctx contractapi.TransactionContextInterface, assetID string) ([]AuditRecord, error) {
+	if err := validateKeyComponent(assetID); err != nil {
+		return nil, err
+	}
+
+	return readAuditLog(ctx, "asset", assetID)
+}
+
+// GetHierarchicalAssetAuditLog returns the audit records of every write to a hierarchical asset, oldest first.
+func (sc *FabricVulnBenchmark) GetHierarchicalAssetAuditLog(ctx contractapi.TransactionContextInterface, assetType, serial string) ([]AuditRecord, error) {
+	if err := validateKeyComponent(assetType); err != nil {
+		return nil, err
+	}
+	if err := validateKeyComponent(serial); err != nil {
+		return nil, err
+	}
+
+	return readAuditLog(ctx, "hierarchicalAsset", assetType, serial)
+}
+
+// readAuditLog returns the audit records of the state key built from objectType and keyParts in chronological order.
+func readAuditLog(ctx contractapi.TransactionContextInterface, objectType string, keyParts ...string) ([]AuditRecord, error) {
+	iterator, err := ctx.GetStub().GetStateByPartialCompositeKey("audit~key~txid", append([]string{objectType}, keyParts...))
+	if err != nil {
+		return nil, fmt.Errorf("unable to interact with world state: %w", err)
+	}
+	defer iterator.Close()
+
+	records := make([]AuditRecord, 0)
+	for iterator.HasNext() {
+		queryResponse, err := iterator.Next()
+		if err != nil {
+			return nil, fmt.Errorf("unable to get next element: %w", err)
+		}
+
+		var record AuditRecord
+		err = json.Unmarshal(queryResponse.GetValue(), &record)
+		if err != nil {
+			return nil, fmt.Errorf("unable to unmarshal audit record: %w", err)
+		}
+
+		records = append(records, record)
+	}
+
+	// Keys are ordered by transaction ID, so sort by timestamp to get a chronological log.
+	sort.SliceStable(records, func(i, j int) bool {
+		if records[i].Timestamp != records[j].Timestamp {
+			return records[i].Timestamp < records[j].Timestamp
+		}
+		return records[i].TxID < records[j].TxID
+	})
+
+	return records, nil
+}
+
+// GetAssetOwnershipChain returns the ownership changes of an asset in chronological order.
+// Consecutive versions with the same owner are collapsed into the first one and deletions are skipped.
+func (sc *FabricVulnBenchmark) GetAssetOwnershipChain(ctx contractapi.TransactionContextInterface, assetID string) ([]OwnershipRecord, error) {
+	stub := ctx.GetStub()
+
+	if err := validateKeyComponent(assetID); err != nil {
+		return nil, err
+	}
+
+	assetKey, err := stub.CreateCompositeKey("asset", []string{assetID})
+	if err != nil {
+		return nil, fmt.Errorf("unable to create composite key: %w", err)
+	}
+
+	iterator, err := stub.GetHistoryForKey(assetKey)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get history for key: %w", err)
+	}
+	defer iterator.Close()
+
+	type version struct {
+		owner     string
+		txID      string
+		timestamp time.Time
+	}
+
+	var versions []version
+	for iterator.HasNext() {
+		modification, err := iterator.Next()
+		if err != nil {
+			return nil, fmt.Errorf("unable to get next history element: %w", err)
+		}
+
+		if modification.GetIsDelete() {
+			continue
+		}
+
+		var asset Asset
+		err = json.Unmarshal(modification.GetValue(), &asset)
+		if err != nil {
+			return nil, fmt.Errorf("unable to unmarshal asset: %w", err)
+		}
+
+		versions = append(versions, version{
+			owner:     asset.Owner,
+			txID:      modification.GetTxId(),
+			timestamp: modification.GetTimestamp().AsTime(),
+		})
+	}
+
+	// The history order is not guaranteed to be chronological, so sort it before collapsing.
+	sort.SliceStable(versions, func(i, j int) bool {
+		return versions[i].timestamp.Before(versions[j].timestamp)
+	})
+
+	chain := make([]OwnershipRecord, 0, len(versions))
+	for _, v := range versions {
+		if len(chain) > 0 && chain[len(chain)-1].Owner == v.owner {
+			continue
+		}
+
+		chain = append(chain, OwnershipRecord{
+			Owner:     v.owner,
+			TxID:      v.txID,
+			Timestamp: v.timestamp.UTC().Format(time.RFC3339),
+		})
+	}
+
+	return chain, nil
+}
+
+func (sc *FabricVulnBenchmark) ReadAssetPrivateDetails(ctx contractapi.TransactionContextInterface, assetID string) (string, error) {
+	err := verifyCollectionMembership(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	collection, err := storedAssetCollection(ctx, assetID)
+	if err != nil {
+		return "", err
+	}
+
+	privateDetails, err := readAssetPrivateDetails(ctx, collection, assetID)
+	if err != nil {
+		return "", err
+	}
+	if privateDetails == nil || privateDetails.Secret == "" {
+		return "", newValidationError(ValidationNotFound, "cannot read private details for asset %s. Does not exist", assetID)
+	}
+
+	return privateDetails.Secret, nil
+}
+
+func (sc *FabricVulnBenchmark) ReadAssetPrivateDescription(ctx contractapi.TransactionContextInterface, assetID string) (string, error) {
+	err := verifyCollectionMembership(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	collection, err := storedAssetCollection(ctx, assetID)
+	if err != nil {
+		return "", err
+	}
+
+	privateDetails, err := readAssetPrivateDetails(ctx, collection, assetID)
+	if err != nil {
+		return "", err
+	}
+	if privateDetails == nil || privateDetails.Description == "" {
+		return "", newValidationError(ValidationNotFound, "cannot read private description for asset %s. Does not exist", assetID)
+	}
+
+	return privateDetails.Description, nil
+}
+
+// ReassignAssetCollection moves the private details of an asset to another private collection.
+func (sc *FabricVulnBenchmark) ReassignAssetCollection(ctx contractapi.TransactionContextInterface, assetID, newCollection string) error {
+	stub := ctx.GetStub()
+
+	err := requireAdmin(ctx)
+	if err != nil {
+		return err
+	}
+
+	if newCollection == "" {
+		return newValidationError(ValidationEmptyField, "collection must not be empty")
+	}
+
+	asset, err := sc.ReadAsset(ctx, assetID)
+	if err != nil {
+		return err
+	}
+
+	err = assertNotFrozen(asset)
+	if err != nil {
+		return err
+	}
+
+	oldCollection := assetCollection(asset)
+	if oldCollection == newCollection {
+		return fmt.Errorf("asset %s is already stored in collection %s", assetID, newCollection)
+	}
+
+	privateDetails, err := readAssetPrivateDetails(ctx, oldCollection, assetID)
+	if err != nil {
+		return err
+	}
+	if privateDetails == nil {
+		return newValidationError(ValidationNotFound, "cannot read private details for asset %s. Does not exist", assetID)
+	}
+
+	err = writeAssetPrivateDetails(ctx, newCollection, privateDetails)
+	if err != nil {
+		return err
+	}
+
+	assetKey, err := stub.CreateCompositeKey("asset", []string{assetID})
+	if err != nil {
+		return fmt.Errorf("unable to create composite key: %w", err)
+	}
+
+	err = stub.DelPrivateData(oldCollection, assetKey)
+	if err != nil {
+		return fmt.Errorf("unable to delete private data: %w", err)
+	}
+
+	asset.PrivateCollection = newCollection
+	if newCollection == privateCollection {
+		asset.PrivateCollection = ""
+	}
+
+	return sc.writeAsset(ctx, assetID, asset)
+}
+
+func (sc *FabricVulnBenchmark) ReadAssets(ctx contractapi.TransactionContextInterface, idsJSON string, skipMissing bool) ([]Asset, error) {
+	var assetIDs []string
+	if err := decodeStrict(idsJSON, &assetIDs); err != nil {
+		return nil, fmt.Errorf("asset IDs must be a JSON array of strings: %w", err)
+	}
+
+	assets := make([]Asset, 0, len(assetIDs))
+	for _, assetID := range assetIDs {
+		asset, found, err := tryReadAsset(ctx, assetID)
+		if err != nil {
+			return nil, err
+		}
+
+		if !found {
+			if skipMissing {
+				continue
+			}
+			return nil, newValidationError(ValidationNotFound, "cannot read world state pair with key %s. Does not exist", assetID)
+		}
+
+		assets = append(assets, *asset)
+	}
+
+	return assets, nil
+}
+
+// TryReadAsset reports a missing asset through Found instead of an error.
+// Contract functions may return at most two values, so the lookup is wrapped in a struct.
+func (sc *FabricVulnBenchmark) TryReadAsset(ctx contractapi.TransactionContextInterface, assetID string) (*AssetLookupResult, error) {
+	asset, found, err := tryReadAsset(ctx, assetID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AssetLookupResult{Asset: asset, Found: found}, nil
+}
+
+// GetAssetWithIntegrity returns the stored asset unchanged and flags whether its amount is within [0, totalCapacity].
+// The flag is returned inside a struct since contract functions may return at most two values.
+func (sc *FabricVulnBenchmark) GetAssetWithIntegrity(ctx contractapi.TransactionContextInterface, assetID string) (*AssetIntegrityResult, error) {
+	asset, err := sc.ReadAsset(ctx, assetID)
+	if err != nil {
+		return nil, err
+	}
+
+	capacity, err := getTotalCapacity(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AssetIntegrityResult{Asset: asset, Valid: amountInRange(asset, capacity)}, nil
+}
+
+// V: ReadAfterWrite - Interprocedural
+func (sc *FabricVulnBenchmark) UpdateAssetDescriptionInterprocedural(ctx contractapi.TransactionContextInterface, assetID, description string) (*Asset, error) {
+	asset, err := sc.ReadAsset(ctx, assetID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = assertNotFrozen(asset)
+	if err != nil {
+		return nil, err
+	}
+
+	err = requireOwnerOrAdmin(ctx, asset.Owner)
+	if err != nil {
+		return nil, err
+	}
+
+	err = checkDescriptionLength(ctx, description)
+	if err != nil {
+		return nil, err
+	}
+
+	asset.Description = description
+
+	err = sc.writeAsset(ctx, assetID, asset)
+	if err != nil {
+		return nil, err
+	}
+
+	// V: ReadAfterWrite
+	return sc.ReadAsset(ctx, assetID)
+}
+
+// ReadAllAssets returns an empty, non-nil slice when no assets exist.
+// Use IsInitialized to tell a contract that was never initialized apart from an empty one.
+func (sc *FabricVulnBenchmark) ReadAllAssets(ctx contractapi.TransactionContextInterface) ([]Asset, error) {
+	return sc.readAllAssets(ctx, false)
+}
+
+// ReadAllAssetsLimited returns all non-archived assets sorted by ID, failing when there are more than limit.
+func (sc *FabricVulnBenchmark) ReadAllAssetsLimited(ctx contractapi.TransactionContextInterface, limit int) ([]Asset, error) {
+	if limit <= 0 {
+		return nil, newValidationError(ValidationOutOfRange, "limit must be positive")
+	}
+
+	assets, err := scanAssets(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(assets) > limit {
+		return nil, newValidationError(ValidationOutOfRange, "%d assets exceed the limit of %d, use QueryAssetsPaginated instead", len(assets), limit)
+	}
+
+	return assets, nil
+}
+
+// SampleAssets picks up to n non-archived assets by ranking them on the SHA-256 of seed and ID.
+// The same seed always selects the same assets, so endorsers agree without any randomness.
+// The sample is returned sorted by ID.
+func (sc *FabricVulnBenchmark) SampleAssets(ctx contractapi.TransactionContextInterface, n int, seed string) ([]Asset, error) {
+	if n <= 0 {
+		return nil, newValidationError(ValidationOutOfRange, "sample size must be positive")
+	}
+
+	assets, err := scanAssets(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+
+	ranks := make(map[string]string, len(assets))
+	for _, asset := range assets {
+		digest := sha256.Sum256([]byte(seed + "\x00" + asset.ID))
+		ranks[asset.ID] = hex.EncodeToString(digest[:])
+	}
+
+	sort.SliceStable(assets, func(i, j int) bool {
+		return ranks[assets[i].ID] < ranks[assets[j].ID]
+	})
+
+	if len(assets) > n {
+		assets = assets[:n]
+	}
+
+	sortAssetsByID(assets)
+
+	return assets, nil
+}
+
+func (sc *FabricVulnBenchmark) ReadAllAssetsIncludingArchived(ctx contractapi.TransactionContextInterface) ([]Asset, error) {
+	return sc.readAllAssets(ctx, true)
+}
+
+// V: Range over map.
+func (sc *FabricVulnBenchmark) readAllAssets(ctx contractapi.TransactionContextInterface, includeArchived bool) ([]Asset, error) {
+	stub := ctx.GetStub()
+
+	iterator, err := stub.GetStateByPartialCompositeKey("asset", []string{})
+	if err != nil {
+		return nil, fmt.Errorf("unable to interact with world state: %w", err)
+	}
+	defer iterator.Close()
+
+	var assetsMap = make(map[string]Asset)
+	for iterator.HasNext() {
+		queryResponse, err := iterator.Next()
+		if err != nil {
+			return nil, fmt.Errorf("unable to get next element: %w", err)
+		}
+
+		_, cKeyParts, err := stub.SplitCompositeKey(queryResponse.GetKey())
+		if err != nil {
+			return nil, fmt.Errorf("unable to split key: %w", err)
+		}
+
+		var asset Asset
+		err = json.Unmarshal(queryResponse.GetValue(), &asset)
+		if err != nil {
+			return nil, fmt.Errorf("unable to unmarshal: %w", err)
+		}
+
+		if asset.Archived && !includeArchived {
+			continue
+		}
+
+		// cKeyParts[0] is the assetKey
+		assetsMap[cKeyParts[0]] = asset
+	}
+
+	var assets = make([]Asset, 0, 100)
+	// V: Range over map.
+	for _, asset := range assetsMap {
+		assets = append(assets, asset)
+	}
+
+	return assets, nil
+}
+
+func (sc *FabricVulnBenchmark) GetAssetsByAmountRange(ctx contractapi.TransactionContextInterface, minAmount, maxAmount int32) ([]Asset, error) {
+	if minAmount > maxAmount {
+		return nil, newValidationError(ValidationOutOfRange, "minimum amount must not be greater than maximum amount")
+	}
+
+	assets, err := scanAssets(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+
+	filtered := make([]Asset, 0, len(assets))
+	for _, asset := range assets {
+		if asset.Amount >= minAmount && asset.Amount <= maxAmount {
+			filtered = append(filtered, asset)
+		}
+	}
+
+	return filtered, nil
+}
+
+func (sc *FabricVulnBenchmark) GetAssetsAboveAmount(ctx contractapi.TransactionContextInterface, threshold int32) ([]Asset, error) {
+	assets, err := scanAssets(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+
+	filtered := make([]Asset, 0, len(assets))
+	for _, asset := range assets {
+		if asset.Amount > threshold {
+			filtered = append(filtered, asset)
+		}
+	}
+
+	return filtered, nil
+}
+
+func (sc *FabricVulnBenchmark) GetAssetsByType(ctx contractapi.TransactionContextInterface, assetType string) ([]Asset, error) {
+	if assetType == "" {
+		return nil, newValidationError(ValidationEmptyField, "asset type must not be empty")
+	}
+
+	assets, err := scanAssets(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+
+	filtered := make([]Asset, 0, len(assets))
+	for _, asset := range assets {
+		if asset.AssetType == assetType {
+			filtered = append(filtered, asset)
+		}
+	}
+
+	return filtered, nil
+}
+
+// GetAssetCountsByType counts non-archived assets per type. JSON encoding sorts the map keys.
+func (sc *FabricVulnBenchmark) GetAssetCountsByType(ctx contractapi.TransactionContextInterface) (map[string]int, error) {
+	assets, err := scanAssets(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int)
+	for _, asset := range assets {
+		counts[asset.AssetType]++
+	}
+
+	return counts, nil
+}
+
+// GetDistinctAssetTypes returns the sorted asset types used by stored assets, archived ones included.
+func (sc *FabricVulnBenchmark) GetDistinctAssetTypes(ctx contractapi.TransactionContextInterface) ([]string, error) {
+	assets, err := scanAssets(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	assetTypes := make([]string, 0)
+	for _, asset := range assets {
+		if !seen[asset.AssetType] {
+			seen[asset.AssetType] = true
+			assetTypes = append(assetTypes, asset.AssetType)
+		}
+	}
+
+	sort.Strings(assetTypes)
+
+	return assetTypes, nil
+}
+
+func (sc *FabricVulnBenchmark) GetAssetsByTypeAndOwner(ctx contractapi.TransactionContextInterface, assetType, ownerID string) ([]Asset, error) {
+	if assetType == "" {
+		return nil, newValidationError(ValidationEmptyField, "asset type must not be empty")
+	}
+	if ownerID == "" {
+		return nil, newValidationError(ValidationEmptyField, "owner ID must not be empty")
+	}
+
+	assets, err := scanAssets(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+
+	filtered := make([]Asset, 0, len(assets))
+	for _, asset := range assets {
+		if asset.AssetType == assetType && asset.Owner == ownerID {
+			filtered = append(filtered, asset)
+		}
+	}
+
+	return filtered, nil
+}
+
+func (sc *FabricVulnBenchmark) GetAssetsByCreator(ctx contractapi.TransactionContextInterface, creatorID string) ([]Asset, error) {
+	if creatorID == "" {
+		return nil, newValidationError(ValidationEmptyField, "creator ID must not be empty")
+	}
+
+	assets, err := scanAssets(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+
+	filtered := make([]Asset, 0, len(assets))
+	for _, asset := range assets {
+		if asset.CreatedBy == creatorID {
+			filtered = append(filtered, asset)
+		}
+	}
+
+	return filtered, nil
+}
+
+func (sc *FabricVulnBenchmark) GetTotalAmountByOwner(ctx contractapi.TransactionContextInterface, ownerID string) (int64, error) {
+	if ownerID == "" {
+		return 0, newValidationError(ValidationEmptyField, "owner ID must not be empty")
+	}
+
+	assets, err := scanAssets(ctx, true)
+	if err != nil {
+		return 0, err
+	}
+
+	var total int64
+	for _, asset := range assets {
+		if asset.Owner == ownerID {
+			total += int64(asset.Amount)
+		}
+	}
+
+	return total, nil
+}
+
+// GetOwnerStatement lists the assets and total amount of an owner.
+// The private name is only included when the caller's org is a member of the private collection.
+func (sc *FabricVulnBenchmark) GetOwnerStatement(ctx contractapi.TransactionContextInterface, ownerID string) (*OwnerStatement, error) {
+	owner, err := readOwner(ctx, ownerID)
+	if err != nil {
+		return nil, err
+	}
+
+	assets, err := scanAssets(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+
+	statement := &OwnerStatement{OwnerID: owner.ID, Assets: make([]Asset, 0)}
+	for _, asset := range assets {
+		if asset.Owner == ownerID {
+			statement.Assets = append(statement.Assets, asset)
+			statement.TotalAmount += int64(asset.Amount)
+		}
+	}
+
+	if verifyCollectionMembership(ctx) != nil {
+		return statement, nil
+	}
+
+	ownerPrivateBytes, err := ctx.GetStub().GetPrivateData(privateCollection, ownerID)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read private data: %w", err)
+	}
+	if ownerPrivateBytes != nil {
+		var ownerPrivate Owner
+		err = json.Unmarshal(ownerPrivateBytes, &ownerPrivate)
+		if err != nil {
+			return nil, fmt.Errorf("unable to unmarshal owner: %w", err)
+		}
+
+		statement.Name = ownerPrivate.Name
+	}
+
+	return statement, nil
+}
+
+func (sc *FabricVulnBenchmark) GetAssetsModifiedAfter(ctx contractapi.TransactionContextInterface, rfc3339 string) ([]Asset, error) {
+	stub := ctx.GetStub()
+
+	after, err := time.Parse(time.RFC3339, rfc3339)
+	if err != nil {
+		return nil, fmt.Errorf("time must be in RFC 3339 format: %w", err)
+	}
+
+	assets, err := scanAssets(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+
+	filtered := make([]Asset, 0, len(assets))
+	for _, asset := range assets {
+		assetKey, err := stub.CreateCompositeKey("asset", []string{asset.ID})
+		if err != nil {
+			return nil, fmt.Errorf("unable to create composite key: %w", err)
+		}
+
+		modified, err := latestModification(ctx, assetKey)
+		if err != nil {
+			return nil, err
+		}
+
+		if modified.After(after) {
+			filtered = append(filtered, asset)
+		}
+	}
+
+	return filtered, nil
+}
+
+// GetAssetsCreatedBetween returns assets whose CreationTime lies within the inclusive range.
+// Legacy assets whose CreationTime is not RFC 3339 are skipped; GetAssetsNeedingRepair counts them.
+func (sc *FabricVulnBenchmark) GetAssetsCreatedBetween(ctx contractapi.TransactionContextInterface, startRFC3339, endRFC3339 string) ([]Asset, error) {
+	start, err := time.Parse(time.RFC3339, startRFC3339)
+	if err != nil {
+		return nil, newValidationError(ValidationInvalidFormat, "start time must be in RFC 3339 format")
+	}
+
+	end, err := time.Parse(time.RFC3339, endRFC3339)
+	if err != nil {
+		return nil, newValidationError(ValidationInvalidFormat, "end time must be in RFC 3339 format")
+	}
+
+	if start.After(end) {
+		return nil, newValidationError(ValidationOutOfRange, "start time must not be after end time")
+	}
+
+	assets, err := scanAssets(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+
+	filtered := make([]Asset, 0, len(assets))
+	for _, asset := range assets {
+		created, err := time.Parse(time.RFC3339, asset.CreationTime)
+		if err != nil {
+			continue
+		}
+
+		if !created.Before(start) && !created.After(end) {
+			filtered = append(filtered, asset)
+		}
+	}
+
+	return filtered, nil
+}
+
+func (sc *FabricVulnBenchmark) GetRemainingCapacity(ctx contractapi.TransactionContextInterface) (int64, error) {
+	used, err := sumAssetAmounts(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	capacity, err := getTotalCapacity(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	return capacity - used, nil
+}
+
+// VerifyCapacityIntegrity returns a report rather than (bool, int64) since contract functions
+// may return at most two values.
+func (sc *FabricVulnBenchmark) VerifyCapacityIntegrity(ctx contractapi.TransactionContextInterface) (*CapacityIntegrityReport, error) {
+	total, err := sumAssetAmounts(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	capacity, err := getTotalCapacity(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &CapacityIntegrityReport{
+		WithinCapacity: total <= capacity,
+		Total:          total,
+		Capacity:       capacity,
+	}, nil
+}
+
+// GetAssetSummary counts and sums every asset, archived ones included, in a single scan.
+func (sc *FabricVulnBenchmark) GetAssetSummary(ctx contractapi.TransactionContextInterface) (*AssetSummary, error) {
+	assets, err := scanAssets(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+
+	capacity, err := getTotalCapacity(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &AssetSummary{
+		AssetCount:    len(assets),
+		CountByType:   make(map[string]int),
+		TotalCapacity: capacity,
+	}
+	for _, asset := range assets {
+		summary.TotalAmount += int64(asset.Amount)
+		summary.CountByType[asset.AssetType]++
+	}
+	summary.UsedCapacity = summary.TotalAmount
+
+	return summary, nil
+}
+
+func (sc *FabricVulnBenchmark) ExportAssetsNDJSON(ctx contractapi.TransactionContextInterface) (string, error) {
+	assets, err := scanAssets(ctx, false)
+	if err != nil {
+		return "", err
+	}
+
+	var builder strings.Builder
+	for _, asset := range assets {
+		assetBytes, err := marshalCanonical(asset)
+		if err != nil {
+			return "", fmt.Errorf("unable to marshal asset: %w", err)
+		}
+
+		builder.Write(assetBytes)
+		builder.WriteByte('\n')
+	}
+
+	return builder.String(), nil
+}
+
+// ExportAssetsCSV writes non-archived assets as CSV with a header row, in ID order.
+func (sc *FabricVulnBenchmark) ExportAssetsCSV(ctx contractapi.TransactionContextInterface) (string, error) {
+	assets, err := scanAssets(ctx, false)
+	if err != nil {
+		return "", err
+	}
+
+	var builder strings.Builder
+	writer := csv.NewWriter(&builder)
+
+	records := [][]string{{"id", "assetType", "description", "amount", "owner", "creationTime", "archived", "minAmount", "createdBy", "version", "frozen", "pendingOwner", "retiredAt", "tags", "privateCollection"}}
+	for _, asset := range assets {
+		records = append(records, []string{
+			asset.ID,
+			asset.AssetType,
+			asset.Description,
+			strconv.FormatInt(int64(asset.Amount), 10),
+			asset.Owner,
+			asset.CreationTime,
+			strconv.FormatBool(asset.Archived),
+			strconv.FormatInt(int64(asset.MinAmount), 10),
+			asset.CreatedBy,
+			strconv.Itoa(asset.Version),
+			strconv.FormatBool(asset.Frozen),
+			asset.PendingOwner,
+			asset.RetiredAt,
+			strings.Join(asset.Tags, ","),
+			assetCollection(&asset),
+		})
+	}
+
+	err = writer.WriteAll(records)
+	if err != nil {
+		return "", fmt.Errorf("unable to write CSV: %w", err)
+	}
+
+	return builder.String(), nil
+}
+
+// AddAssetTag adds a tag to an asset. Tags are kept sorted and unique, and adding an existing tag is a no-op.
+func (sc *FabricVulnBenchmark) AddAssetTag(ctx contractapi.TransactionContextInterface, assetID, tag string) error {
+	return sc.updateAssetTags(ctx, assetID, tag, true)
+}
+
+// RemoveAssetTag removes a tag from an asset. Removing a missing tag is a no-op.
+func (sc *FabricVulnBenchmark) RemoveAssetTag(ctx contractapi.TransactionContextInterface, assetID, tag string) error {
+	return sc.updateAssetTags(ctx, assetID, tag, false)
+}
+
+func (sc *FabricVulnBenchmark) updateAssetTags(ctx contractapi.TransactionContextInterface, assetID, tag string, add bool) error {
+	if tag == "" {
+		return newValidationError(ValidationEmptyField, "tag must not be empty")
+	}
+
+	asset, err := sc.ReadAsset(ctx, assetID)
+	if err != nil {
+		return err
+	}
+
+	err = assertNotFrozen(asset)
+	if err != nil {
+		return err
+	}
+
+	err = requireOwnerOrAdmin(ctx, asset.Owner)
+	if err != nil {
+		return err
+	}
+
+	tags := make([]string, 0, len(asset.Tags)+1)
+	found := false
+	for _, existing := range asset.Tags {
+		if existing == tag {
+			found = true
+			if !add {
+				continue
+			}
+		}
+		tags = append(tags, existing)
+	}
+
+	if found == add {
+		return nil
+	}
+	if add {
+		tags = append(tags, tag)
+	}
+
+	asset.Tags = normalizeTags(tags)
+
+	return sc.writeAsset(ctx, assetID, asset)
+}
+
+func (sc *FabricVulnBenchmark) GetAssetsByTag(ctx contractapi.TransactionContextInterface, tag string) ([]Asset, error) {
+	if tag == "" {
+		return nil, newValidationError(ValidationEmptyField, "tag must not be empty")
+	}
+
+	assets, err := scanAssets(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+
+	filtered := make([]Asset, 0, len(assets))
+	for _, asset := range assets {
+		index := sort.SearchStrings(asset.Tags, tag)
+		if index < len(asset.Tags) && asset.Tags[index] == tag {
+			filtered = append(filtered, asset)
+		}
+	}
+
+	return filtered, nil
+}
+
+func (sc *FabricVulnBenchmark) ArchiveAsset(ctx contractapi.TransactionContextInterface, assetID string) error {
+	return sc.setAssetArchived(ctx, assetID, true)
+}
+
+func (sc *FabricVulnBenchmark) UnarchiveAsset(ctx contractapi.TransactionContextInterface, assetID string) error {
+	return sc.setAssetArchived(ctx, assetID, false)
+}
+
+func (sc *FabricVulnBenchmark) setAssetArchived(ctx contractapi.TransactionContextInterface, assetID string, archived bool) error {
+	asset, err := sc.ReadAsset(ctx, assetID)
+	if err != nil {
+		return err
+	}
+
+	err = assertNotFrozen(asset)
+	if err != nil {
+		return err
+	}
+
+	err = requireOwnerOrAdmin(ctx, asset.Owner)
+	if err != nil {
+		return err
+	}
+
+	if !archived && asset.RetiredAt != "" {
+		return fmt.Errorf("asset %s is retired and cannot be unarchived", assetID)
+	}
+
+	if asset.Archived == archived {
+		if archived {
+			return fmt.Errorf("asset %s is already archived", assetID)
+		}
+		return fmt.Errorf("asset %s is not archived", assetID)
+	}
+
+	asset.Archived = archived
+
+	return sc.writeAsset(ctx, assetID, asset)
+}
+
+// RetireAsset zeroes the amount of an asset, archives it and stamps it with the transaction timestamp.
+func (sc *FabricVulnBenchmark) RetireAsset(ctx contractapi.TransactionContextInterface, assetID string) error {
+	asset, err := sc.ReadAsset(ctx, assetID)
+	if err != nil {
+		return err
+	}
+
+	if asset.RetiredAt != "" {
+		return fmt.Errorf("asset %s is already retired", assetID)
+	}
+
+	err = assertNotFrozen(asset)
+	if err != nil {
+		return err
+	}
+
+	err = requireOwnerOrAdmin(ctx, asset.Owner)
+	if err != nil {
+		return err
+	}
+
+	err = assertNoReservations(ctx, assetID)
+	if err != nil {
+		return err
+	}
+
+	retiredAt, err := txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+
+	asset.Amount = 0
+	asset.Archived = true
+	asset.RetiredAt = retiredAt
+
+	err = sc.writeAsset(ctx, assetID, asset)
+	if err != nil {
+		return err
+	}
+
+	eventBytes, err := marshalCanonical(AssetRetiredEvent{AssetID: assetID, RetiredAt: retiredAt})
+	if err != nil {
+		return fmt.Errorf("unable to marshal event: %w", err)
+	}
+
+	err = ctx.GetStub().SetEvent("AssetRetired", eventBytes)
+	if err != nil {
+		return fmt.Errorf("unable to set event: %w", err)
+	}
+
+	return nil
+}
+
+// ChangeTotalCapacity is SetTotalCapacity taking the capacity as a decimal string.
+func (sc *FabricVulnBenchmark) ChangeTotalCapacity(ctx contractapi.TransactionContextInterface, valueStr string) error {
+	value, err := strconv.ParseInt(valueStr, 10, 64)
+	if err != nil {
+		return fmt.Errorf("unable to parse string to int: %w", err)
+	}
+
+	return sc.SetTotalCapacity(ctx, value)
+}
+
+// V: cross-channel invocation - simulation
+func (sc *FabricVulnBenchmark) TransferAnotherAsset(ctx contractapi.TransactionContextInterface, chaincodeName, ownerID, channel string) (string, error) {
+	stub := ctx.GetStub()
+
+	if chaincodeName == "" {
+		return "", newValidationError(ValidationEmptyField, "chaincode name must not be empty")
+	}
+	if ownerID == "" {
+		return "", newValidationError(ValidationEmptyField, "owner ID must not be empty")
+	}
+	if channel == "" {
+		return "", newValidationError(ValidationEmptyField, "channel must not be empty")
+	}
+
+	response := stub.InvokeChaincode(chaincodeName, toChaincodeArgs("TransferAnotherAsset", ownerID), channel)
+	if response.GetStatus() != shim.OK {
+		return "", fmt.Errorf("unable to invoke another chaincode: %s", response.GetMessage())
+	}
+
+	return string(response.GetPayload()), nil
+}
+
+func (sc *FabricVulnBenchmark) ReadAssetFromChannel(ctx contractapi.TransactionContextInterface, chaincodeName, assetID, channel string) (*Asset, error) {
+	stub := ctx.GetStub()
+
+	if chaincodeName == "" {
+		return nil, newValidationError(ValidationEmptyField, "chaincode name must not be empty")
+	}
+	if assetID == "" {
+		return nil, newValidationError(ValidationEmptyField, "asset ID must not be empty")
+	}
+	if channel == "" {
+		return nil, newValidationError(ValidationEmptyField, "channel must not be empty")
+	}
+
+	response := stub.InvokeChaincode(chaincodeName, toChaincodeArgs("ReadAsset", assetID), channel)
+	if response.GetStatus() != shim.OK {
+		return nil, fmt.Errorf("unable to invoke another chaincode: %s", response.GetMessage())
+	}
+
+	payload := response.GetPayload()
+	if len(payload) == 0 {
+		return nil, fmt.Errorf("empty payload reading asset %s from channel %s", assetID, channel)
+	}
+
+	var asset Asset
+	err := json.Unmarshal(payload, &asset)
+	if err != nil {
+		return nil, fmt.Errorf("unable to unmarshal asset: %w", err)
+	}
+
+	return &asset, nil
+}
+
+// QueryAssetsPaginated runs a read-only CouchDB selector query one page at a time.
+// Documents outside the asset keyspace are dropped from the page.
+func (sc *FabricVulnBenchmark) QueryAssetsPaginated(ctx contractapi.TransactionContextInterface, selectorJSON string, pageSize int32, bookmark string) (*PaginatedAssetResult, error) {
+	stub := ctx.GetStub()
+
+	if pageSize <= 0 {
+		return nil, newValidationError(ValidationOutOfRange, "page size must be positive")
+	}
+
+	var selector map[string]interface{}
+	if err := decodeStrict(selectorJSON, &selector); err != nil {
+		return nil, newValidationError(ValidationInvalidFormat, "selector must be a JSON object: %v", err)
+	}
+	if selector == nil {
+		return nil, newValidationError(ValidationInvalidFormat, "selector must be a JSON object")
+	}
+
+	queryBytes, err := marshalCanonical(map[string]interface{}{"selector": selector})
+	if err != nil {
+		return nil, fmt.Errorf("unable to marshal query: %w", err)
+	}
+
+	iterator, metadata, err := stub.GetQueryResultWithPagination(string(queryBytes), pageSize, bookmark)
+	if err != nil {
+		return nil, fmt.Errorf("unable to interact with world state: %w", err)
+	}
+	defer iterator.Close()
+
+	assets := make([]Asset, 0, pageSize)
+	for iterator.HasNext() {
+		queryResponse, err := iterator.Next()
+		if err != nil {
+			return nil, fmt.Errorf("unable to get next element: %w", err)
+		}
+
+		objectType, _, err := stub.SplitCompositeKey(queryResponse.GetKey())
+		if err != nil || objectType != "asset" {
+			continue
+		}
+
+		var asset Asset
+		err = json.Unmarshal(queryResponse.GetValue(), &asset)
+		if err != nil {
+			return nil, fmt.Errorf("unable to unmarshal asset: %w", err)
+		}
+
+		assets = append(assets, asset)
+	}
+
+	return &PaginatedAssetResult{
+		Assets:              assets,
+		FetchedRecordsCount: metadata.GetFetchedRecordsCount(),
+		Bookmark:            metadata.GetBookmark(),
+	}, nil
+}
+
+// GetAssetsByOwner returns the non-archived assets of an owner through the owner~asset index, sorted by ID.
+func (sc *FabricVulnBenchmark) GetAssetsByOwner(ctx contractapi.TransactionContextInterface, ownerID string) ([]Asset, error) {
+	stub := ctx.GetStub()
+
+	if ownerID == "" {
+		return nil, newValidationError(ValidationEmptyField, "owner ID must not be empty")
+	}
+
+	if err := validateKeyComponent(ownerID); err != nil {
+		return nil, err
+	}
+
+	iterator, err := stub.GetStateByPartialCompositeKey("owner~asset", []string{ownerID})
+	if err != nil {
+		return nil, fmt.Errorf("unable to interact with world state: %w", err)
+	}
+	defer iterator.Close()
+
+	assets := make([]Asset, 0)
+	for iterator.HasNext() {
+		queryResponse, err := iterator.Next()
+		if err != nil {
+			return nil, fmt.Errorf("unable to get next element: %w", err)
+		}
+
+		_, cKeyParts, err := stub.SplitCompositeKey(queryResponse.GetKey())
+		if err != nil {
+			return nil, fmt.Errorf("unable to split key: %w", err)
+		}
+
+		asset, _, err := getAsset(ctx, cKeyParts[1])
+		if err != nil {
+			return nil, err
+		}
+		if asset == nil || asset.Archived {
+			continue
+		}
+
+		assets = append(assets, *asset)
+	}
+
+	sortAssetsByID(assets)
+
+	return assets, nil
+}
+
+// GetAssetsByOwnerPaginated pages through the owner~asset index entries of one owner in asset ID order.
+// Archived assets are left out, so a page can hold fewer assets than pageSize while the bookmark still
+// points past every entry fetched.
+func (sc *FabricVulnBenchmark) GetAssetsByOwnerPaginated(ctx contractapi.TransactionContextInterface, ownerID string, pageSize int32, bookmark string) (*PaginatedAssetResult, error) {
+	stub := ctx.GetStub()
+
+	if ownerID == "" {
+		return nil, newValidationError(ValidationEmptyField, "owner ID must not be empty")
+	}
+	if pageSize <= 0 {
+		return nil, newValidationError(ValidationOutOfRange, "page size must be positive")
+	}
+
+	if err := validateKeyComponent(ownerID); err != nil {
+		return nil, err
+	}
+
+	iterator, metadata, err := stub.GetStateByPartialCompositeKeyWithPagination("owner~asset", []string{ownerID}, pageSize, bookmark)
+	if err != nil {
+		return nil, fmt.Errorf("unable to interact with world state: %w", err)
+	}
+	defer iterator.Close()
+
+	assets := make([]Asset, 0, pageSize)
+	for iterator.HasNext() {
+		queryResponse, err := iterator.Next()
+		if err != nil {
+			return nil, fmt.Errorf("unable to get next element: %w", err)
+		}
+
+		_, cKeyParts, err := stub.SplitCompositeKey(queryResponse.GetKey())
+		if err != nil {
+			return nil, fmt.Errorf("unable to split key: %w", err)
+		}
+
+		asset, _, err := getAsset(ctx, cKeyParts[1])
+		if err != nil {
+			return nil, err
+		}
+		if asset == nil || asset.Archived {
+			continue
+		}
+
+		assets = append(assets, *asset)
+	}
+
+	return &PaginatedAssetResult{
+		Assets:              assets,
+		FetchedRecordsCount: metadata.GetFetchedRecordsCount(),
+		Bookmark:            metadata.GetBookmark(),
+	}, nil
+}
+
+// V: Phantom Read
+func (sc *FabricVulnBenchmark) UpdateAssetsByType(ctx contractapi.TransactionContextInterface, assetType string) error {
+	stub := ctx.GetStub()
+
+	queryString := fmt.Sprintf(`{"selector":{"assetType":"%s"}}`, assetType)
+
+	resultsIterator, err := stub.GetQueryResult(queryString)
+	if err != nil {
+		return err
+	}
+	defer resultsIterator.Close()
+
+	for resultsIterator.HasNext() {
+		queryResult, err := resultsIterator.Next()
+		if err != nil {
+			return err
+		}
+		var asset Asset
+		err = json.Unmarshal(queryResult.GetValue(), &asset)
+		if err != nil {
+			return err
+		}
+
+		if asset.Frozen {
+			continue
+		}
+
+		asset.Amount += 1
+		asset.Version++
+
+		updatedAssetBytes, err := marshalCanonical(asset)
+		if err != nil {
+			return fmt.Errorf("unable to marshal asset: %w", err)
+		}
+
+		err = stub.PutState(queryResult.GetKey(), updatedAssetBytes)
+		if err != nil {
+			return fmt.Errorf("unable to interact with world state: %w", err)
+		}
+
+		// The selector also matches hierarchical assets, so the audit record uses the key that was written.
+		objectType, keyParts, err := stub.SplitCompositeKey(queryResult.GetKey())
+		if err != nil {
+			return fmt.Errorf("unable to split key: %w", err)
+		}
+
+		err = writeAuditRecord(ctx, objectType, keyParts...)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// UpdateAssetsByTypeSafe is UpdateAssetsByType without rich queries, so the read set can be revalidated at commit.
+// Archived and frozen assets are skipped. Each increment is subject to the same total capacity,
+// type capacity and daily owner limits as IncrementAssetAmount.
+// Assets are processed in ID order and a single AssetsUpdated event lists the updated IDs.
+func (sc *FabricVulnBenchmark) UpdateAssetsByTypeSafe(ctx contractapi.TransactionContextInterface, assetType string) (int, error) {
+	assets, err := scanAssets(ctx, false)
+	if err != nil {
+		return 0, err
+	}
+
+	capacity, err := getTotalCapacity(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	var matched []Asset
+	ownerIncrements := make(map[string]int64)
+	var owners []string
+	for _, asset := range assets {
+		if asset.AssetType != assetType || asset.Frozen {
+			continue
+		}
+
+		if int64(asset.Amount)+1 > capacity {
+			return 0, newValidationError(ValidationOutOfRange, "asset %s would exceed the total capacity", asset.ID)
+		}
+
+		matched = append(matched, asset)
+		if ownerIncrements[asset.Owner] == 0 {
+			owners = append(owners, asset.Owner)
+		}
+		ownerIncrements[asset.Owner]++
+	}
+
+	err = checkTypeCapacity(ctx, assetType, int64(len(matched)))
+	if err != nil {
+		return 0, err
+	}
+
+	// The increment window is not visible to later reads in this transaction,
+	// so each owner's increments are recorded in one call.
+	for _, ownerID := range owners {
+		err = recordOwnerIncrement(ctx, ownerID, ownerIncrements[ownerID])
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	updatedIDs := make([]string, 0, len(matched))
+	for i := range matched {
+		matched[i].Amount, err = toInt32Checked(int64(matched[i].Amount) + 1)
+		if err != nil {
+			return 0, newValidationError(ValidationOutOfRange, "asset %s amount would overflow", matched[i].ID)
+		}
+
+		err = sc.writeAsset(ctx, matched[i].ID, &matched[i])
+		if err != nil {
+			return 0, err
+		}
+
+		updatedIDs = append(updatedIDs, matched[i].ID)
+	}
+
+	eventBytes, err := marshalCanonical(AssetsUpdatedEvent{AssetType: assetType, AssetIDs: updatedIDs})
+	if err != nil {
+		return 0, fmt.Errorf("unable to marshal event: %w", err)
+	}
+
+	err = ctx.GetStub().SetEvent("AssetsUpdated", eventBytes)
+	if err != nil {
+		return 0, fmt.Errorf("unable to set event: %w", err)
+	}
+
+	return len(updatedIDs), nil
+}
+
+func (sc *FabricVulnBenchmark) SetTypeCapacity(ctx contractapi.TransactionContextInterface, assetType string, capacity int64) error {
+	stub := ctx.GetStub()
+
+	err := requireAdmin(ctx)
+	if err != nil {
+		return err
+	}
+
+	if assetType == "" {
+		return newValidationError(ValidationEmptyField, "asset type must not be empty")
+	}
+	if capacity < 0 {
+		return newValidationError(ValidationOutOfRange, "capacity must not be negative")
+	}
+
+	if err := validateKeyComponent(assetType); err != nil {
+		return err
+	}
+
+	capacityKey, err := stub.CreateCompositeKey("typeCapacity", []string{assetType})
+	if err != nil {
+		return fmt.Errorf("unable to create composite key: %w", err)
+	}
+
+	err = stub.PutState(capacityKey, []byte(strconv.FormatInt(capacity, 10)))
+	if err != nil {
+		return fmt.Errorf("unable to interact with world state: %w", err)
+	}
+
+	return writeAuditRecord(ctx, "typeCapacity", assetType)
+}
+
+// RegisterAssetType adds an asset type to the type registry.
+func (sc *FabricVulnBenchmark) RegisterAssetType(ctx contractapi.TransactionContextInterface, assetType string) error {
+	err := requireAdmin(ctx)
+	if err != nil {
+		return err
+	}
+
+	if assetType == "" {
+		return newValidationError(ValidationEmptyField, "asset type must not be empty")
+	}
+
+	return putAssetTypeRegistration(ctx, assetType)
+}
+
+// RebuildAssetTypeRegistry registers every asset type used by a stored asset and returns how many were found.
+// Registered types that no asset uses are kept.
+func (sc *FabricVulnBenchmark) RebuildAssetTypeRegistry(ctx contractapi.TransactionContextInterface) (int, error) {
+	err := requireAdmin(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	assetTypes, err := sc.GetDistinctAssetTypes(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	registered := 0
+	for _, assetType := range assetTypes {
+		if assetType == "" {
+			continue
+		}
+
+		err = putAssetTypeRegistration(ctx, assetType)
+		if err != nil {
+			return 0, err
+		}
+
+		registered++
+	}
+
+	return registered, nil
+}
+
+// ChangeAssetType moves an asset to another registered type, counting its amount against the new type's capacity.
+func (sc *FabricVulnBenchmark) ChangeAssetType(ctx contractapi.TransactionContextInterface, assetID, newType string) error {
+	if newType == "" {
+		return newValidationError(ValidationEmptyField, "asset type must not be empty")
+	}
+
+	asset, err := sc.ReadAsset(ctx, assetID)
+	if err != nil {
+		return err
+	}
+
+	err = assertNotFrozen(asset)
+	if err != nil {
+		return err
+	}
+
+	err = requireOwnerOrAdmin(ctx, asset.Owner)
+	if err != nil {
+		return err
+	}
+
+	if asset.AssetType == newType {
+		return errors.New("asset already has the requested type")
+	}
+
+	err = assertActive(asset)
+	if err != nil {
+		return err
+	}
+
+	err = requireRegisteredAssetType(ctx, newType)
+	if err != nil {
+		return err
+	}
+
+	err = checkTypeCapacity(ctx, newType, int64(asset.Amount))
+	if err != nil {
+		return err
+	}
+
+	asset.AssetType = newType
+
+	return sc.writeAsset(ctx, assetID, asset)
+}
+
+// SetMaxAssetsPerOwner limits how many assets a single owner may hold. Zero means unlimited.
+func (sc *FabricVulnBenchmark) SetMaxAssetsPerOwner(ctx contractapi.TransactionContextInterface, limit int) error {
+	err := requireAdmin(ctx)
+	if err != nil {
+		return err
+	}
+
+	if limit < 0 {
+		return newValidationError(ValidationOutOfRange, "maximum assets per owner must not be negative")
+	}
+
+	return putConfigInt(ctx, maxAssetsPerOwnerConfig, int64(limit))
+}
+
+// SetTotalCapacity persists a new total capacity. Every capacity check reads it from world state.
+func (sc *FabricVulnBenchmark) SetTotalCapacity(ctx contractapi.TransactionContextInterface, capacity int64) error {
+	err := requireAdmin(ctx)
+	if err != nil {
+		return err
+	}
+
+	if capacity < 0 {
+		return newValidationError(ValidationOutOfRange, "total capacity must not be negative")
+	}
+
+	return putConfigInt(ctx, totalCapacityConfig, capacity)
+}
+
+func (sc *FabricVulnBenchmark) SetMinimumAge(ctx contractapi.TransactionContextInterface, age int) error {
+	err := requireAdmin(ctx)
+	if err != nil {
+		return err
+	}
+
+	if age <= 0 || age > maxOwnerAge {
+		return newValidationError(ValidationOutOfRange, "minimum owner age is out of the accepted range")
+	}
+
+	return putConfigInt(ctx, minimumOwnerAgeConfig, int64(age))
+}
+
+// SetOwnerIDScheme selects how CreateOwner assigns IDs: "sequential", the default, uses a counter kept
+// in world state, "counter" the in-memory counter and "documentHash" a keyed hash of the document number.
+// The documentHash scheme needs a salt set through SetOwnerIDSalt first.
+func (sc *FabricVulnBenchmark) SetOwnerIDScheme(ctx contractapi.TransactionContextInterface, scheme string) error {
+	err := requireAdmin(ctx)
+	if err != nil {
+		return err
+	}
+
+	value, ok := ownerIDSchemes[scheme]
+	if !ok {
+		return newValidationError(ValidationInvalidFormat, "unknown owner ID scheme %s", scheme)
+	}
+
+	return putConfigInt(ctx, ownerIDSchemeConfig, value)
+}
+
+// SetOwnerIDSalt stores the transient ownerIdSalt in the private collection. It keys the documentHash
+// owner ID scheme, so public owner IDs cannot be matched against guessed document numbers.
+// The salt can only be set once, since changing it would change the ID derived for a document.
+func (sc *FabricVulnBenchmark) SetOwnerIDSalt(ctx contractapi.TransactionContextInterface) error {
+	stub := ctx.GetStub()
+
+	err := requireAdmin(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = verifyCollectionMembership(ctx)
+	if err != nil {
+		return err
+	}
+
+	transientMap, err := stub.GetTransient()
+	if err != nil {
+		return fmt.Errorf("unable to get transient data: %w", err)
+	}
+
+	salt, err := requireTransientField(transientMap, ownerIDSaltKey)
+	if err != nil {
+		return err
+	}
+	if len(salt) < minOwnerIDSaltLen {
+		return newValidationError(ValidationOutOfRange, "owner ID salt must be at least %d bytes", minOwnerIDSaltLen)
+	}
+
+	existing, err := stub.GetPrivateData(privateCollection, ownerIDSaltKey)
+	if err != nil {
+		return fmt.Errorf("unable to read private data: %w", err)
+	}
+	if existing != nil {
+		return newValidationError(ValidationAlreadyExists, "owner ID salt is already set")
+	}
+
+	err = stub.PutPrivateData(privateCollection, ownerIDSaltKey, salt)
+	if err != nil {
+		return fmt.Errorf("unable to store private data: %w", err)
+	}
+
+	return writeAuditRecord(ctx, ownerIDSaltKey)
+}
+
+// SetMaxDailyIncrement caps the total amount increments per owner and UTC day. Zero disables the cap.
+func (sc *FabricVulnBenchmark) SetMaxDailyIncrement(ctx contractapi.TransactionContextInterface, limit int64) error {
+	err := requireAdmin(ctx)
+	if err != nil {
+		return err
+	}
+
+	if limit < 0 {
+		return newValidationError(ValidationOutOfRange, "maximum daily increment must not be negative")
+	}
+
+	return putConfigInt(ctx, maxDailyIncrementConfig, limit)
+}
+
+func (sc *FabricVulnBenchmark) SetMaxDescriptionLength(ctx contractapi.TransactionContextInterface, n string) error {
+	err := requireAdmin(ctx)
+	if err != nil {
+		return err
+	}
+
+	maxLength, err := strconv.ParseInt(n, 10, 64)
+	if err != nil {
+		return newValidationError(ValidationInvalidFormat, "maximum description length must be an integer")
+	}
+	if maxLength <= 0 {
+		return newValidationError(ValidationOutOfRange, "maximum description length must be positive")
+	}
+
+	return putConfigInt(ctx, maxDescriptionLengthConfig, maxLength)
+}
+
+func (sc *FabricVulnBenchmark) GetContractConfig(ctx contractapi.TransactionContextInterface) (*ContractConfig, error) {
+	stub := ctx.GetStub()
+
+	iterator, err := stub.GetStateByPartialCompositeKey("typeCapacity", []string{})
+	if err != nil {
+		return nil, fmt.Errorf("unable to interact with world state: %w", err)
+	}
+	defer iterator.Close()
+
+	typeCapacities := make(map[string]int64)
+	for iterator.HasNext() {
+		queryResponse, err := iterator.Next()
+		if err != nil {
+			return nil, fmt.Errorf("unable to get next element: %w", err)
+		}
+
+		_, cKeyParts, err := stub.SplitCompositeKey(queryResponse.GetKey())
+		if err != nil {
+			return nil, fmt.Errorf("unable to split key: %w", err)
+		}
+
+		capacity, err := strconv.ParseInt(string(queryResponse.GetValue()), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse type capacity: %w", err)
+		}
+
+		typeCapacities[cKeyParts[0]] = capacity
+	}
+
+	capacity, err := getTotalCapacity(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	minimumAge, err := getConfigInt(ctx, minimumOwnerAgeConfig, minOwnerAge)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ContractConfig{
+		TotalCapacity:     capacity,
+		MinimumOwnerAge:   uint64(minimumAge),
+		PrivateCollection: privateCollection,
+		TypeCapacities:    typeCapacities,
+	}, nil
+}
+
+func (sc *FabricVulnBenchmark) SetAssetEndorsement(ctx contractapi.TransactionContextInterface, assetID string, orgs []string) error {
+	stub := ctx.GetStub()
+
+	if len(orgs) == 0 {
+		return newValidationError(ValidationEmptyField, "at least one organization is required")
+	}
+
+	if err := validateKeyComponent(assetID); err != nil {
+		return err
+	}
+
+	assetKey, err := stub.CreateCompositeKey("asset", []string{assetID})
+	if err != nil {
+		return fmt.Errorf("unable to create composite key: %w", err)
+	}
+
+	asset, _, err := getAsset(ctx, assetID)
+	if err != nil {
+		return err
+	}
+	if asset == nil {
+		return newValidationError(ValidationNotFound, "cannot set endorsement for world state pair with key %s. Does not exist", assetID)
+	}
+
+	err = requireOwnerOrAdmin(ctx, asset.Owner)
+	if err != nil {
+		return err
+	}
+
+	endorsementPolicy, err := statebased.NewStateEP(nil)
+	if err != nil {
+		return fmt.Errorf("unable to create endorsement policy: %w", err)
+	}
+
+	err = endorsementPolicy.AddOrgs(statebased.RoleTypePeer, orgs...)
+	if err != nil {
+		return fmt.Errorf("unable to add organizations to endorsement policy: %w", err)
+	}
+
+	policy, err := endorsementPolicy.Policy()
+	if err != nil {
+		return fmt.Errorf("unable to build endorsement policy: %w", err)
+	}
+
+	err = stub.SetStateValidationParameter(assetKey, policy)
+	if err != nil {
+		return fmt.Errorf("unable to set state validation parameter: %w", err)
+	}
+
+	return nil
+}
+
+func (sc *FabricVulnBenchmark) GetAssetEndorsement(ctx contractapi.TransactionContextInterface, assetID string) ([]string, error) {
+	stub := ctx.GetStub()
+
+	if err := validateKeyComponent(assetID); err != nil {
+		return nil, err
+	}
+
+	assetKey, err := stub.CreateCompositeKey("asset", []string{assetID})
+	if err != nil {
+		return nil, fmt.Errorf("unable to create composite key: %w", err)
+	}
+
+	policy, err := stub.GetStateValidationParameter(assetKey)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get state validation parameter: %w", err)
+	}
+	if len(policy) == 0 {
+		return []string{}, nil
+	}
+
+	endorsementPolicy, err := statebased.NewStateEP(policy)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse endorsement policy: %w", err)
+	}
+
+	// ListOrgs ranges over a map, so the organizations are sorted to keep the response deterministic.
+	orgs := endorsementPolicy.ListOrgs()
+	sort.Strings(orgs)
+
+	return orgs, nil
+}
+
+func (sc *FabricVulnBenchmark) TransferAsset(ctx contractapi.TransactionContextInterface, assetID, newOwnerID string) error {
+	asset, err := sc.ReadAsset(ctx, assetID)
+	if err != nil {
+		return err
+	}
+
+	err = assertNotFrozen(asset)
+	if err != nil {
+		return err
+	}
+
+	err = assertNotArchived(asset)
+	if err != nil {
+		return err
+	}
+
+	err = requireOwnerOrAdmin(ctx, asset.Owner)
+	if err != nil {
+		return err
+	}
+
+	if asset.Owner == newOwnerID {
+		return fmt.Errorf("asset %s is already owned by owner %s", assetID, newOwnerID)
+	}
+
+	_, err = readOwner(ctx, newOwnerID)
+	if err != nil {
+		return err
+	}
+
+	err = checkOwnerAssetLimit(ctx, newOwnerID, 1)
+	if err != nil {
+		return err
+	}
+
+	asset.Owner = newOwnerID
+	asset.PendingOwner = ""
+
+	return sc.writeAsset(ctx, assetID, asset)
+}
+
+// TransferAssetToDocument transfers an asset to the owner registered with the transient documentNumber.
+func (sc *FabricVulnBenchmark) TransferAssetToDocument(ctx contractapi.TransactionContextInterface, assetID string) error {
+	err := verifyCollectionMembership(ctx)
+	if err != nil {
+		return err
+	}
+
+	ownerID, err := readDocumentIndex(ctx)
+	if err != nil {
+		return err
+	}
+	if ownerID == nil {
+		return newValidationError(ValidationNotFound, "no owner is registered with this document number")
+	}
+
+	return sc.TransferAsset(ctx, assetID, string(ownerID))
+}
+
+func (sc *FabricVulnBenchmark) ProposeTransfer(ctx contractapi.TransactionContextInterface, assetID, toOwnerID string) error {
+	asset, err := sc.ReadAsset(ctx, assetID)
+	if err != nil {
+		return err
+	}
+
+	err = assertNotFrozen(asset)
+	if err != nil {
+		return err
+	}
+
+	err = assertNotArchived(asset)
+	if err != nil {
+		return err
+	}
+
+	err = requireOwnerOrAdmin(ctx, asset.Owner)
+	if err != nil {
+		return err
+	}
+
+	if toOwnerID == asset.Owner {
+		return errors.New("asset is already owned by the proposed owner")
+	}
+
+	_, err = readOwner(ctx, toOwnerID)
+	if err != nil {
+		return err
+	}
+
+	asset.PendingOwner = toOwnerID
+
+	return sc.writeAsset(ctx, assetID, asset)
+}
+
+func (sc *FabricVulnBenchmark) AcceptTransfer(ctx contractapi.TransactionContextInterface, assetID string) error {
+	asset, err := sc.ReadAsset(ctx, assetID)
+	if err != nil {
+		return err
+	}
+
+	err = assertNotFrozen(asset)
+	if err != nil {
+		return err
+	}
+
+	err = assertNotArchived(asset)
+	if err != nil {
+		return err
+	}
+
+	if asset.PendingOwner == "" {
+		return fmt.Errorf("asset %s has no pending transfer", assetID)
+	}
+
+	pendingOwner, err := readOwner(ctx, asset.PendingOwner)
+	if err != nil {
+		return err
+	}
+
+	clientID, err := ctx.GetClientIdentity().GetID()
+	if err != nil {
+		return fmt.Errorf("unable to get client identity: %w", err)
+	}
+
+	if pendingOwner.BoundIdentity == "" || clientID != pendingOwner.BoundIdentity {
+		return errors.New("only the proposed owner can accept the transfer")
+	}
+
+	err = checkOwnerAssetLimit(ctx, asset.PendingOwner, 1)
+	if err != nil {
+		return err
+	}
+
+	asset.Owner = asset.PendingOwner
+	asset.PendingOwner = ""
+
+	return sc.writeAsset(ctx, assetID, asset)
+}
+
+func (sc *FabricVulnBenchmark) CancelTransfer(ctx contractapi.TransactionContextInterface, assetID string) error {
+	asset, err := sc.ReadAsset(ctx, assetID)
+	if err != nil {
+		return err
+	}
+
+	if asset.PendingOwner == "" {
+		return fmt.Errorf("asset %s has no pending transfer", assetID)
+	}
+
+	err = assertNotFrozen(asset)
+	if err != nil {
+		return err
+	}
+
+	err = requireOwnerOrAdmin(ctx, asset.Owner)
+	if err != nil {
+		return err
+	}
+
+	asset.PendingOwner = ""
+
+	return sc.writeAsset(ctx, assetID, asset)
+}
+
+func (sc *FabricVulnBenchmark) FreezeAsset(ctx contractapi.TransactionContextInterface, assetID string) error {
+	return sc.setAssetFrozen(ctx, assetID, true)
+}
+
+func (sc *FabricVulnBenchmark) UnfreezeAsset(ctx contractapi.TransactionContextInterface, assetID string) error {
+	return sc.setAssetFrozen(ctx, assetID, false)
+}
+
+func (sc *FabricVulnBenchmark) setAssetFrozen(ctx contractapi.TransactionContextInterface, assetID string, frozen bool) error {
+	err := requireAdmin(ctx)
+	if err != nil {
+		return err
+	}
+
+	asset, err := sc.ReadAsset(ctx, assetID)
+	if err != nil {
+		return err
+	}
+
+	if asset.Frozen == frozen {
+		if frozen {
+			return fmt.Errorf("asset %s is already frozen", assetID)
+		}
+		return fmt.Errorf("asset %s is not frozen", assetID)
+	}
+
+	asset.Frozen = frozen
+
+	return sc.writeAsset(ctx, assetID, asset)
+}
+
+func (sc *FabricVulnBenchmark) DeleteAsset(ctx contractapi.TransactionContextInterface, assetID string) error {
+	stub := ctx.GetStub()
+
+	asset, err := sc.ReadAsset(ctx, assetID)
+	if err != nil {
+		return err
+	}
+
+	err = assertNotFrozen(asset)
+	if err != nil {
+		return err
+	}
+
+	err = requireOwnerOrAdmin(ctx, asset.Owner)
+	if err != nil {
+		return err
+	}
+
+	err = assertNoReservations(ctx, assetID)
+	if err != nil {
+		return err
+	}
+
+	assetKey, err := stub.CreateCompositeKey("asset", []string{assetID})
+	if err != nil {
+		return fmt.Errorf("unable to create composite key: %w", err)
+	}
+
+	return deleteAssetByKey(ctx, assetKey, assetCollection(asset))
+}
+
+// DeleteAssets deletes every asset in the JSON array of IDs and returns how many were deleted.
+func (sc *FabricVulnBenchmark) DeleteAssets(ctx contractapi.TransactionContextInterface, idsJSON string, skipMissing bool) (int, error) {
+	stub := ctx.GetStub()
+
+	var assetIDs []string
+	if err := decodeStrict(idsJSON, &assetIDs); err != nil {
+		return 0, fmt.Errorf("asset IDs must be a JSON array of strings: %w", err)
+	}
+
+	// Deletes are not visible to later reads in the same transaction, so repeated IDs are dropped here.
+	seen := make(map[string]bool, len(assetIDs))
+	deleted := 0
+	for _, assetID := range assetIDs {
+		if seen[assetID] {
+			continue
+		}
+		seen[assetID] = true
+
+		asset, found, err := tryReadAsset(ctx, assetID)
+		if err != nil {
+			return 0, err
+		}
+
+		if !found {
+			if skipMissing {
+				continue
+			}
+			return 0, newValidationError(ValidationNotFound, "cannot delete world state pair with key %s. Does not exist", assetID)
+		}
+
+		err = assertNotFrozen(asset)
+		if err != nil {
+			return 0, err
+		}
+
+		err = requireOwnerOrAdmin(ctx, asset.Owner)
+		if err != nil {
+			return 0, err
+		}
+
+		err = assertNoReservations(ctx, assetID)
+		if err != nil {
+			return 0, err
+		}
+
+		assetKey, err := stub.CreateCompositeKey("asset", []string{assetID})
+		if err != nil {
+			return 0, fmt.Errorf("unable to create composite key: %w", err)
+		}
+
+		err = deleteAssetByKey(ctx, assetKey, assetCollection(asset))
+		if err != nil {
+			return 0, err
+		}
+
+		deleted++
+	}
+
+	return deleted, nil
+}
+
+func (sc *FabricVulnBenchmark) DeleteAssetsByType(ctx contractapi.TransactionContextInterface, assetType string) (int, error) {
+	stub := ctx.GetStub()
+
+	err := requireAdmin(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	iterator, err := stub.GetStateByPartialCompositeKey("asset", []string{})
+	if err != nil {
+		return 0, fmt.Errorf("unable to interact with world state: %w", err)
+	}
+	defer iterator.Close()
+
+	var assetKeys []string
+	collections := make(map[string]string)
+	for iterator.HasNext() {
+		queryResponse, err := iterator.Next()
+		if err != nil {
+			return 0, fmt.Errorf("unable to get next element: %w", err)
+		}
+
+		var asset Asset
+		err = json.Unmarshal(queryResponse.GetValue(), &asset)
+		if err != nil {
+			return 0, fmt.Errorf("unable to unmarshal asset: %w", err)
+		}
+
+		if asset.AssetType == assetType {
+			// A frozen asset fails the whole deletion, like DeleteAssets.
+			err = assertNotFrozen(&asset)
+			if err != nil {
+				return 0, err
+			}
+
+			err = assertNoReservations(ctx, asset.ID)
+			if err != nil {
+				return 0, err
+			}
+
+			assetKeys = append(assetKeys, queryResponse.GetKey())
+			collections[queryResponse.GetKey()] = assetCollection(&asset)
+		}
+	}
+
+	for _, assetKey := range assetKeys {
+		err = deleteAssetByKey(ctx, assetKey, collections[assetKey])
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return len(assetKeys), nil
+}
+
+func (sc *FabricVulnBenchmark) RelabelAssetType(ctx contractapi.TransactionContextInterface, oldType, newType string) (int, error) {
+	err := requireAdmin(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	if oldType == "" || newType == "" {
+		return 0, newValidationError(ValidationEmptyField, "asset types must not be empty")
+	}
+	if oldType == newType {
+		return 0, errors.New("old and new asset types must be different")
+	}
+
+	err = requireRegisteredAssetType(ctx, newType)
+	if err != nil {
+		return 0, err
+	}
+
+	assets, err := scanAssets(ctx, true)
+	if err != nil {
+		return 0, err
+	}
+
+	var matched []Asset
+	var movedAmount int64
+	for _, asset := range assets {
+		if asset.AssetType == oldType && !asset.Frozen {
+			matched = append(matched, asset)
+			movedAmount += int64(asset.Amount)
+		}
+	}
+
+	err = checkTypeCapacity(ctx, newType, movedAmount)
+	if err != nil {
+		return 0, err
+	}
+
+	for i := range matched {
+		matched[i].AssetType = newType
+
+		err = sc.writeAsset(ctx, matched[i].ID, &matched[i])
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return len(matched), nil
+}
+
+// GetAssetsNeedingRepair counts assets with pointer-string owners, out-of-range amounts
+// and creation times that are not RFC 3339. An asset with several issues is counted once per issue.
+func (sc *FabricVulnBenchmark) GetAssetsNeedingRepair(ctx contractapi.TransactionContextInterface) (*RepairReport, error) {
+	assets, err := scanAssets(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+
+	capacity, err := getTotalCapacity(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &RepairReport{AssetsScanned: len(assets)}
+	for i := range assets {
+		if isPointerString(assets[i].Owner) {
+			report.PointerOwners++
+		}
+		if !amountInRange(&assets[i], capacity) {
+			report.OutOfRangeAmounts++
+		}
+		if _, err := time.Parse(time.RFC3339, assets[i].CreationTime); err != nil {
+			report.InvalidCreationTimes++
+		}
+	}
+
+	return report, nil
+}
+
+// RepairAssetOwners assigns ownerID to every asset whose owner was stored as a pointer address.
+func (sc *FabricVulnBenchmark) RepairAssetOwners(ctx contractapi.TransactionContextInterface, ownerID string) (int, error) {
+	err := requireAdmin(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = readOwner(ctx, ownerID)
+	if err != nil {
+		return 0, err
+	}
+
+	assets, err := scanAssets(ctx, true)
+	if err != nil {
+		return 0, err
+	}
+
+	// Index writes are not visible within the transaction, so the limit is checked once for all repairs.
+	var repairs int64
+	for i := range assets {
+		if !assets[i].Frozen && isPointerString(assets[i].Owner) {
+			repairs++
+		}
+	}
+
+	err = checkOwnerAssetLimit(ctx, ownerID, repairs)
+	if err != nil {
+		return 0, err
+	}
+
+	repaired := 0
+	for i := range assets {
+		if assets[i].Frozen || !isPointerString(assets[i].Owner) {
+			continue
+		}
+
+		assets[i].Owner = ownerID
+		assets[i].PendingOwner = ""
+
+		err = sc.writeAsset(ctx, assets[i].ID, &assets[i])
+		if err != nil {
+			return 0, err
+		}
+
+		repaired++
+	}
+
+	return repaired, nil
+}
+
+// RebuildOwnerIndex makes the owner~asset index match the stored assets, archived ones included,
+// and returns how many entries were added or removed. It backfills assets written before the index existed.
+func (sc *FabricVulnBenchmark) RebuildOwnerIndex(ctx contractapi.TransactionContextInterface) (int, error) {
+	stub := ctx.GetStub()
+
+	err := requireAdmin(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	assets, err := scanAssets(ctx, true)
+	if err != nil {
+		return 0, err
+	}
+
+	expected := make(map[string]bool, len(assets))
+	var missing []string
+	for _, asset := range assets {
+		if asset.Owner == "" {
+			continue
+		}
+
+		if err := validateKeyComponent(asset.Owner); err != nil {
+			return 0, err
+		}
+
+		indexKey, err := stub.CreateCompositeKey("owner~asset", []string{asset.Owner, asset.ID})
+		if err != nil {
+			return 0, fmt.Errorf("unable to create composite key: %w", err)
+		}
+
+		expected[indexKey] = true
+		missing = append(missing, indexKey)
+	}
+
+	iterator, err := stub.GetStateByPartialCompositeKey("owner~asset", []string{})
+	if err != nil {
+		return 0, fmt.Errorf("unable to interact with world state: %w", err)
+	}
+	defer iterator.Close()
+
+	indexed := make(map[string]bool)
+	var stale []string
+	for iterator.HasNext() {
+		queryResponse, err := iterator.Next()
+		if err != nil {
+			return 0, fmt.Errorf("unable to get next element: %w", err)
+		}
+
+		indexed[queryResponse.GetKey()] = true
+		if !expected[queryResponse.GetKey()] {
+			stale = append(stale, queryResponse.GetKey())
+		}
+	}
+
+	changed := 0
+	for _, indexKey := range stale {
+		err = stub.DelState(indexKey)
+		if err != nil {
+			return 0, fmt.Errorf("unable to interact with world state: %w", err)
+		}
+
+		changed++
+	}
+
+	for _, indexKey := range missing {
+		if indexed[indexKey] {
+			continue
+		}
+
+		err = stub.PutState(indexKey, []byte{0x00})
+		if err != nil {
+			return 0, fmt.Errorf("unable to interact with world state: %w", err)
+		}
+
+		changed++
+	}
+
+	return changed, nil
+}
+
+// V: Unhandled Error
+func (sc *FabricVulnBenchmark) UnhandledError(ctx contractapi.TransactionContextInterface, assetID string) {
+	sc.ReadAsset(ctx, "AssetID")
+}
+
+// nextOwnerID returns the ID for a new owner according to the configured owner ID scheme.
+func (sc *FabricVulnBenchmark) nextOwnerID(ctx contractapi.TransactionContextInterface, documentNumber string) (int, error) {
+	scheme, err := getConfigInt(ctx, ownerIDSchemeConfig, ownerIDSchemeSequential)
+	if err != nil {
+		return 0, err
+	}
+
+	switch scheme {
+	case ownerIDSchemeSequential:
+		next, err := getConfigInt(ctx, ownerSequenceConfig, 1)
+		if err != nil {
+			return 0, err
+		}
+
+		// Skip IDs already taken, for instance by owners created with the in-memory counter.
+		for {
+			exists, err := ownerExists(ctx, strconv.FormatInt(next, 10))
+			if err != nil {
+				return 0, err
+			}
+			if !exists {
+				break
+			}
+			next++
+		}
+
+		err = putConfigInt(ctx, ownerSequenceConfig, next+1)
+		if err != nil {
+			return 0, err
+		}
+
+		return int(next), nil
+	case ownerIDSchemeDocumentHash:
+		salt, err := ctx.GetStub().GetPrivateData(privateCollection, ownerIDSaltKey)
+		if err != nil {
+			return 0, fmt.Errorf("unable to read private data: %w", err)
+		}
+		if salt == nil {
+			return 0, errors.New("owner ID salt is not set")
+		}
+
+		// 48 bits keep the ID exactly representable in JSON clients that use float64 numbers.
+		mac := hmac.New(sha256.New, salt)
+		mac.Write([]byte(documentNumber))
+		id, err := strconv.ParseInt(hex.EncodeToString(mac.Sum(nil)[:6]), 16, 64)
+		if err != nil {
+			return 0, fmt.Errorf("unable to derive owner ID: %w", err)
+		}
+
+		exists, err := ownerExists(ctx, strconv.FormatInt(id, 10))
+		if err != nil {
+			return 0, err
+		}
+		if exists {
+			return 0, newValidationError(ValidationAlreadyExists, "an owner with this document number already exists")
+		}
+
+		return int(id), nil
+	default:
+		id := sc.ownerCounter
+		sc.ownerCounter = sc.ownerCounter + 1
+
+		return id, nil
+	}
+}
+
+func (sc *FabricVulnBenchmark) writeAsset(ctx contractapi.TransactionContextInterface, assetID string, asset *Asset) error {
+	stub := ctx.GetStub()
+
+	asset.Version++
+
+	updatedAssetBytes, err := marshalCanonical(asset)
+	if err != nil {
+		return fmt.Errorf("unable to marshal asset: %w", err)
+	}
+
+	previous, _, err := getAsset(ctx, assetID)
+	if err != nil {
+		return err
+	}
+
+	assetKey, err := stub.CreateCompositeKey("asset", []string{assetID})
+	if err != nil {
+		return fmt.Errorf("unable to create composite key: %w", err)
+	}
+
+	err = stub.PutState(assetKey, updatedAssetBytes)
+	if err != nil {
+		return fmt.Errorf("unable to interact with world state: %w", err)
+	}
+
+	previousOwner := ""
+	if previous != nil {
+		previousOwner = previous.Owner
+	}
+
+	err = updateOwnerIndex(ctx, assetID, previousOwner, asset.Owner)
+	if err != nil {
+		return err
+	}
+
+	return writeAuditRecord(ctx, "asset", assetID)
+}
+
+// parseAmountsJSON validates that the input is a JSON array of strings.
+// It returns a descriptive error naming the offending element otherwise.
+func parseAmountsJSON(amountsJSON string) ([]string, error) {
+	var rawAmounts []json.RawMessage
+	if err := decodeStrict(amountsJSON, &rawAmounts); err != nil {
+		return nil, fmt.Errorf("amounts must be a JSON array of strings: %w", err)
+	}
+
+	amounts := make([]string, len(rawAmounts))
+	for i, rawAmount := range rawAmounts {
+		if len(rawAmount) == 0 || rawAmount[0] != '"' {
+			return nil, newValidationError(ValidationInvalidFormat, "amounts[%d] must be a JSON string, got %s", i, rawAmount)
+		}
+		if err := json.Unmarshal(rawAmount, &amounts[i]); err != nil {
+			return nil, fmt.Errorf("amounts[%d] must be a JSON string: %w", i, err)
+		}
+	}
+
+	return amounts, nil
+}
+
+// decodeStrict decodes a client-supplied JSON argument, rejecting unknown object fields and trailing data.
+func decodeStrict(input string, v interface{}) error {
+	decoder := json.NewDecoder(strings.NewReader(input))
+	decoder.DisallowUnknownFields()
+
+	err := decoder.Decode(v)
+	if err != nil {
+		return err
+	}
+
+	if _, err := decoder.Token(); err != io.EOF {
+		return errors.New("unexpected data after the JSON value")
+	}
+
+	return nil
+}
+
+// marshalCanonical serializes v into a canonical JSON form with object keys sorted and numbers
+// kept verbatim, so persisted bytes are stable across peers, Go versions and struct field order.
+func marshalCanonical(v interface{}) ([]byte, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+
+	var generic interface{}
+	err = decoder.Decode(&generic)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(generic)
+}
+
+// documentHash returns the hex-encoded SHA-256 of a document number, used as its private index key.
+func documentHash(documentNumber string) string {
+	digest := sha256.Sum256([]byte(documentNumber))
+
+	return hex.EncodeToString(digest[:])
+}
+
+// autoAssetID derives a deterministic asset ID from a transaction ID.
+func autoAssetID(txID string) string {
+	digest := sha256.Sum256([]byte(txID))
+
+	return "asset-" + hex.EncodeToString(digest[:8])
+}
+
+// txTimestamp returns the transaction timestamp formatted as RFC 3339.
+// Unlike time.Now, it is identical on every endorsing peer.
+func txTimestamp(ctx contractapi.TransactionContextInterface) (string, error) {
+	timestamp, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return "", fmt.Errorf("unable to get transaction timestamp: %w", err)
+	}
+
+	return timestamp.AsTime().UTC().Format(time.RFC3339), nil
+}
+
+// scanAssets iterates over the whole asset keyspace and filters in Go instead of using rich queries.
+// It returns the assets sorted by ID, optionally including archived ones.
+func scanAssets(ctx contractapi.TransactionContextInterface, includeArchived bool) ([]Asset, error) {
+	stub := ctx.GetStub()
+
+	iterator, err := stub.GetStateByPartialCompositeKey("asset", []string{})
+	if err != nil {
+		return nil, fmt.Errorf("unable to interact with world state: %w", err)
+	}
+	defer iterator.Close()
+
+	assets := make([]Asset, 0)
+	for iterator.HasNext() {
+		queryResponse, err := iterator.Next()
+		if err != nil {
+			return nil, fmt.Errorf("unable to get next element: %w", err)
+		}
+
+		var asset Asset
+		err = json.Unmarshal(queryResponse.GetValue(), &asset)
+		if err != nil {
+			return nil, fmt.Errorf("unable to unmarshal asset: %w", err)
+		}
+
+		if asset.Archived && !includeArchived {
+			continue
+		}
+
+		assets = append(assets, asset)
+	}
+
+	sortAssetsByID(assets)
+
+	return assets, nil
+}
+
+// latestModification walks the history of a key and returns its most recent modification time.
+func latestModification(ctx contractapi.TransactionContextInterface, key string) (time.Time, error) {
+	iterator, err := ctx.GetStub().GetHistoryForKey(key)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("unable to get history for key: %w", err)
+	}
+	defer iterator.Close()
+
+	var latest time.Time
+	for iterator.HasNext() {
+		modification, err := iterator.Next()
+		if err != nil {
+			return time.Time{}, fmt.Errorf("unable to get next history element: %w", err)
+		}
+
+		timestamp := modification.GetTimestamp().AsTime()
+		if timestamp.After(latest) {
+			latest = timestamp
+		}
+	}
+
+	return latest, nil
+}
+
+// sumAssetAmounts adds up the amounts of all assets, archived ones included, in key order.
+func sumAssetAmounts(ctx contractapi.TransactionContextInterface) (int64, error) {
+	assets, err := scanAssets(ctx, true)
+	if err != nil {
+		return 0, err
+	}
+
+	var total int64
+	for _, asset := range assets {
+		total += int64(asset.Amount)
+	}
+
+	return total, nil
+}
+
+// toInt32Checked converts value to an int32 amount, failing instead of wrapping around.
+// Capacities and amount arithmetic are int64 throughout; amounts are narrowed only through this helper.
+func toInt32Checked(value int64) (int32, error) {
+	if value > math.MaxInt32 || value < math.MinInt32 {
+		return 0, newValidationError(ValidationOutOfRange, "value %d does not fit in an int32 amount", value)
+	}
+
+	return int32(value), nil
+}
+
+// amountInRange reports whether the stored amount could have been produced by a checked update.
+func amountInRange(asset *Asset, capacity int64) bool {
+	return asset.Amount >= 0 && int64(asset.Amount) <= capacity
+}
+
+// getTotalCapacity reads the total capacity configured through InitContract and SetTotalCapacity.
+func getTotalCapacity(ctx contractapi.TransactionContextInterface) (int64, error) {
+	return getConfigInt(ctx, totalCapacityConfig, defaultTotalCapacity)
+}
+
+// getTypeCapacity reads the capacity configured for an asset type.
+// The boolean is false when no capacity is configured for the type.
+func getTypeCapacity(ctx contractapi.TransactionContextInterface, assetType string) (int64, bool, error) {
+	stub := ctx.GetStub()
+
+	if err := validateKeyComponent(assetType); err != nil {
+		return 0, false, err
+	}
+
+	capacityKey, err := stub.CreateCompositeKey("typeCapacity", []string{assetType})
+	if err != nil {
+		return 0, false, fmt.Errorf("unable to create composite key: %w", err)
+	}
+
+	capacityBytes, err := stub.GetState(capacityKey)
+	if err != nil {
+		return 0, false, fmt.Errorf("unable to interact with world state: %w", err)
+	}
+	if capacityBytes == nil {
+		return 0, false, nil
+	}
+
+	capacity, err := strconv.ParseInt(string(capacityBytes), 10, 64)
+	if err != nil {
+		return 0, false, fmt.Errorf("unable to parse type capacity: %w", err)
+	}
+
+	return capacity, true, nil
+}
+
+// putAssetTypeRegistration marks an asset type as registered under the assetTypeRegistry composite key.
+func putAssetTypeRegistration(ctx contractapi.TransactionContextInterface, assetType string) error {
+	stub := ctx.GetStub()
+
+	if err := validateKeyComponent(assetType); err != nil {
+		return err
+	}
+
+	registryKey, err := stub.CreateCompositeKey("assetTypeRegistry", []string{assetType})
+	if err != nil {
+		return fmt.Errorf("unable to create composite key: %w", err)
+	}
+
+	err = stub.PutState(registryKey, []byte("true"))
+	if err != nil {
+		return fmt.Errorf("unable to interact with world state: %w", err)
+	}
+
+	return writeAuditRecord(ctx, "assetTypeRegistry", assetType)
+}
+
+// isAssetTypeRegistered reports whether an asset type has an assetTypeRegistry entry.
+func isAssetTypeRegistered(ctx contractapi.TransactionContextInterface, assetType string) (bool, error) {
+	stub := ctx.GetStub()
+
+	if err := validateKeyComponent(assetType); err != nil {
+		return false, err
+	}
+
+	registryKey, err := stub.CreateCompositeKey("assetTypeRegistry", []string{assetType})
+	if err != nil {
+		return false, fmt.Errorf("unable to create composite key: %w", err)
+	}
+
+	registryBytes, err := stub.GetState(registryKey)
+	if err != nil {
+		return false, fmt.Errorf("unable to interact with world state: %w", err)
+	}
+
+	return registryBytes != nil, nil
+}
+
+// requireRegisteredAssetType rejects asset types without a registry entry.
+// Every path that sets an asset type goes through it.
+func requireRegisteredAssetType(ctx contractapi.TransactionContextInterface, assetType string) error {
+	registered, err := isAssetTypeRegistered(ctx, assetType)
+	if err != nil {
+		return err
+	}
+	if !registered {
+		return newValidationError(ValidationNotFound, "asset type %s is not registered", assetType)
+	}
+
+	return nil
+}
+
+// checkTypeCapacity verifies that adding delta to the summed amount of an asset type stays within its capacity.
+func checkTypeCapacity(ctx contractapi.TransactionContextInterface, assetType string, delta int64) error {
+	capacity, ok, err := getTypeCapacity(ctx, assetType)
+	if err != nil {
+		return err
+	}
+	if !ok || delta <= 0 {
+		return nil
+	}
+
+	assets, err := scanAssets(ctx, true)
+	if err != nil {
+		return err
+	}
+
+	var typeTotal int64
+	for _, asset := range assets {
+		if asset.AssetType == assetType {
+			typeTotal += int64(asset.Amount)
+		}
+	}
+
+	if typeTotal+delta > capacity {
+		return newValidationError(ValidationOutOfRange, "amount would exceed the capacity of asset type %s", assetType)
+	}
+
+	return nil
+}
+
+func newValidationError(code ValidationCode, format string, args ...interface{}) error {
+	return &ValidationError{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// validateKeyComponent rejects composite key parts that would corrupt the key space.
+// Fabric delimits composite keys with U+0000 and uses U+10FFFF as the range upper bound.
+func validateKeyComponent(s string) error {
+	if !utf8.ValidString(s) {
+		return newValidationError(ValidationInvalidFormat, "key component %q is not valid UTF-8", s)
+	}
+
+	for _, r := range s {
+		if r == 0 || r == utf8.MaxRune {
+			return newValidationError(ValidationInvalidFormat, "key component %q contains a reserved character", s)
+		}
+	}
+
+	return nil
+}
+
+// validateAssetCreation performs every read-only check required before creating an asset.
+// It returns the owner record so callers do not need to read it again.
+func validateAssetCreation(ctx contractapi.TransactionContextInterface, assetID, description, assetType, ownerID string, amount int32) (*Owner, error) {
+	err := validateNewAsset(ctx, assetID, description, assetType, amount)
+	if err != nil {
+		return nil, err
+	}
+
+	owner, err := readOwner(ctx, ownerID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = checkOwnerAssetLimit(ctx, ownerID, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	return owner, nil
+}
+
+// validateNewAsset runs the creation checks that do not depend on the owner.
+func validateNewAsset(ctx contractapi.TransactionContextInterface, assetID, description, assetType string, amount int32) error {
+	if assetID == "" {
+		return newValidationError(ValidationEmptyField, "asset ID must not be empty")
+	}
+
+	err := checkDescriptionLength(ctx, description)
+	if err != nil {
+		return err
+	}
+
+	if amount < 0 {
+		return newValidationError(ValidationOutOfRange, "amount must not be negative")
+	}
+	capacity, err := getTotalCapacity(ctx)
+	if err != nil {
+		return err
+	}
+	if int64(amount) > capacity {
+		return newValidationError(ValidationOutOfRange, "amount exceeds the total capacity")
+	}
+
+	err = requireRegisteredAssetType(ctx, assetType)
+	if err != nil {
+		return err
+	}
+
+	existing, _, err := getAsset(ctx, assetID)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		return newValidationError(ValidationAlreadyExists, "cannot create world state pair with key %s. Already exists", assetID)
+	}
+
+	// A new asset must not inherit private data left behind by an earlier asset with the same ID.
+	assetKey, err := ctx.GetStub().CreateCompositeKey("asset", []string{assetID})
+	if err != nil {
+		return fmt.Errorf("unable to create composite key: %w", err)
+	}
+
+	privateHash, err := ctx.GetStub().GetPrivateDataHash(privateCollection, assetKey)
+	if err != nil {
+		return fmt.Errorf("unable to read private data hash: %w", err)
+	}
+	if privateHash != nil {
+		return newValidationError(ValidationAlreadyExists, "stale private data exists for asset %s", assetID)
+	}
+
+	return checkTypeCapacity(ctx, assetType, int64(amount))
+}
+
+// getConfigInt reads an integer setting stored under the config composite key, or defaultValue if unset.
+func getConfigInt(ctx contractapi.TransactionContextInterface, name string, defaultValue int64) (int64, error) {
+	value, found, err := lookupConfigInt(ctx, name)
+	if err != nil {
+		return 0, err
+	}
+	if !found {
+		return defaultValue, nil
+	}
+
+	return value, nil
+}
+
+// lookupConfigInt reads an integer setting stored under the config composite key and reports whether it is set.
+func lookupConfigInt(ctx contractapi.TransactionContextInterface, name string) (int64, bool, error) {
+	stub := ctx.GetStub()
+
+	configKey, err := stub.CreateCompositeKey("config", []string{name})
+	if err != nil {
+		return 0, false, fmt.Errorf("unable to create composite key: %w", err)
+	}
+
+	valueBytes, err := stub.GetState(configKey)
+	if err != nil {
+		return 0, false, fmt.Errorf("unable to interact with world state: %w", err)
+	}
+	if valueBytes == nil {
+		return 0, false, nil
+	}
+
+	value, err := strconv.ParseInt(string(valueBytes), 10, 64)
+	if err != nil {
+		return 0, false, fmt.Errorf("unable to parse config %s: %w", name, err)
+	}
+
+	return value, true, nil
+}
+
+func putConfigInt(ctx contractapi.TransactionContextInterface, name string, value int64) error {
+	stub := ctx.GetStub()
+
+	configKey, err := stub.CreateCompositeKey("config", []string{name})
+	if err != nil {
+		return fmt.Errorf("unable to create composite key: %w", err)
+	}
+
+	err = stub.PutState(configKey, []byte(strconv.FormatInt(value, 10)))
+	if err != nil {
+		return fmt.Errorf("unable to interact with world state: %w", err)
+	}
+
+	return writeAuditRecord(ctx, "config", name)
+}
+
+// checkDescriptionLength rejects descriptions longer than the configured maximum number of bytes.
+func checkDescriptionLength(ctx contractapi.TransactionContextInterface, description string) error {
+	maxLength, err := getConfigInt(ctx, maxDescriptionLengthConfig, defaultMaxDescriptionLength)
+	if err != nil {
+		return err
+	}
+
+	if int64(len(description)) > maxLength {
+		return newValidationError(ValidationOutOfRange, "description exceeds the maximum length of %d bytes", maxLength)
+	}
+
+	return nil
+}
+
+// recordOwnerIncrement adds a positive increment to the owner's total for the current day and
+// rejects it when the configured daily cap would be exceeded. The day is taken from the
+// transaction timestamp so every endorser picks the same bucket.
+func recordOwnerIncrement(ctx contractapi.TransactionContextInterface, ownerID string, increment int64) error {
+	stub := ctx.GetStub()
+
+	if increment <= 0 {
+		return nil
+	}
+
+	limit, err := getConfigInt(ctx, maxDailyIncrementConfig, 0)
+	if err != nil {
+		return err
+	}
+	if limit == 0 {
+		return nil
+	}
+
+	timestamp, err := stub.GetTxTimestamp()
+	if err != nil {
+		return fmt.Errorf("unable to get transaction timestamp: %w", err)
+	}
+	bucket := timestamp.AsTime().UTC().Format("2006-01-02")
+
+	if err := validateKeyComponent(ownerID); err != nil {
+		return err
+	}
+
+	windowKey, err := stub.CreateCompositeKey("incrementWindow", []string{ownerID, bucket})
+	if err != nil {
+		return fmt.Errorf("unable to create composite key: %w", err)
+	}
+
+	windowBytes, err := stub.GetState(windowKey)
+	if err != nil {
+		return fmt.Errorf("unable to interact with world state: %w", err)
+	}
+
+	var used int64
+	if windowBytes != nil {
+		used, err = strconv.ParseInt(string(windowBytes), 10, 64)
+		if err != nil {
+			return fmt.Errorf("unable to parse increment window: %w", err)
+		}
+	}
+
+	if used+increment > limit {
+		return newValidationError(ValidationOutOfRange, "owner %s would exceed the daily increment limit", ownerID)
+	}
+
+	err = stub.PutState(windowKey, []byte(strconv.FormatInt(used+increment, 10)))
+	if err != nil {
+		return fmt.Errorf("unable to interact with world state: %w", err)
+	}
+
+	return nil
+}
+
+// checkOwnerAssetLimit rejects giving an owner count more assets when the owner would exceed the configured maximum.
+// Held assets are counted through the owner~asset index, so RebuildOwnerIndex must have indexed older assets.
+func checkOwnerAssetLimit(ctx contractapi.TransactionContextInterface, ownerID string, count int64) error {
+	limit, err := getConfigInt(ctx, maxAssetsPerOwnerConfig, 0)
+	if err != nil {
+		return err
+	}
+	if limit == 0 {
+		return nil
+	}
+
+	if err := validateKeyComponent(ownerID); err != nil {
+		return err
+	}
+
+	iterator, err := ctx.GetStub().GetStateByPartialCompositeKey("owner~asset", []string{ownerID})
+	if err != nil {
+		return fmt.Errorf("unable to interact with world state: %w", err)
+	}
+	defer iterator.Close()
+
+	var owned int64
+	for iterator.HasNext() {
+		if _, err := iterator.Next(); err != nil {
+			return fmt.Errorf("unable to get next element: %w", err)
+		}
+		owned++
+	}
+
+	if owned+count > limit {
+		return newValidationError(ValidationOutOfRange, "owner %s already holds the maximum number of assets", ownerID)
+	}
+
+	return nil
+}
+
+// assertNotFrozen rejects modifications to an asset frozen by an admin.
+func assertNotFrozen(asset *Asset) error {
+	if asset.Frozen {
+		return fmt.Errorf("asset %s is frozen and cannot be modified", asset.ID)
+	}
+
+	return nil
+}
+
+// assertSameType rejects operations that move amounts between assets of different types.
+func assertSameType(a, b *Asset) error {
+	if a.AssetType != b.AssetType {
+		return fmt.Errorf("assets %s and %s must be of the same type", a.ID, b.ID)
+	}
+
+	return nil
+}
+
+func assertNotArchived(asset *Asset) error {
+	if asset.Archived {
+		return fmt.Errorf("asset %s is archived and cannot be transferred", asset.ID)
+	}
+
+	return nil
+}
+
+// assertActive rejects amount changes to assets that have been retired or archived.
+func assertActive(asset *Asset) error {
+	if asset.RetiredAt != "" {
+		return fmt.Errorf("asset %s is retired", asset.ID)
+	}
+	if asset.Archived {
+		return fmt.Errorf("asset %s is archived", asset.ID)
+	}
+
+	return nil
+}
+
+// isPointerString reports whether s looks like a %p formatted address such as 0xc000123456.
+func isPointerString(s string) bool {
+	digits := strings.TrimPrefix(s, "0x")
+	if digits == s || digits == "" {
+		return false
+	}
+
+	return strings.Trim(digits, "0123456789abcdef") == ""
+}
+
+// normalizeTags sorts tags and drops duplicates so the serialized asset is deterministic.
+func normalizeTags(tags []string) []string {
+	sort.Strings(tags)
+
+	unique := make([]string, 0, len(tags))
+	for i, tag := range tags {
+		if i == 0 || tag != tags[i-1] {
+			unique = append(unique, tag)
+		}
+	}
+
+	return unique
+}
+
+// sortAssetsByID sorts assets in place by ID so results are deterministic across peers.
+// Composite keys keep IDs unique, but ties are still broken by creation time and owner.
+func sortAssetsByID(assets []Asset) {
+	sort.Slice(assets, func(i, j int) bool {
+		if assets[i].ID != assets[j].ID {
+			return assets[i].ID < assets[j].ID
+		}
+		if assets[i].CreationTime != assets[j].CreationTime {
+			return assets[i].CreationTime < assets[j].CreationTime
+		}
+		return assets[i].Owner < assets[j].Owner
+	})
+}
+
+// isInitialized reports whether InitContract has written its marker to world state.
+func isInitialized(ctx contractapi.TransactionContextInterface) (bool, error) {
+	initialized, err := ctx.GetStub().GetState(initializedKey)
+	if err != nil {
+		return false, fmt.Errorf("unable to interact with world state: %w", err)
+	}
+
+	return initialized != nil, nil
+}
+
+// logTransactionStart logs the invoked function and transaction ID before each transaction.
+// Arguments are never logged since they may carry private data.
+func logTransactionStart(ctx contractapi.TransactionContextInterface) error {
+	stub := ctx.GetStub()
+	function, _ := stub.GetFunctionAndParameters()
+
+	logger.Info("transaction started", "function", function, "txID", stub.GetTxID())
+
+	return nil
+}
+
+// logTransactionEnd logs the invoked function and transaction ID after a successful transaction.
+// The returned payload is never logged since it may carry private data.
+func logTransactionEnd(ctx contractapi.TransactionContextInterface) error {
+	stub := ctx.GetStub()
+	function, _ := stub.GetFunctionAndParameters()
+
+	logger.Info("transaction completed", "function", function, "txID", stub.GetTxID())
+
+	return nil
+}
+
+// LoggingChaincode wraps the contract chaincode and logs every failed transaction.
+// contractapi only runs the after transaction hook on success, so failures are logged here
+// with the function, transaction ID and status. The error message is never logged since it may carry private data.
+type LoggingChaincode struct {
+	*contractapi.ContractChaincode
+}
+
+// NewLoggingChaincode creates the contract chaincode for contract and wraps it in a LoggingChaincode.
+func NewLoggingChaincode(contract *FabricVulnBenchmark) (*LoggingChaincode, error) {
+	cc, err := contractapi.NewChaincode(contract)
+	if err != nil {
+		return nil, err
+	}
+
+	return &LoggingChaincode{ContractChaincode: cc}, nil
+}
+
+func (cc *LoggingChaincode) Init(stub shim.ChaincodeStubInterface) *peer.Response {
+	return logTransactionFailure(stub, cc.ContractChaincode.Init(stub))
+}
+
+func (cc *LoggingChaincode) Invoke(stub shim.ChaincodeStubInterface) *peer.Response {
+	return logTransactionFailure(stub, cc.ContractChaincode.Invoke(stub))
+}
+
+// Start runs the wrapped chaincode as an external chaincode server when CHAINCODE_SERVER_ADDRESS
+// and CORE_CHAINCODE_ID_NAME are set, and as a peer-launched chaincode otherwise, like contractapi does.
+func (cc *LoggingChaincode) Start() error {
+	address := os.Getenv("CHAINCODE_SERVER_ADDRESS")
+	ccid := os.Getenv("CORE_CHAINCODE_ID_NAME")
+	if address == "" || ccid == "" {
+		return shim.Start(cc)
+	}
+
+	tlsProps, err := loadTLSProperties()
+	if err != nil {
+		return err
+	}
+
+	server := &shim.ChaincodeServer{
+		CCID:     ccid,
+		Address:  address,
+		CC:       cc,
+		TLSProps: *tlsProps,
+	}
+
+	return server.Start()
+}
+
+// loadTLSProperties reads the chaincode server TLS settings from the same variables contractapi uses.
+func loadTLSProperties() (*shim.TLSProperties, error) {
+	tlsEnabled, _ := strconv.ParseBool(os.Getenv("CORE_PEER_TLS_ENABLED"))
+	if !tlsEnabled {
+		return &shim.TLSProperties{Disabled: true}, nil
+	}
+
+	key, err := os.ReadFile(os.Getenv("CORE_TLS_CLIENT_KEY_FILE"))
+	if err != nil {
+		return nil, fmt.Errorf("unable to read the TLS key: %w", err)
+	}
+
+	cert, err := os.ReadFile(os.Getenv("CORE_TLS_CLIENT_CERT_FILE"))
+	if err != nil {
+		return nil, fmt.Errorf("unable to read the TLS certificate: %w", err)
+	}
+
+	var rootCert []byte
+	if root := os.Getenv("CORE_PEER_TLS_ROOTCERT_FILE"); root != "" {
+		rootCert, err = os.ReadFile(root)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read the TLS root certificate: %w", err)
+		}
+	}
 
-func (sc *FabricVulnBenchmark) InitContract(ctx contractapi.TransactionContextInterface) error {
-	totalCapacity = 500
-	sc.ownerCounter = 1
-	return nil
+	return &shim.TLSProperties{Key: key, Cert: cert, ClientCACerts: rootCert}, nil
 }
 
-type Owner struct {
-	ID             int    `json:"id"`
-	Name           string `json:"name"`
-	Age            uint64 `json:"age"`
-	DocumentNumber string `json:"documentNumber"`
+// logTransactionFailure logs the invoked function, transaction ID and status of a failed transaction
+// and returns the response unchanged.
+func logTransactionFailure(stub shim.ChaincodeStubInterface, response *peer.Response) *peer.Response {
+	if response.Status < shim.ERRORTHRESHOLD {
+		return response
+	}
+
+	function, _ := stub.GetFunctionAndParameters()
+	logger.Error("transaction failed", "function", function, "txID", stub.GetTxID(), "status", response.Status)
+
+	return response
 }
 
-type Asset struct {
-	AssetType    string `json:"assetType"`
-	ID           string `json:"id"`
-	Description  string `json:"description"`
-	Amount       int32  `json:"amount"`
-	Owner        string `json:"owner"`
-	CreationTime string `json:"creationTime"`
+// requireAdmin checks that the client identity carries the admin role attribute.
+// It returns an error when the caller is not an admin.
+func requireAdmin(ctx contractapi.TransactionContextInterface) error {
+	err := ctx.GetClientIdentity().AssertAttributeValue(adminRoleAttr, adminRoleValue)
+	if err != nil {
+		return fmt.Errorf("caller is not authorized to perform this operation: %w", err)
+	}
+
+	return nil
 }
 
-// V: Non-determinism caused by the use of pointers and timestamp
-func (sc *FabricVulnBenchmark) CreateAsset(ctx contractapi.TransactionContextInterface, assetID, description, assetType, ownerID string) error {
-	stub := ctx.GetStub()
+// requireTransientField returns a transient value, failing when the key is missing or empty.
+func requireTransientField(transientMap map[string][]byte, name string) ([]byte, error) {
+	value, ok := transientMap[name]
+	if !ok || len(value) == 0 {
+		return nil, newValidationError(ValidationEmptyField, "missing transient field %s", name)
+	}
 
-	assetKey, err := stub.CreateCompositeKey("asset", []string{assetID})
+	return value, nil
+}
+
+// validateAge parses an owner age and checks that it is within (0, maxOwnerAge].
+// Errors never include the age itself.
+func validateAge(ageStr string) (uint64, error) {
+	age, err := strconv.ParseUint(ageStr, 10, 64)
 	if err != nil {
-		return errors.New("unable to create composite key")
+		// The parse error echoes the input, so it is not wrapped to keep the age private.
+		return 0, errors.New("unable to parse string to uint")
 	}
 
-	// V: Unhandled error
-	existing, err := stub.GetState(assetKey)
+	if age == 0 || age > maxOwnerAge {
+		return 0, newValidationError(ValidationOutOfRange, "owner age is out of the accepted range")
+	}
 
-	if existing != nil {
-		return fmt.Errorf("cannot create world state pair with key %s. Already exists", assetID)
+	return age, nil
+}
+
+// writeAuditRecord stores who wrote the state key built from objectType and keyParts, through which function
+// and when, under audit~key~txid [objectType, keyParts..., txID] so the records of one key form a key range.
+// Asset, owner, reservation and configuration writes are audited; index entries derived from them are not.
+func writeAuditRecord(ctx contractapi.TransactionContextInterface, objectType string, keyParts ...string) error {
+	stub := ctx.GetStub()
+
+	function, _ := stub.GetFunctionAndParameters()
+
+	caller, err := ctx.GetClientIdentity().GetID()
+	if err != nil {
+		return fmt.Errorf("unable to get client identity: %w", err)
 	}
 
-	ownerBytes, err := stub.GetState(ownerID)
+	timestamp, err := txTimestamp(ctx)
 	if err != nil {
-		return errors.New("unable to interact with world state")
+		return err
 	}
-	if ownerBytes == nil {
-		return errors.New("owner does not exist")
+
+	record := AuditRecord{
+		TxID:       stub.GetTxID(),
+		Operation:  function,
+		ObjectType: objectType,
+		Key:        keyParts,
+		Caller:     caller,
+		Timestamp:  timestamp,
 	}
 
-	var owner Owner
-	err = json.Unmarshal(ownerBytes, &owner)
+	auditKey, err := stub.CreateCompositeKey("audit~key~txid", append(append([]string{objectType}, keyParts...), record.TxID))
 	if err != nil {
-		return errors.New("unable to unmarshal")
+		return fmt.Errorf("unable to create composite key: %w", err)
 	}
 
-	var asset Asset
-	asset.AssetType = assetType
-	asset.Description = description
-	asset.ID = assetID
-	asset.Amount = 1
-	asset.Owner = fmt.Sprintf("%p", &owner)                          // V: Pointer.
-	asset.CreationTime = time.Now().Format("Jan _2 15:04:05.000000") // V: Timestamp.
-
-	assetBytes, err := json.Marshal(asset)
+	recordBytes, err := marshalCanonical(record)
 	if err != nil {
-		return errors.New("unable to marshal asset")
+		return fmt.Errorf("unable to marshal audit record: %w", err)
 	}
 
-	err = stub.PutState(assetKey, []byte(assetBytes))
+	err = stub.PutState(auditKey, recordBytes)
 	if err != nil {
-		return errors.New("unable to interact with world state")
+		return fmt.Errorf("unable to interact with world state: %w", err)
 	}
 
 	return nil
 }
 
-// V: Privacy leakage from private data in arguments, branch condition and returned payload
-func (sc *FabricVulnBenchmark) CreateOwner(ctx contractapi.TransactionContextInterface, name, documentNumber string) (string, error) {
+// readDocumentIndex looks up the transient documentNumber in the private document index.
+// It returns the stored owner ID, or nil when the document is not registered.
+func readDocumentIndex(ctx contractapi.TransactionContextInterface) ([]byte, error) {
 	stub := ctx.GetStub()
 
 	transientMap, err := stub.GetTransient()
 	if err != nil {
-		return "", errors.New("unable to get transient data")
+		return nil, fmt.Errorf("unable to get transient data: %w", err)
 	}
 
-	age, err := strconv.ParseUint(string(transientMap["ownerAge"]), 10, 64)
+	documentNumber, err := requireTransientField(transientMap, "documentNumber")
 	if err != nil {
-		return "", errors.New("unable to parse string to uint")
+		return nil, err
 	}
 
-	if age < 18 { // V: Privacy leakage: private data in branch statement
-		return "", fmt.Errorf("owner (%s, %s) must be at least 18 years old", name, documentNumber)
+	documentKey, err := stub.CreateCompositeKey("documentHash", []string{documentHash(string(documentNumber))})
+	if err != nil {
+		return nil, fmt.Errorf("unable to create composite key: %w", err)
 	}
 
-	var ownerPublic Owner
-	ownerPublic.ID = sc.ownerCounter
-	sc.ownerCounter = sc.ownerCounter + 1
-
-	ownerPublicBytes, err := json.Marshal(ownerPublic)
+	ownerID, err := stub.GetPrivateData(privateCollection, documentKey)
 	if err != nil {
-		return "", errors.New("unable to marshal asset")
+		return nil, fmt.Errorf("unable to read private data: %w", err)
 	}
 
-	err = stub.PutState(strconv.Itoa(ownerPublic.ID), ownerPublicBytes)
-	if err != nil {
-		return "", errors.New("unable to interact with world state")
+	return ownerID, nil
+}
+
+// readOwner reads the public owner record stored under the owner composite key.
+func readOwner(ctx contractapi.TransactionContextInterface, ownerID string) (*Owner, error) {
+	stub := ctx.GetStub()
+
+	if err := validateKeyComponent(ownerID); err != nil {
+		return nil, err
 	}
 
-	var ownerPrivate Owner
-	ownerPrivate.Age = age
-	ownerPrivate.Name = name
-	ownerPrivate.DocumentNumber = documentNumber
+	ownerKey, err := stub.CreateCompositeKey("owner", []string{ownerID})
+	if err != nil {
+		return nil, fmt.Errorf("unable to create composite key: %w", err)
+	}
 
-	ownerPrivateBytes, err := json.Marshal(ownerPrivate)
+	ownerBytes, err := stub.GetState(ownerKey)
 	if err != nil {
-		return "", errors.New("unable to marshal asset")
+		return nil, fmt.Errorf("unable to interact with world state: %w", err)
 	}
-	err = stub.PutPrivateData("collectionID", strconv.Itoa(ownerPublic.ID), ownerPrivateBytes)
+	if ownerBytes == nil {
+		return nil, newValidationError(ValidationNotFound, "owner does not exist")
+	}
+
+	var owner Owner
+	err = json.Unmarshal(ownerBytes, &owner)
 	if err != nil {
-		return "", errors.New("unable to store private data")
+		return nil, fmt.Errorf("unable to unmarshal owner: %w", err)
 	}
 
-	// V: Privacy leakage in returned payload
-	return fmt.Sprintf("Owner %s (%s) created successfully.", name, documentNumber), nil
+	return &owner, nil
 }
 
-// V: Non Determinism caused by concurrency (Go Routines), Math and Conversion overflow
-func (sc *FabricVulnBenchmark) UpdateAssetAmount(ctx contractapi.TransactionContextInterface, assetID, amountsJSON string) error {
+// ownerExists reports whether a public owner record is stored under the owner ID.
+func ownerExists(ctx contractapi.TransactionContextInterface, ownerID string) (bool, error) {
 	stub := ctx.GetStub()
 
-	var amounts []string
-	if err := json.Unmarshal([]byte(amountsJSON), &amounts); err != nil {
-		return err
-	}
-
-	assetKey, err := stub.CreateCompositeKey("asset", []string{assetID})
+	ownerKey, err := stub.CreateCompositeKey("owner", []string{ownerID})
 	if err != nil {
-		return errors.New("unable to create composite key")
+		return false, fmt.Errorf("unable to create composite key: %w", err)
 	}
 
-	assetBytes, err := stub.GetState(assetKey)
+	ownerBytes, err := stub.GetState(ownerKey)
 	if err != nil {
-		return errors.New("unable to interact with world state")
+		return false, fmt.Errorf("unable to interact with world state: %w", err)
 	}
-	if assetBytes == nil {
-		return fmt.Errorf("cannot update world state pair with key %s. Does not exist", assetID)
+
+	return ownerBytes != nil, nil
+}
+
+// requireOwnerOrAdmin checks that the caller is the identity bound to the owner, or an admin.
+func requireOwnerOrAdmin(ctx contractapi.TransactionContextInterface, ownerID string) error {
+	if requireAdmin(ctx) == nil {
+		return nil
 	}
 
-	var asset Asset
-	err = json.Unmarshal(assetBytes, &asset)
+	owner, err := readOwner(ctx, ownerID)
 	if err != nil {
-		return errors.New("unable to unmarshal asset")
+		return err
 	}
 
-	var wg sync.WaitGroup
-	for _, valueStr := range amounts {
-		wg.Add(1)
-		go func(incrementStr string) { // V: Concurrency
-			defer wg.Done()
-			value, _ := strconv.ParseInt(incrementStr, 10, 64) // V: Unhandled error leading to unsafe arithmetic
+	clientID, err := ctx.GetClientIdentity().GetID()
+	if err != nil {
+		return fmt.Errorf("unable to get client identity: %w", err)
+	}
 
-			res := asset.Amount + int32(value)           // V: Math and Conversion overflow
-			if res <= int32(totalCapacity) && res >= 0 { // V: Conversion overflow
-				asset.Amount = res
-			}
-		}(valueStr)
+	if owner.BoundIdentity == "" || clientID != owner.BoundIdentity {
+		return errors.New("caller is not the owner of the asset")
 	}
-	wg.Wait()
 
-	updatedAssetBytes, err := json.Marshal(asset)
+	return nil
+}
+
+// verifyCollectionMembership checks that the client belongs to the same org as the peer.
+// It returns an error when the client is not allowed to read the private collection.
+func verifyCollectionMembership(ctx contractapi.TransactionContextInterface) error {
+	clientMSPID, err := ctx.GetClientIdentity().GetMSPID()
 	if err != nil {
-		return errors.New("unable to marshal asset")
+		return fmt.Errorf("unable to get client MSPID: %w", err)
 	}
 
-	err = stub.PutState(assetKey, updatedAssetBytes)
+	peerMSPID, err := shim.GetMSPID()
 	if err != nil {
-		return errors.New("unable to interact with world state")
+		return fmt.Errorf("unable to get peer MSPID: %w", err)
+	}
+
+	if clientMSPID != peerMSPID {
+		return errors.New("client is not authorized to access private data")
 	}
 
 	return nil
 }
 
-// V: ReadAfterWrite
-func (sc *FabricVulnBenchmark) UpdateAssetDescription(ctx contractapi.TransactionContextInterface, assetID, description string) (*Asset, error) {
+// readReservation returns a reservation together with its world state key.
+func readReservation(ctx contractapi.TransactionContextInterface, reservationID string) (*Reservation, string, error) {
 	stub := ctx.GetStub()
 
-	assetKey, err := stub.CreateCompositeKey("asset", []string{assetID})
+	if err := validateKeyComponent(reservationID); err != nil {
+		return nil, "", err
+	}
+
+	reservationKey, err := stub.CreateCompositeKey("reservation", []string{reservationID})
 	if err != nil {
-		return nil, errors.New("unable to create composite key")
+		return nil, "", fmt.Errorf("unable to create composite key: %w", err)
 	}
 
-	assetBytes, err := stub.GetState(assetID)
+	reservationBytes, err := stub.GetState(reservationKey)
 	if err != nil {
-		return nil, errors.New("unable to interact with world state")
+		return nil, "", fmt.Errorf("unable to interact with world state: %w", err)
+	}
+	if reservationBytes == nil {
+		return nil, "", newValidationError(ValidationNotFound, "reservation %s does not exist", reservationID)
 	}
 
-	if assetBytes == nil {
-		return nil, fmt.Errorf("cannot update world state pair with key %s. Does not exist", assetID)
+	var reservation Reservation
+	err = json.Unmarshal(reservationBytes, &reservation)
+	if err != nil {
+		return nil, "", fmt.Errorf("unable to unmarshal reservation: %w", err)
 	}
 
-	var asset Asset
-	err = json.Unmarshal(assetBytes, &asset)
+	return &reservation, reservationKey, nil
+}
+
+// deleteReservation removes a reservation together with its asset~reservation index entry.
+func deleteReservation(ctx contractapi.TransactionContextInterface, reservation *Reservation, reservationKey string) error {
+	stub := ctx.GetStub()
+
+	err := stub.DelState(reservationKey)
 	if err != nil {
-		return nil, errors.New("unable to unmarshal asset")
+		return fmt.Errorf("unable to delete reservation: %w", err)
 	}
 
-	asset.Description = description
+	err = writeAuditRecord(ctx, "reservation", reservation.ID)
+	if err != nil {
+		return err
+	}
 
-	updatedAssetBytes, err := json.Marshal(asset)
+	indexKey, err := stub.CreateCompositeKey("asset~reservation", []string{reservation.AssetID, reservation.ID})
 	if err != nil {
-		return nil, errors.New("unable to marshal asset")
+		return fmt.Errorf("unable to create composite key: %w", err)
 	}
 
-	err = stub.PutState(assetKey, updatedAssetBytes)
+	err = stub.DelState(indexKey)
 	if err != nil {
-		return nil, errors.New("unable to interact with world state")
+		return fmt.Errorf("unable to interact with world state: %w", err)
 	}
 
-	// V: ReadAfterWrite
-	assetBytes, err = stub.GetState(assetKey)
+	return nil
+}
+
+// assertNoReservations rejects lifecycle changes that would strand the open reservations of an asset.
+func assertNoReservations(ctx contractapi.TransactionContextInterface, assetID string) error {
+	if err := validateKeyComponent(assetID); err != nil {
+		return err
+	}
+
+	iterator, err := ctx.GetStub().GetStateByPartialCompositeKey("asset~reservation", []string{assetID})
 	if err != nil {
-		return nil, errors.New("unable to interact with world state")
+		return fmt.Errorf("unable to interact with world state: %w", err)
+	}
+	defer iterator.Close()
+
+	if iterator.HasNext() {
+		return fmt.Errorf("asset %s has open reservations", assetID)
 	}
 
-	err = json.Unmarshal(assetBytes, &asset)
+	return nil
+}
+
+// tryReadAsset reads an asset from world state.
+// It returns (nil, false, nil) when the asset does not exist and an error only on ledger failures.
+func tryReadAsset(ctx contractapi.TransactionContextInterface, assetID string) (*Asset, bool, error) {
+	asset, _, err := getAsset(ctx, assetID)
 	if err != nil {
-		return nil, errors.New("unable to unmarshal asset")
+		return nil, false, err
 	}
 
-	return &asset, nil
+	return asset, asset != nil, nil
 }
 
-func (sc *FabricVulnBenchmark) ReadAsset(ctx contractapi.TransactionContextInterface, assetID string) (*Asset, error) {
+// getAsset builds the asset key, reads it from world state and unmarshals it.
+// It returns the stored bytes alongside the asset, and nil values without error when the asset does not exist.
+func getAsset(ctx contractapi.TransactionContextInterface, assetID string) (*Asset, []byte, error) {
 	stub := ctx.GetStub()
 
+	if err := validateKeyComponent(assetID); err != nil {
+		return nil, nil, err
+	}
+
 	assetKey, err := stub.CreateCompositeKey("asset", []string{assetID})
 	if err != nil {
-		return nil, errors.New("unable to create composite key")
+		return nil, nil, fmt.Errorf("unable to create composite key: %w", err)
 	}
 
 	assetBytes, err := stub.GetState(assetKey)
 	if err != nil {
-		return nil, errors.New("unable to interact with world state")
+		return nil, nil, fmt.Errorf("unable to interact with world state: %w", err)
 	}
 	if assetBytes == nil {
-		return nil, fmt.Errorf("cannot read world state pair with key %s. Does not exist", assetKey)
+		return nil, nil, nil
 	}
 
 	var asset Asset
 	err = json.Unmarshal(assetBytes, &asset)
 	if err != nil {
-		return nil, errors.New("unable to unmarshal asset")
+		return nil, nil, fmt.Errorf("unable to unmarshal asset: %w", err)
 	}
 
-	return &asset, nil
+	return &asset, assetBytes, nil
 }
 
-// V: ReadAfterWrite - Interprocedural
-func (sc *FabricVulnBenchmark) UpdateAssetDescriptionInterprocedural(ctx contractapi.TransactionContextInterface, assetID, description string) (*Asset, error) {
-	asset, err := sc.ReadAsset(ctx, assetID)
-	if err != nil {
-		return nil, err
+// updateOwnerIndex moves the owner~asset index entry of an asset from oldOwner to newOwner.
+// An empty owner means no entry. The new entry is always written so assets created before
+// the index existed are indexed on their next write.
+func updateOwnerIndex(ctx contractapi.TransactionContextInterface, assetID, oldOwner, newOwner string) error {
+	stub := ctx.GetStub()
+
+	if oldOwner != "" && oldOwner != newOwner {
+		if err := validateKeyComponent(oldOwner); err != nil {
+			return err
+		}
+
+		oldKey, err := stub.CreateCompositeKey("owner~asset", []string{oldOwner, assetID})
+		if err != nil {
+			return fmt.Errorf("unable to create composite key: %w", err)
+		}
+
+		err = stub.DelState(oldKey)
+		if err != nil {
+			return fmt.Errorf("unable to interact with world state: %w", err)
+		}
 	}
 
-	asset.Description = description
+	if newOwner == "" {
+		return nil
+	}
 
-	err = sc.writeAsset(ctx, assetID, asset)
+	if err := validateKeyComponent(newOwner); err != nil {
+		return err
+	}
+
+	newKey, err := stub.CreateCompositeKey("owner~asset", []string{newOwner, assetID})
 	if err != nil {
-		return nil, err
+		return fmt.Errorf("unable to create composite key: %w", err)
 	}
 
-	// V: ReadAfterWrite
-	return sc.ReadAsset(ctx, assetID)
+	err = stub.PutState(newKey, []byte{0x00})
+	if err != nil {
+		return fmt.Errorf("unable to interact with world state: %w", err)
+	}
+
+	return nil
 }
 
-// V: Range over map.
-func (sc *FabricVulnBenchmark) ReadAllAssets(ctx contractapi.TransactionContextInterface) ([]Asset, error) {
+// deleteAssetByKey removes an asset from world state together with its private record, if any.
+// The private data hash is used for the existence check so it also works on non-member peers.
+func deleteAssetByKey(ctx contractapi.TransactionContextInterface, assetKey, collection string) error {
 	stub := ctx.GetStub()
 
-	iterator, err := stub.GetStateByPartialCompositeKey("asset", []string{})
+	objectType, keyParts, err := stub.SplitCompositeKey(assetKey)
 	if err != nil {
-		return nil, errors.New("unable to interact with world state")
+		return fmt.Errorf("unable to split key: %w", err)
 	}
-	defer iterator.Close()
 
-	var assetsMap = make(map[string]Asset)
-	for iterator.HasNext() {
-		queryResponse, err := iterator.Next()
-		if err != nil {
-			return nil, errors.New("unable to get next element")
-		}
+	assetBytes, err := stub.GetState(assetKey)
+	if err != nil {
+		return fmt.Errorf("unable to interact with world state: %w", err)
+	}
 
-		_, cKeyParts, err := stub.SplitCompositeKey(queryResponse.GetKey())
+	if assetBytes != nil {
+		var asset Asset
+		err = json.Unmarshal(assetBytes, &asset)
 		if err != nil {
-			return nil, errors.New("unable to split key")
+			return fmt.Errorf("unable to unmarshal asset: %w", err)
 		}
 
-		var asset Asset
-		err = json.Unmarshal(queryResponse.GetValue(), &asset)
+		err = updateOwnerIndex(ctx, keyParts[0], asset.Owner, "")
 		if err != nil {
-			return nil, errors.New("unable to unmarshal")
+			return err
 		}
-
-		// cKeyParts[0] is the assetKey
-		assetsMap[cKeyParts[0]] = asset
 	}
 
-	var assets = make([]Asset, 0, 100)
-	// V: Range over map.
-	for _, asset := range assetsMap {
-		assets = append(assets, asset)
+	err = stub.DelState(assetKey)
+	if err != nil {
+		return fmt.Errorf("unable to interact with world state: %w", err)
 	}
 
-	return assets, nil
-}
-
-func (sc *FabricVulnBenchmark) ChangeTotalCapacity(valueStr string) error {
-	value, err := strconv.ParseUint(valueStr, 10, 64)
+	privateHash, err := stub.GetPrivateDataHash(collection, assetKey)
 	if err != nil {
-		return errors.New("unable to parse string to uint")
+		return fmt.Errorf("unable to read private data hash: %w", err)
 	}
 
-	totalCapacity = value
+	if privateHash != nil {
+		err = stub.DelPrivateData(collection, assetKey)
+		if err != nil {
+			return fmt.Errorf("unable to delete private data: %w", err)
+		}
+	}
 
-	return nil
+	return writeAuditRecord(ctx, objectType, keyParts...)
 }
 
-// V: cross-channel invocation - simulation
-func (sc *FabricVulnBenchmark) TransferAnotherAsset(ctx contractapi.TransactionContextInterface, ownerID, channel string) error {
-	stub := ctx.GetStub()
+// assetCollection returns the private collection holding an asset's private details.
+// An empty PrivateCollection means the default collection.
+func assetCollection(asset *Asset) string {
+	if asset.PrivateCollection == "" {
+		return privateCollection
+	}
 
-	response := stub.InvokeChaincode("TransferChaincode", toChaincodeArgs("TransferAnotherAsset", ownerID), channel)
-	if response.GetStatus() != shim.OK {
-		return errors.New("unable to invoke another chaincode")
+	return asset.PrivateCollection
+}
+
+// storedAssetCollection looks up the private collection of an asset by ID.
+// Private details without a public asset are looked up in the default collection.
+func storedAssetCollection(ctx contractapi.TransactionContextInterface, assetID string) (string, error) {
+	asset, _, err := getAsset(ctx, assetID)
+	if err != nil {
+		return "", err
+	}
+	if asset == nil {
+		return privateCollection, nil
 	}
 
-	return nil
+	return assetCollection(asset), nil
 }
 
-// V: Phantom Read
-func (sc *FabricVulnBenchmark) UpdateAssetsByType(ctx contractapi.TransactionContextInterface, assetType string) error {
+// readAssetPrivateDetails reads the private record stored for an asset.
+// It returns nil without error when no private record exists.
+func readAssetPrivateDetails(ctx contractapi.TransactionContextInterface, collection, assetID string) (*AssetPrivateDetails, error) {
 	stub := ctx.GetStub()
 
-	queryString := fmt.Sprintf(`{"selector":{"assetType":"%s"}}`, assetType)
+	if err := validateKeyComponent(assetID); err != nil {
+		return nil, err
+	}
 
-	resultsIterator, err := stub.GetQueryResult(queryString)
+	assetKey, err := stub.CreateCompositeKey("asset", []string{assetID})
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("unable to create composite key: %w", err)
 	}
-	defer resultsIterator.Close()
 
-	for resultsIterator.HasNext() {
-		queryResult, err := resultsIterator.Next()
-		if err != nil {
-			return err
-		}
-		var asset Asset
-		err = json.Unmarshal(queryResult.GetValue(), &asset)
-		if err != nil {
-			return err
-		}
+	privateDetailsBytes, err := stub.GetPrivateData(collection, assetKey)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read private data: %w", err)
+	}
+	if privateDetailsBytes == nil {
+		return nil, nil
+	}
 
-		asset.Amount += 1
+	var privateDetails AssetPrivateDetails
+	err = json.Unmarshal(privateDetailsBytes, &privateDetails)
+	if err != nil {
+		return nil, fmt.Errorf("unable to unmarshal asset private details: %w", err)
+	}
 
-		updatedAssetBytes, err := json.Marshal(asset)
-		if err != nil {
-			return errors.New("unable to marshal asset")
-		}
+	return &privateDetails, nil
+}
 
-		err = stub.PutState(queryResult.GetKey(), updatedAssetBytes)
-		if err != nil {
-			return errors.New("unable to interact with world state")
-		}
+// writeTransientAssetSecret stores the transient assetSecret, when present, as the private details of an asset.
+func writeTransientAssetSecret(ctx contractapi.TransactionContextInterface, assetID string) error {
+	transientMap, err := ctx.GetStub().GetTransient()
+	if err != nil {
+		return fmt.Errorf("unable to get transient data: %w", err)
 	}
 
-	return nil
-}
+	secret, ok := transientMap["assetSecret"]
+	if !ok || len(secret) == 0 {
+		return nil
+	}
 
-// V: Unhandled Error
-func (sc *FabricVulnBenchmark) UnhandledError(ctx contractapi.TransactionContextInterface, assetID string) {
-	sc.ReadAsset(ctx, "AssetID")
+	return writeAssetPrivateDetails(ctx, privateCollection, &AssetPrivateDetails{ID: assetID, Secret: string(secret)})
 }
 
-func (sc *FabricVulnBenchmark) writeAsset(ctx contractapi.TransactionContextInterface, assetID string, asset *Asset) error {
+func writeAssetPrivateDetails(ctx contractapi.TransactionContextInterface, collection string, privateDetails *AssetPrivateDetails) error {
 	stub := ctx.GetStub()
 
-	updatedAssetBytes, err := json.Marshal(asset)
+	if err := validateKeyComponent(privateDetails.ID); err != nil {
+		return err
+	}
+
+	assetKey, err := stub.CreateCompositeKey("asset", []string{privateDetails.ID})
 	if err != nil {
-		return errors.New("unable to marshal asset")
+		return fmt.Errorf("unable to create composite key: %w", err)
 	}
 
-	assetKey, err := stub.CreateCompositeKey("asset", []string{assetID})
+	privateDetailsBytes, err := marshalCanonical(privateDetails)
 	if err != nil {
-		return errors.New("unable to create composite key")
+		return fmt.Errorf("unable to marshal asset private details: %w", err)
 	}
 
-	err = stub.PutState(assetKey, updatedAssetBytes)
+	err = stub.PutPrivateData(collection, assetKey, privateDetailsBytes)
 	if err != nil {
-		return errors.New("unable to interact with world state")
+		return fmt.Errorf("unable to store private data: %w", err)
 	}
 
 	return nil
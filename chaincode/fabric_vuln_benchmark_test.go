@@ -0,0 +1,5461 @@
+package chaincode
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-chaincode-go/v2/shim"
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+	"github.com/hyperledger/fabric-protos-go-apiv2/ledger/queryresult"
+	"github.com/hyperledger/fabric-protos-go-apiv2/peer"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestAssetEndorsement(t *testing.T) {
+	env := newTestEnv(t)
+	aliceID := env.createOwner(aliceIdentity, "Alice", "DOC-A", "30")
+	env.createOwner(bobIdentity, "Bob", "DOC-B", "40")
+	env.createAsset("asset1", "gold", aliceID, 1)
+
+	setEndorsement := func(identity *mockIdentity, assetID string, orgs ...string) error {
+		return env.invoke(func(ctx contractapi.TransactionContextInterface) error {
+			return env.sc.SetAssetEndorsement(ctx, assetID, orgs)
+		}, as(identity))
+	}
+	getEndorsement := func() []string {
+		t.Helper()
+
+		var orgs []string
+		env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+			var err error
+			orgs, err = env.sc.GetAssetEndorsement(ctx, "asset1")
+			return err
+		})
+
+		return orgs
+	}
+
+	if orgs := getEndorsement(); orgs == nil || len(orgs) != 0 {
+		t.Fatalf("endorsement before set = %#v, want an empty slice", orgs)
+	}
+
+	expectValidationCode(t, setEndorsement(aliceIdentity, "asset1"), ValidationEmptyField)
+	expectValidationCode(t, setEndorsement(aliceIdentity, "missing", "Org1MSP"), ValidationNotFound)
+	expectError(t, setEndorsement(bobIdentity, "asset1", "Org2MSP"), "caller is not the owner of the asset")
+
+	if err := setEndorsement(aliceIdentity, "asset1", "Org3MSP", "Org1MSP", "Org2MSP"); err != nil {
+		t.Fatalf("SetAssetEndorsement: %v", err)
+	}
+	assetKey := compositeKey(t, "asset", "asset1")
+	if len(env.ledger.validation[assetKey]) == 0 {
+		t.Fatal("no state validation parameter stored for the asset key")
+	}
+
+	for round := 0; round < 5; round++ {
+		orgs := getEndorsement()
+		if len(orgs) != 3 || orgs[0] != "Org1MSP" || orgs[1] != "Org2MSP" || orgs[2] != "Org3MSP" {
+			t.Fatalf("endorsement = %v, want [Org1MSP Org2MSP Org3MSP]", orgs)
+		}
+	}
+}
+
+func TestArchivedAssetsAreHiddenFromListings(t *testing.T) {
+	env := newTestEnv(t)
+	ownerID := env.createOwner(aliceIdentity, "Alice", "DOC-A", "30")
+	env.createAsset("asset1", "gold", ownerID, 1)
+	env.createAsset("asset2", "gold", ownerID, 1)
+
+	err := env.invoke(func(ctx contractapi.TransactionContextInterface) error {
+		return env.sc.ArchiveAsset(ctx, "asset1")
+	}, as(bobIdentity))
+	expectError(t, err, "caller is not the owner of the asset")
+
+	env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+		return env.sc.ArchiveAsset(ctx, "asset1")
+	}, as(aliceIdentity))
+
+	readAll := func() []Asset {
+		var assets []Asset
+		env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+			var err error
+			assets, err = env.sc.ReadAllAssets(ctx)
+			return err
+		})
+		return assets
+	}
+
+	assets := readAll()
+	if len(assets) != 1 || assets[0].ID != "asset2" {
+		t.Fatalf("ReadAllAssets returned %v, want only asset2", assets)
+	}
+	if !env.readAsset("asset1").Archived {
+		t.Fatal("archived asset is not readable by ID")
+	}
+
+	var all []Asset
+	env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+		var err error
+		all, err = env.sc.ReadAllAssetsIncludingArchived(ctx)
+		return err
+	})
+	if len(all) != 2 {
+		t.Fatalf("ReadAllAssetsIncludingArchived returned %d assets, want 2", len(all))
+	}
+
+	err = env.invoke(func(ctx contractapi.TransactionContextInterface) error {
+		return env.sc.ArchiveAsset(ctx, "asset1")
+	}, as(aliceIdentity))
+	expectError(t, err, "already archived")
+
+	env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+		return env.sc.UnarchiveAsset(ctx, "asset1")
+	}, as(aliceIdentity))
+	if got := len(readAll()); got != 2 {
+		t.Fatalf("ReadAllAssets returned %d assets after unarchiving, want 2", got)
+	}
+}
+
+func TestOwnerAgeBounds(t *testing.T) {
+	env := newTestEnv(t)
+
+	create := func(documentNumber, age string) error {
+		return env.invoke(func(ctx contractapi.TransactionContextInterface) error {
+			_, err := env.sc.createOwner(ctx, "Owner", documentNumber)
+			return err
+		}, withTransient("ownerAge", age))
+	}
+
+	for _, age := range []string{"18", "150"} {
+		if err := create("DOC-"+age, age); err != nil {
+			t.Fatalf("age %s rejected: %v", age, err)
+		}
+	}
+
+	for _, age := range []string{"0", "151", "999"} {
+		err := create("DOC-"+age, age)
+		expectValidationCode(t, err, ValidationOutOfRange)
+		if strings.Contains(err.Error(), age) {
+			t.Fatalf("error for age %s leaks the age: %v", age, err)
+		}
+	}
+
+	expectError(t, create("DOC-17", "17"), "must be at least 18 years old")
+
+	for _, age := range []string{"-1", "abc", "1e3"} {
+		err := create("DOC-X", age)
+		expectError(t, err, "unable to parse string to uint")
+		if strings.Contains(err.Error(), age) {
+			t.Fatalf("error for age %q leaks the input: %v", age, err)
+		}
+	}
+
+	expectValidationCode(t, env.invoke(func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.sc.createOwner(ctx, "Owner", "DOC-Y")
+		return err
+	}), ValidationEmptyField)
+}
+
+func TestAssetPrivateDetails(t *testing.T) {
+	const secret = "vault-combination-4711"
+
+	env := newTestEnv(t)
+	aliceID := env.createOwner(aliceIdentity, "Alice", "DOC-A", "30")
+
+	env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+		return env.sc.CreateAssetWithAmount(ctx, "asset1", "public", "gold", aliceID, 1)
+	}, withTransient("assetSecret", secret))
+	for name, payload := range env.lastStub.events {
+		if strings.Contains(string(payload), secret) {
+			t.Fatalf("event %s leaks the secret", name)
+		}
+	}
+	env.createAsset("asset2", "gold", aliceID, 1)
+
+	for key, value := range env.ledger.state {
+		if strings.Contains(string(value), secret) {
+			t.Fatalf("world state key %q leaks the secret", key)
+		}
+	}
+	if asset := env.readAsset("asset1"); strings.Contains(asset.Description, secret) {
+		t.Fatal("public asset record leaks the secret")
+	}
+
+	readSecret := func(assetID string, identity *mockIdentity) (string, error) {
+		var value string
+		err := env.invoke(func(ctx contractapi.TransactionContextInterface) error {
+			var err error
+			value, err = env.sc.ReadAssetPrivateDetails(ctx, assetID)
+			return err
+		}, as(identity))
+		return value, err
+	}
+
+	value, err := readSecret("asset1", aliceIdentity)
+	if err != nil || value != secret {
+		t.Fatalf("ReadAssetPrivateDetails = %q, %v, want the stored secret", value, err)
+	}
+
+	_, err = readSecret("asset1", outsiderIdentity)
+	expectError(t, err, "client is not authorized to access private data")
+
+	_, err = readSecret("asset2", aliceIdentity)
+	expectValidationCode(t, err, ValidationNotFound)
+}
+
+func TestTransferAnotherAsset(t *testing.T) {
+	env := newTestEnv(t)
+
+	transfer := func(ownerID, channel string, invoke func(string, [][]byte, string) *peer.Response) (string, error) {
+		var payload string
+		err := env.invoke(func(ctx contractapi.TransactionContextInterface) error {
+			var err error
+			payload, err = env.sc.TransferAnotherAsset(ctx, "TransferChaincode", ownerID, channel)
+			return err
+		}, withInvoke(invoke))
+		return payload, err
+	}
+
+	var gotArgs [][]byte
+	var gotChannel string
+	payload, err := transfer("owner-7", "other-channel", func(_ string, args [][]byte, channel string) *peer.Response {
+		gotArgs, gotChannel = args, channel
+		return shim.Success([]byte(`{"transferred":true}`))
+	})
+	if err != nil || payload != `{"transferred":true}` {
+		t.Fatalf("TransferAnotherAsset = %q, %v, want the invoked chaincode payload", payload, err)
+	}
+	if gotChannel != "other-channel" || len(gotArgs) != 2 || string(gotArgs[0]) != "TransferAnotherAsset" || string(gotArgs[1]) != "owner-7" {
+		t.Fatalf("invoked with args %q on channel %q", gotArgs, gotChannel)
+	}
+
+	_, err = transfer("owner-7", "other-channel", func(string, [][]byte, string) *peer.Response {
+		return shim.Error("owner owner-7 is unknown")
+	})
+	expectError(t, err, "unable to invoke another chaincode: owner owner-7 is unknown")
+
+	noCall := func(string, [][]byte, string) *peer.Response {
+		t.Fatal("InvokeChaincode called with invalid arguments")
+		return nil
+	}
+	_, err = transfer("", "other-channel", noCall)
+	expectValidationCode(t, err, ValidationEmptyField)
+	_, err = transfer("owner-7", "", noCall)
+	expectValidationCode(t, err, ValidationEmptyField)
+}
+
+func TestTransferAnotherAssetUsesTheGivenChaincode(t *testing.T) {
+	env := newTestEnv(t)
+
+	for _, chaincodeName := range []string{"TransferChaincode", "asset-transfer-v2"} {
+		var invoked string
+		env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+			_, err := env.sc.TransferAnotherAsset(ctx, chaincodeName, "owner-7", "other-channel")
+			return err
+		}, withInvoke(func(name string, _ [][]byte, _ string) *peer.Response {
+			invoked = name
+			return shim.Success(nil)
+		}))
+		if invoked != chaincodeName {
+			t.Fatalf("invoked chaincode %q, want %q", invoked, chaincodeName)
+		}
+	}
+
+	err := env.invoke(func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.sc.TransferAnotherAsset(ctx, "", "owner-7", "other-channel")
+		return err
+	})
+	expectValidationCode(t, err, ValidationEmptyField)
+
+	// Without a handler the mock reports the chaincode as not installed.
+	err = env.invoke(func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.sc.TransferAnotherAsset(ctx, "missing-chaincode", "owner-7", "other-channel")
+		return err
+	})
+	expectError(t, err, "chaincode missing-chaincode is not installed")
+}
+
+func TestOwnerCountAndPagination(t *testing.T) {
+	env := newTestEnv(t)
+	aliceID := env.createOwner(aliceIdentity, "Alice", "DOC-A", "30")
+	env.createOwner(bobIdentity, "Bob", "DOC-B", "40")
+	env.createOwner(outsiderIdentity, "Carol", "DOC-C", "50")
+	env.createAsset("asset1", "gold", aliceID, 1)
+
+	var count int
+	env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+		var err error
+		count, err = env.sc.GetOwnerCount(ctx)
+		return err
+	})
+	if count != 3 {
+		t.Fatalf("GetOwnerCount = %d, want 3", count)
+	}
+
+	page := func(bookmark string) *PaginatedOwnerResult {
+		t.Helper()
+
+		var result *PaginatedOwnerResult
+		env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+			var err error
+			result, err = env.sc.ReadOwnersPaginated(ctx, 2, bookmark)
+			return err
+		})
+
+		return result
+	}
+
+	first := page("")
+	if len(first.Owners) != 2 || first.FetchedRecordsCount != 2 || first.Bookmark == "" {
+		t.Fatalf("first page = %+v", first)
+	}
+	second := page(first.Bookmark)
+	if len(second.Owners) != 1 || second.Bookmark != "" {
+		t.Fatalf("second page = %+v", second)
+	}
+
+	seen := make(map[int]bool)
+	for _, owner := range append(first.Owners, second.Owners...) {
+		if owner.Name != "" || owner.DocumentNumber != "" || owner.Age != 0 || owner.BoundIdentity != "" {
+			t.Fatalf("page exposes more than the owner ID: %+v", owner)
+		}
+		seen[owner.ID] = true
+	}
+	if len(seen) != 3 || !seen[1] || !seen[2] || !seen[3] {
+		t.Fatalf("pages listed owners %v, want 1, 2 and 3", seen)
+	}
+
+	err := env.invoke(func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.sc.ReadOwnersPaginated(ctx, 0, "")
+		return err
+	})
+	expectValidationCode(t, err, ValidationOutOfRange)
+}
+
+func TestInitContractGuard(t *testing.T) {
+	env := newTestEnv(t)
+	env.ledger = newMockLedger()
+	env.sc = new(FabricVulnBenchmark)
+
+	ping := func() string {
+		t.Helper()
+
+		var status string
+		env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+			var err error
+			status, err = env.sc.Ping(ctx)
+			return err
+		})
+
+		return status
+	}
+
+	if status := ping(); status != "FabricVulnBenchmark initialized=false" {
+		t.Fatalf("Ping before init = %q", status)
+	}
+
+	env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+		return env.sc.InitContract(ctx, false)
+	}, as(aliceIdentity))
+	if status := ping(); status != "FabricVulnBenchmark initialized=true" {
+		t.Fatalf("Ping after init = %q", status)
+	}
+	if _, ok := env.ledger.state[compositeKey(t, "config", totalCapacityConfig)]; !ok {
+		t.Fatal("init did not persist the total capacity")
+	}
+
+	env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+		return env.sc.SetOwnerIDScheme(ctx, "counter")
+	})
+	env.createOwner(aliceIdentity, "Alice", "DOC-A", "30")
+
+	err := env.invoke(func(ctx contractapi.TransactionContextInterface) error {
+		return env.sc.InitContract(ctx, false)
+	})
+	expectError(t, err, "contract is already initialized")
+	err = env.invoke(func(ctx contractapi.TransactionContextInterface) error {
+		return env.sc.InitContract(ctx, true)
+	}, as(aliceIdentity))
+	expectError(t, err, "caller is not authorized")
+
+	// A forced re-init does not reset the owner counter, so the next ID is not reused.
+	env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+		return env.sc.InitContract(ctx, true)
+	})
+	if id := env.createOwner(bobIdentity, "Bob", "DOC-B", "40"); id != "2" {
+		t.Fatalf("owner ID after forced re-init = %s, want 2", id)
+	}
+}
+
+func TestTransferAmount(t *testing.T) {
+	env := newTestEnv(t)
+	aliceID := env.createOwner(aliceIdentity, "Alice", "DOC-A", "30")
+	bobID := env.createOwner(bobIdentity, "Bob", "DOC-B", "40")
+	env.createAsset("a1", "gold", aliceID, 20)
+	env.createAsset("a2", "gold", aliceID, 490)
+	env.createAsset("a3", "silver", aliceID, 5)
+	env.createAsset("b1", "gold", bobID, 5)
+
+	move := func(from, to string, amount int32, identity *mockIdentity) error {
+		return env.invoke(func(ctx contractapi.TransactionContextInterface) error {
+			return env.sc.TransferAmount(ctx, from, to, amount)
+		}, as(identity))
+	}
+
+	expectValidationCode(t, move("a1", "a2", 21, aliceIdentity), ValidationOutOfRange)
+	expectValidationCode(t, move("a1", "a2", 0, aliceIdentity), ValidationOutOfRange)
+	expectError(t, move("a1", "a1", 1, aliceIdentity), "source and target assets must be different")
+	expectError(t, move("a1", "b1", 1, aliceIdentity), "assets must belong to the same owner")
+	expectError(t, move("a1", "a3", 1, aliceIdentity), "must be of the same type")
+	expectError(t, move("a1", "a2", 1, bobIdentity), "caller is not the owner of the asset")
+	expectValidationCode(t, move("a1", "missing", 1, aliceIdentity), ValidationNotFound)
+
+	if err := move("a1", "a2", 5, aliceIdentity); err != nil {
+		t.Fatalf("TransferAmount: %v", err)
+	}
+	if from, to := env.readAsset("a1").Amount, env.readAsset("a2").Amount; from != 15 || to != 495 {
+		t.Fatalf("after transfer a1=%d a2=%d, want 15 and 495", from, to)
+	}
+
+	err := move("a1", "a2", 6, aliceIdentity)
+	expectValidationCode(t, err, ValidationOutOfRange)
+	expectError(t, err, "asset a2 would exceed the total capacity")
+	if from, to := env.readAsset("a1").Amount, env.readAsset("a2").Amount; from != 15 || to != 495 {
+		t.Fatalf("rejected transfer changed amounts: a1=%d a2=%d", from, to)
+	}
+}
+
+func TestAmountsAreBoundCheckedAgainstWideCapacities(t *testing.T) {
+	env := newTestEnv(t)
+	aliceID := env.createOwner(aliceIdentity, "Alice", "DOC-A", "30")
+	env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+		return env.sc.SetTotalCapacity(ctx, math.MaxInt64)
+	})
+
+	// The configured capacity is reported in the width it is stored and checked in.
+	var config *ContractConfig
+	env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+		var err error
+		config, err = env.sc.GetContractConfig(ctx)
+		return err
+	})
+	if config.TotalCapacity != math.MaxInt64 {
+		t.Fatalf("configured total capacity = %d, want %d", config.TotalCapacity, int64(math.MaxInt64))
+	}
+
+	// A capacity wider than an int32 amount must not let the target amount wrap around.
+	env.createAsset("from", "gold", aliceID, 10)
+	env.createAsset("to", "gold", aliceID, math.MaxInt32-2)
+	err := env.invoke(func(ctx contractapi.TransactionContextInterface) error {
+		return env.sc.TransferAmount(ctx, "from", "to", 5)
+	}, as(aliceIdentity))
+	expectValidationCode(t, err, ValidationOutOfRange)
+	if from, to := env.readAsset("from").Amount, env.readAsset("to").Amount; from != 10 || to != math.MaxInt32-2 {
+		t.Fatalf("after the rejected transfer from=%d to=%d", from, to)
+	}
+
+	err = env.invoke(func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.sc.IncrementAssetAmount(ctx, "to", 5)
+		return err
+	}, as(aliceIdentity))
+	expectValidationCode(t, err, ValidationOutOfRange)
+}
+
+func TestOwnerCreationLogsNoPrivateData(t *testing.T) {
+	env := newTestEnv(t)
+
+	var logs bytes.Buffer
+	logger = slog.New(slog.NewJSONHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	env.createOwner(aliceIdentity, "Alice Liddell", "DOC-7391", "42")
+	for _, age := range []string{"7", "200", "forty"} {
+		age := age
+		err := env.invoke(func(ctx contractapi.TransactionContextInterface) error {
+			_, err := env.sc.createOwner(ctx, "Alice Liddell", "DOC-7391")
+			return err
+		}, withTransient("ownerAge", age))
+		if err == nil {
+			t.Fatalf("age %s accepted", age)
+		}
+	}
+
+	if !strings.Contains(logs.String(), `"msg":"owner created"`) {
+		t.Fatalf("owner creation was not logged: %s", logs.String())
+	}
+	// Numeric ages could match timestamps, so only the age attribute and the unparsable input are checked.
+	for _, private := range []string{"Alice Liddell", "DOC-7391", `"age"`, "forty"} {
+		if strings.Contains(logs.String(), private) {
+			t.Fatalf("logs contain private value %q: %s", private, logs.String())
+		}
+	}
+}
+
+func TestFailedTransactionsAreLogged(t *testing.T) {
+	env := newTestEnv(t)
+
+	var logs bytes.Buffer
+	logger = slog.New(slog.NewJSONHandler(&logs, nil))
+
+	cc, err := NewLoggingChaincode(env.sc)
+	if err != nil {
+		t.Fatalf("unable to create chaincode: %v", err)
+	}
+
+	var response *peer.Response
+	env.invoke(func(ctx contractapi.TransactionContextInterface) error {
+		response = cc.Invoke(ctx.GetStub())
+		return nil
+	}, withFunction("CreateOwner", "Alice Liddell", "DOC-7391"), withTransient("ownerAge", "7"), withTxID("tx-failed"))
+
+	if response.Status < shim.ERRORTHRESHOLD {
+		t.Fatalf("underage owner accepted: %s", response.Payload)
+	}
+	for _, want := range []string{`"msg":"transaction failed"`, `"function":"CreateOwner"`, `"txID":"tx-failed"`} {
+		if !strings.Contains(logs.String(), want) {
+			t.Fatalf("logs do not contain %s: %s", want, logs.String())
+		}
+	}
+	for _, private := range []string{"Alice Liddell", "DOC-7391"} {
+		if strings.Contains(logs.String(), private) {
+			t.Fatalf("logs contain private value %q: %s", private, logs.String())
+		}
+	}
+
+	logs.Reset()
+	env.invoke(func(ctx contractapi.TransactionContextInterface) error {
+		response = cc.Invoke(ctx.GetStub())
+		return nil
+	}, withFunction("GetRequiredIndexes"))
+
+	if response.Status >= shim.ERRORTHRESHOLD {
+		t.Fatalf("transaction failed: %s", response.Message)
+	}
+	if strings.Contains(logs.String(), "transaction failed") {
+		t.Fatalf("successful transaction logged as failed: %s", logs.String())
+	}
+}
+
+func TestCreateAssetWithAmount(t *testing.T) {
+	env := newTestEnv(t)
+	aliceID := env.createOwner(aliceIdentity, "Alice", "DOC-A", "30")
+
+	create := func(assetID string, amount int32) error {
+		return env.invoke(func(ctx contractapi.TransactionContextInterface) error {
+			return env.sc.CreateAssetWithAmount(ctx, assetID, "description", "gold", aliceID, amount)
+		})
+	}
+
+	env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+		return env.sc.CreateAsset(ctx, "default", "description", "gold", aliceID)
+	})
+	if got := env.readAsset("default").Amount; got != 1 {
+		t.Fatalf("CreateAsset amount = %d, want 1", got)
+	}
+
+	for assetID, amount := range map[string]int32{"zero": 0, "some": 42, "full": defaultTotalCapacity} {
+		if err := create(assetID, amount); err != nil {
+			t.Fatalf("amount %d rejected: %v", amount, err)
+		}
+		if got := env.readAsset(assetID).Amount; got != amount {
+			t.Fatalf("asset %s amount = %d, want %d", assetID, got, amount)
+		}
+	}
+
+	err := create("negative", -1)
+	expectValidationCode(t, err, ValidationOutOfRange)
+	expectError(t, err, "amount must not be negative")
+	err = create("over", defaultTotalCapacity+1)
+	expectValidationCode(t, err, ValidationOutOfRange)
+	expectError(t, err, "amount exceeds the total capacity")
+	if env.assetExists("negative") || env.assetExists("over") {
+		t.Fatal("rejected asset was created")
+	}
+}
+
+func TestDeleteAssetsByType(t *testing.T) {
+	env := newTestEnv(t)
+	aliceID := env.createOwner(aliceIdentity, "Alice", "DOC-A", "30")
+	env.createAsset("g1", "gold", aliceID, 1)
+	env.createAsset("s1", "silver", aliceID, 1)
+	env.createAsset("g2", "gold", aliceID, 1)
+	env.createAsset("g3", "gold", aliceID, 1)
+	env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+		return env.sc.FreezeAsset(ctx, "g3")
+	})
+
+	deleteGold := func(identity *mockIdentity) (int, error) {
+		var deleted int
+		err := env.invoke(func(ctx contractapi.TransactionContextInterface) error {
+			var err error
+			deleted, err = env.sc.DeleteAssetsByType(ctx, "gold")
+			return err
+		}, as(identity))
+		return deleted, err
+	}
+
+	_, err := deleteGold(aliceIdentity)
+	expectError(t, err, "caller is not authorized")
+	if !env.assetExists("g1") {
+		t.Fatal("unauthorized call deleted assets")
+	}
+
+	// The frozen g3 fails the whole deletion.
+	_, err = deleteGold(adminIdentity)
+	expectError(t, err, "asset g3 is frozen")
+	for _, assetID := range []string{"g1", "g2", "g3"} {
+		if !env.assetExists(assetID) {
+			t.Fatalf("asset %s was deleted despite the frozen asset", assetID)
+		}
+	}
+
+	env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+		return env.sc.UnfreezeAsset(ctx, "g3")
+	})
+	deleted, err := deleteGold(adminIdentity)
+	if err != nil || deleted != 3 {
+		t.Fatalf("DeleteAssetsByType = %d, %v, want 3", deleted, err)
+	}
+	for assetID, want := range map[string]bool{"g1": false, "g2": false, "g3": false, "s1": true} {
+		if got := env.assetExists(assetID); got != want {
+			t.Fatalf("asset %s exists = %t, want %t", assetID, got, want)
+		}
+	}
+
+	deleted, err = deleteGold(adminIdentity)
+	if err != nil || deleted != 0 {
+		t.Fatalf("second DeleteAssetsByType = %d, %v, want 0", deleted, err)
+	}
+}
+
+func TestWorldStateErrorsAreWrapped(t *testing.T) {
+	env := newTestEnv(t)
+	aliceID := env.createOwner(aliceIdentity, "Alice", "DOC-A", "30")
+	env.createAsset("asset1", "gold", aliceID, 1)
+
+	errLedger := errors.New("ledger unavailable")
+	calls := map[string]func(ctx contractapi.TransactionContextInterface) error{
+		"ReadAsset": func(ctx contractapi.TransactionContextInterface) error {
+			_, err := env.sc.ReadAsset(ctx, "asset1")
+			return err
+		},
+		"TryReadAsset": func(ctx contractapi.TransactionContextInterface) error {
+			_, err := env.sc.TryReadAsset(ctx, "asset1")
+			return err
+		},
+		"CreateAsset": func(ctx contractapi.TransactionContextInterface) error {
+			return env.sc.CreateAsset(ctx, "asset2", "description", "gold", aliceID)
+		},
+		"TransferAsset": func(ctx contractapi.TransactionContextInterface) error {
+			return env.sc.TransferAsset(ctx, "asset1", aliceID)
+		},
+		"DeleteAsset": func(ctx contractapi.TransactionContextInterface) error {
+			return env.sc.DeleteAsset(ctx, "asset1")
+		},
+		"GetContractConfig": func(ctx contractapi.TransactionContextInterface) error {
+			_, err := env.sc.GetContractConfig(ctx)
+			return err
+		},
+	}
+	for name, call := range calls {
+		t.Run(name, func(t *testing.T) {
+			err := env.invoke(call, withGetStateError(errLedger))
+			if !errors.Is(err, errLedger) {
+				t.Fatalf("error %v does not wrap the GetState failure", err)
+			}
+			if errors.Unwrap(err) == nil {
+				t.Fatalf("error %v has no wrapped cause", err)
+			}
+		})
+	}
+}
+
+func TestGetAssetsByAmountRange(t *testing.T) {
+	env := newTestEnv(t)
+	aliceID := env.createOwner(aliceIdentity, "Alice", "DOC-A", "30")
+	for assetID, amount := range map[string]int32{"e": 0, "d": 10, "c": 20, "b": 30, "a": 40, "z": 20} {
+		env.createAsset(assetID, "gold", aliceID, amount)
+	}
+	env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+		return env.sc.ArchiveAsset(ctx, "z")
+	})
+
+	query := func(minAmount, maxAmount int32) ([]Asset, error) {
+		var assets []Asset
+		err := env.invoke(func(ctx contractapi.TransactionContextInterface) error {
+			var err error
+			assets, err = env.sc.GetAssetsByAmountRange(ctx, minAmount, maxAmount)
+			return err
+		})
+		return assets, err
+	}
+	mustQuery := func(minAmount, maxAmount int32) []Asset {
+		t.Helper()
+
+		assets, err := query(minAmount, maxAmount)
+		if err != nil {
+			t.Fatalf("GetAssetsByAmountRange(%d, %d): %v", minAmount, maxAmount, err)
+		}
+		return assets
+	}
+
+	expectAssetIDs(t, mustQuery(10, 30), "b", "c", "d")
+	expectAssetIDs(t, mustQuery(20, 20), "c")
+	expectAssetIDs(t, mustQuery(0, 0), "e")
+	expectAssetIDs(t, mustQuery(-5, 1000), "a", "b", "c", "d", "e")
+	expectAssetIDs(t, mustQuery(11, 19))
+	if assets := mustQuery(41, 50); assets == nil {
+		t.Fatal("empty range returned nil instead of an empty slice")
+	}
+
+	_, err := query(30, 10)
+	expectValidationCode(t, err, ValidationOutOfRange)
+}
+
+func TestHierarchicalAssets(t *testing.T) {
+	env := newTestEnv(t)
+	aliceID := env.createOwner(aliceIdentity, "Alice", "DOC-A", "30")
+
+	create := func(assetType, serial, ownerID string) error {
+		return env.invoke(func(ctx contractapi.TransactionContextInterface) error {
+			return env.sc.CreateHierarchicalAsset(ctx, assetType, serial, "bar "+serial, ownerID)
+		})
+	}
+	listByType := func(assetType string) []Asset {
+		t.Helper()
+
+		var assets []Asset
+		env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+			var err error
+			assets, err = env.sc.GetHierarchicalAssetsByType(ctx, assetType)
+			return err
+		})
+
+		return assets
+	}
+
+	expectValidationCode(t, create("golden", "s3", aliceID), ValidationNotFound)
+	env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+		return env.sc.RegisterAssetType(ctx, "golden")
+	})
+	for _, key := range [][2]string{{"gold", "s2"}, {"gold", "s1"}, {"silver", "s1"}, {"golden", "s3"}} {
+		if err := create(key[0], key[1], aliceID); err != nil {
+			t.Fatalf("CreateHierarchicalAsset(%s, %s): %v", key[0], key[1], err)
+		}
+	}
+
+	expectValidationCode(t, create("gold", "s1", aliceID), ValidationAlreadyExists)
+	expectValidationCode(t, create("gold", "", aliceID), ValidationEmptyField)
+	expectValidationCode(t, create("gold", "s9", "999"), ValidationNotFound)
+
+	var asset *Asset
+	env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+		var err error
+		asset, err = env.sc.ReadHierarchicalAsset(ctx, "silver", "s1")
+		return err
+	})
+	if asset.AssetType != "silver" || asset.ID != "s1" || asset.Owner != aliceID || asset.Description != "bar s1" {
+		t.Fatalf("ReadHierarchicalAsset = %+v", asset)
+	}
+	err := env.invoke(func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.sc.ReadHierarchicalAsset(ctx, "silver", "s2")
+		return err
+	})
+	expectValidationCode(t, err, ValidationNotFound)
+
+	// The partial key matches whole type components only, so golden is not listed under gold.
+	expectAssetIDs(t, listByType("gold"), "s1", "s2")
+	expectAssetIDs(t, listByType("silver"), "s1")
+	expectAssetIDs(t, listByType("copper"))
+
+	// Hierarchical assets live in their own keyspace.
+	if env.assetExists("s1") {
+		t.Fatal("hierarchical asset is visible as a plain asset")
+	}
+}
+
+func TestCreateAssetPrivate(t *testing.T) {
+	const description = "unmarked bars, vault 7"
+
+	env := newTestEnv(t)
+	aliceID := env.createOwner(aliceIdentity, "Alice", "DOC-A", "30")
+
+	err := env.invoke(func(ctx contractapi.TransactionContextInterface) error {
+		return env.sc.CreateAssetPrivate(ctx, "asset1", "gold", aliceID)
+	}, as(aliceIdentity))
+	expectValidationCode(t, err, ValidationEmptyField)
+	if env.assetExists("asset1") {
+		t.Fatal("asset was created without a transient description")
+	}
+
+	env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+		return env.sc.CreateAssetPrivate(ctx, "asset1", "gold", aliceID)
+	}, as(aliceIdentity), withTransient("assetDescription", description))
+
+	asset := env.readAsset("asset1")
+	if asset.Description != "" || asset.AssetType != "gold" || asset.Owner != aliceID {
+		t.Fatalf("public asset = %+v, want an empty description", asset)
+	}
+	for key, value := range env.ledger.state {
+		if strings.Contains(string(value), description) {
+			t.Fatalf("world state key %q leaks the description", key)
+		}
+	}
+	for name, payload := range env.lastStub.events {
+		if strings.Contains(string(payload), description) {
+			t.Fatalf("event %s leaks the description", name)
+		}
+	}
+
+	readDescription := func(identity *mockIdentity) (string, error) {
+		var value string
+		err := env.invoke(func(ctx contractapi.TransactionContextInterface) error {
+			var err error
+			value, err = env.sc.ReadAssetPrivateDescription(ctx, "asset1")
+			return err
+		}, as(identity))
+		return value, err
+	}
+
+	value, err := readDescription(aliceIdentity)
+	if err != nil || value != description {
+		t.Fatalf("ReadAssetPrivateDescription = %q, %v, want the transient description", value, err)
+	}
+
+	_, err = readDescription(outsiderIdentity)
+	expectError(t, err, "client is not authorized to access private data")
+}
+
+func TestGetRemainingCapacity(t *testing.T) {
+	env := newTestEnv(t)
+
+	remaining := func() int64 {
+		t.Helper()
+
+		var value int64
+		env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+			var err error
+			value, err = env.sc.GetRemainingCapacity(ctx)
+			return err
+		})
+
+		return value
+	}
+
+	if got := remaining(); got != defaultTotalCapacity {
+		t.Fatalf("remaining capacity on an empty ledger = %d, want %d", got, defaultTotalCapacity)
+	}
+
+	aliceID := env.createOwner(aliceIdentity, "Alice", "DOC-A", "30")
+	env.createAsset("asset1", "gold", aliceID, 120)
+	env.createAsset("asset2", "silver", aliceID, 80)
+	if got := remaining(); got != defaultTotalCapacity-200 {
+		t.Fatalf("remaining capacity after partial use = %d, want %d", got, defaultTotalCapacity-200)
+	}
+
+	env.createAsset("asset3", "gold", aliceID, defaultTotalCapacity-200)
+	if got := remaining(); got != 0 {
+		t.Fatalf("remaining capacity at full use = %d, want 0", got)
+	}
+}
+
+func TestTypeCapacity(t *testing.T) {
+	env := newTestEnv(t)
+	aliceID := env.createOwner(aliceIdentity, "Alice", "DOC-A", "30")
+	env.createAsset("gold1", "gold", aliceID, 60)
+	env.createAsset("gold2", "gold", aliceID, 30)
+	env.createAsset("silver1", "silver", aliceID, 90)
+
+	setTypeCapacity := func(identity *mockIdentity, capacity int64) error {
+		return env.invoke(func(ctx contractapi.TransactionContextInterface) error {
+			return env.sc.SetTypeCapacity(ctx, "gold", capacity)
+		}, as(identity))
+	}
+	increment := func(assetID string, delta int32) error {
+		return env.invoke(func(ctx contractapi.TransactionContextInterface) error {
+			_, err := env.sc.IncrementAssetAmount(ctx, assetID, delta)
+			return err
+		}, as(aliceIdentity))
+	}
+
+	expectError(t, setTypeCapacity(aliceIdentity, 100), "caller is not authorized")
+	expectValidationCode(t, setTypeCapacity(adminIdentity, -1), ValidationOutOfRange)
+	if err := setTypeCapacity(adminIdentity, 100); err != nil {
+		t.Fatalf("SetTypeCapacity: %v", err)
+	}
+
+	// 90 gold plus 20 stays far below the total capacity but exceeds the gold cap of 100.
+	err := increment("gold1", 20)
+	expectValidationCode(t, err, ValidationOutOfRange)
+	expectError(t, err, "capacity of asset type gold")
+	err = env.invoke(func(ctx contractapi.TransactionContextInterface) error {
+		return env.sc.UpdateAssetAmount(ctx, "gold2", `["20"]`)
+	}, as(aliceIdentity))
+	expectError(t, err, "capacity of asset type gold")
+	if got := env.readAsset("gold1").Amount; got != 60 {
+		t.Fatalf("gold1 amount = %d, want 60 after the rejected increment", got)
+	}
+
+	if err := increment("gold1", 10); err != nil {
+		t.Fatalf("increment up to the gold cap: %v", err)
+	}
+	// Other asset types are not limited by the gold cap.
+	if err := increment("silver1", 50); err != nil {
+		t.Fatalf("increment of an uncapped type: %v", err)
+	}
+}
+
+func TestUpdateAssetAmountRejectsMalformedAmounts(t *testing.T) {
+	env := newTestEnv(t)
+	aliceID := env.createOwner(aliceIdentity, "Alice", "DOC-A", "30")
+	env.createAsset("asset1", "gold", aliceID, 10)
+
+	update := func(amountsJSON string) error {
+		return env.invoke(func(ctx contractapi.TransactionContextInterface) error {
+			return env.sc.UpdateAssetAmount(ctx, "asset1", amountsJSON)
+		}, as(aliceIdentity))
+	}
+
+	for _, tc := range []struct {
+		amountsJSON string
+		want        string
+	}{
+		{`{"a":1}`, "amounts must be a JSON array of strings"},
+		{`"5"`, "amounts must be a JSON array of strings"},
+		{`not json`, "amounts must be a JSON array of strings"},
+		{`[1,2]`, "amounts[0] must be a JSON string, got 1"},
+		{`["1",{"a":1}]`, `amounts[1] must be a JSON string, got {"a":1}`},
+		{`["1",null]`, "amounts[1] must be a JSON string, got null"},
+	} {
+		t.Run(tc.amountsJSON, func(t *testing.T) {
+			expectError(t, update(tc.amountsJSON), tc.want)
+		})
+	}
+	expectValidationCode(t, update(`[1,2]`), ValidationInvalidFormat)
+
+	if got := env.readAsset("asset1").Amount; got != 10 {
+		t.Fatalf("amount = %d after malformed updates, want 10", got)
+	}
+
+	if err := update(`["5"]`); err != nil {
+		t.Fatalf("UpdateAssetAmount with a string array: %v", err)
+	}
+	if got := env.readAsset("asset1").Amount; got != 15 {
+		t.Fatalf("amount = %d, want 15", got)
+	}
+}
+
+func TestTryReadAsset(t *testing.T) {
+	env := newTestEnv(t)
+	aliceID := env.createOwner(aliceIdentity, "Alice", "DOC-A", "30")
+	env.createAsset("asset1", "gold", aliceID, 1)
+
+	tryRead := func(assetID string, opts ...txOption) (*AssetLookupResult, error) {
+		var result *AssetLookupResult
+		err := env.invoke(func(ctx contractapi.TransactionContextInterface) error {
+			var err error
+			result, err = env.sc.TryReadAsset(ctx, assetID)
+			return err
+		}, opts...)
+		return result, err
+	}
+
+	result, err := tryRead("asset1")
+	if err != nil || !result.Found || result.Asset == nil || result.Asset.ID != "asset1" {
+		t.Fatalf("TryReadAsset(asset1) = %+v, %v, want the stored asset", result, err)
+	}
+
+	result, err = tryRead("missing")
+	if err != nil {
+		t.Fatalf("TryReadAsset(missing) returned an error: %v", err)
+	}
+	if result.Found || result.Asset != nil {
+		t.Fatalf("TryReadAsset(missing) = %+v, want not found", result)
+	}
+
+	errLedger := errors.New("ledger unavailable")
+	result, err = tryRead("missing", withGetStateError(errLedger))
+	if !errors.Is(err, errLedger) {
+		t.Fatalf("TryReadAsset on a failing ledger = %+v, %v, want the ledger error", result, err)
+	}
+}
+
+func TestCreateAssetAutoIDDerivesTheIDFromTheTxID(t *testing.T) {
+	createAutoID := func(env *testEnv, ownerID, txID string) (string, error) {
+		var assetID string
+		err := env.invoke(func(ctx contractapi.TransactionContextInterface) error {
+			var err error
+			assetID, err = env.sc.CreateAssetAutoID(ctx, "description", "gold", ownerID)
+			return err
+		}, as(aliceIdentity), withTxID(txID))
+		return assetID, err
+	}
+
+	const txID = "4f1c9be0d2a7"
+	digest := sha256.Sum256([]byte(txID))
+	want := "asset-" + hex.EncodeToString(digest[:8])
+
+	// Endorsers simulating the same transaction must derive the same ID.
+	for i := 0; i < 2; i++ {
+		env := newTestEnv(t)
+		aliceID := env.createOwner(aliceIdentity, "Alice", "DOC-A", "30")
+
+		assetID, err := createAutoID(env, aliceID, txID)
+		if err != nil {
+			t.Fatalf("CreateAssetAutoID: %v", err)
+		}
+		if assetID != want {
+			t.Fatalf("CreateAssetAutoID = %q, want %q", assetID, want)
+		}
+		if asset := env.readAsset(assetID); asset.Owner != aliceID {
+			t.Fatalf("auto ID asset owner = %q, want %q", asset.Owner, aliceID)
+		}
+
+		otherID, err := createAutoID(env, aliceID, "another-tx")
+		if err != nil || otherID == assetID {
+			t.Fatalf("CreateAssetAutoID in another transaction = %q, %v, want a different ID", otherID, err)
+		}
+	}
+}
+
+func TestIsDocumentRegistered(t *testing.T) {
+	env := newTestEnv(t)
+	env.createOwner(aliceIdentity, "Alice", "DOC-A", "30")
+
+	isRegistered := func(opts ...txOption) (bool, error) {
+		var registered bool
+		err := env.invoke(func(ctx contractapi.TransactionContextInterface) error {
+			var err error
+			registered, err = env.sc.IsDocumentRegistered(ctx)
+			return err
+		}, append([]txOption{as(bobIdentity)}, opts...)...)
+		return registered, err
+	}
+
+	registered, err := isRegistered(withTransient("documentNumber", "DOC-A"))
+	if err != nil || !registered {
+		t.Fatalf("IsDocumentRegistered(DOC-A) = %v, %v, want true", registered, err)
+	}
+
+	registered, err = isRegistered(withTransient("documentNumber", "DOC-B"))
+	if err != nil || registered {
+		t.Fatalf("IsDocumentRegistered(DOC-B) = %v, %v, want false", registered, err)
+	}
+
+	_, err = isRegistered()
+	expectValidationCode(t, err, ValidationEmptyField)
+}
+
+func TestAssetMinAmount(t *testing.T) {
+	env := newTestEnv(t)
+	aliceID := env.createOwner(aliceIdentity, "Alice", "DOC-A", "30")
+	env.createAsset("reserve", "gold", aliceID, 10)
+	env.createAsset("plain", "gold", aliceID, 3)
+
+	setMinAmount := func(identity *mockIdentity, minAmount int32) error {
+		return env.invoke(func(ctx contractapi.TransactionContextInterface) error {
+			return env.sc.SetAssetMinAmount(ctx, "reserve", minAmount)
+		}, as(identity))
+	}
+	increment := func(assetID string, delta int32) error {
+		return env.invoke(func(ctx contractapi.TransactionContextInterface) error {
+			_, err := env.sc.IncrementAssetAmount(ctx, assetID, delta)
+			return err
+		}, as(aliceIdentity))
+	}
+
+	expectError(t, setMinAmount(bobIdentity, 5), "caller is not the owner of the asset")
+	expectValidationCode(t, setMinAmount(aliceIdentity, -1), ValidationOutOfRange)
+	expectValidationCode(t, setMinAmount(aliceIdentity, 11), ValidationOutOfRange)
+	if err := setMinAmount(aliceIdentity, 5); err != nil {
+		t.Fatalf("SetAssetMinAmount: %v", err)
+	}
+
+	// Every decreasing operation refuses to take the amount from 10 to 4.
+	decrements := map[string]func(ctx contractapi.TransactionContextInterface) error{
+		"IncrementAssetAmount": func(ctx contractapi.TransactionContextInterface) error {
+			_, err := env.sc.IncrementAssetAmount(ctx, "reserve", -6)
+			return err
+		},
+		"UpdateAssetAmount": func(ctx contractapi.TransactionContextInterface) error {
+			return env.sc.UpdateAssetAmount(ctx, "reserve", `["-6"]`)
+		},
+		"TransferAmount": func(ctx contractapi.TransactionContextInterface) error {
+			return env.sc.TransferAmount(ctx, "reserve", "plain", 6)
+		},
+		"ReserveAmount": func(ctx contractapi.TransactionContextInterface) error {
+			_, err := env.sc.ReserveAmount(ctx, "reserve", 6)
+			return err
+		},
+	}
+	for name, decrement := range decrements {
+		t.Run(name, func(t *testing.T) {
+			expectValidationCode(t, env.invoke(decrement, as(aliceIdentity)), ValidationOutOfRange)
+		})
+	}
+	if got := env.readAsset("reserve").Amount; got != 10 {
+		t.Fatalf("amount = %d after rejected decrements, want 10", got)
+	}
+
+	if err := increment("reserve", -5); err != nil {
+		t.Fatalf("decrement down to the minimum amount: %v", err)
+	}
+
+	// The default floor is 0.
+	if err := increment("plain", -3); err != nil {
+		t.Fatalf("decrement down to 0: %v", err)
+	}
+	expectValidationCode(t, increment("plain", -1), ValidationOutOfRange)
+}
+
+func TestGetAssetsByCreator(t *testing.T) {
+	env := newTestEnv(t)
+	aliceID := env.createOwner(aliceIdentity, "Alice", "DOC-A", "30")
+	bobID := env.createOwner(bobIdentity, "Bob", "DOC-B", "40")
+
+	create := func(identity *mockIdentity, assetID, ownerID string) {
+		t.Helper()
+		env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+			return env.sc.CreateAsset(ctx, assetID, "description", "gold", ownerID)
+		}, as(identity))
+	}
+	create(aliceIdentity, "c3", aliceID)
+	create(bobIdentity, "b2", bobID)
+	create(aliceIdentity, "a1", aliceID)
+	env.createAsset("admin1", "gold", aliceID, 1)
+
+	byCreator := func(creatorID string) ([]Asset, error) {
+		var assets []Asset
+		err := env.invoke(func(ctx contractapi.TransactionContextInterface) error {
+			var err error
+			assets, err = env.sc.GetAssetsByCreator(ctx, creatorID)
+			return err
+		})
+		return assets, err
+	}
+
+	assets, err := byCreator(aliceIdentity.id)
+	if err != nil {
+		t.Fatalf("GetAssetsByCreator(alice): %v", err)
+	}
+	expectAssetIDs(t, assets, "a1", "c3")
+
+	assets, err = byCreator(bobIdentity.id)
+	if err != nil {
+		t.Fatalf("GetAssetsByCreator(bob): %v", err)
+	}
+	expectAssetIDs(t, assets, "b2")
+
+	assets, err = byCreator("nobody")
+	if err != nil {
+		t.Fatalf("GetAssetsByCreator(nobody): %v", err)
+	}
+	expectAssetIDs(t, assets)
+
+	_, err = byCreator("")
+	expectValidationCode(t, err, ValidationEmptyField)
+}
+
+func TestReadAssets(t *testing.T) {
+	env := newTestEnv(t)
+	aliceID := env.createOwner(aliceIdentity, "Alice", "DOC-A", "30")
+	env.createAsset("asset1", "gold", aliceID, 1)
+	env.createAsset("asset2", "gold", aliceID, 2)
+
+	readAssets := func(idsJSON string, skipMissing bool) ([]Asset, error) {
+		var assets []Asset
+		err := env.invoke(func(ctx contractapi.TransactionContextInterface) error {
+			var err error
+			assets, err = env.sc.ReadAssets(ctx, idsJSON, skipMissing)
+			return err
+		})
+		return assets, err
+	}
+
+	for _, skipMissing := range []bool{false, true} {
+		assets, err := readAssets(`["asset2","asset1"]`, skipMissing)
+		if err != nil {
+			t.Fatalf("ReadAssets(skipMissing=%v): %v", skipMissing, err)
+		}
+		expectAssetIDs(t, assets, "asset2", "asset1")
+	}
+
+	_, err := readAssets(`["asset1","missing","asset2"]`, false)
+	expectValidationCode(t, err, ValidationNotFound)
+	expectError(t, err, "missing")
+
+	assets, err := readAssets(`["asset1","missing","asset2"]`, true)
+	if err != nil {
+		t.Fatalf("ReadAssets skipping missing IDs: %v", err)
+	}
+	expectAssetIDs(t, assets, "asset1", "asset2")
+
+	_, err = readAssets(`{"id":"asset1"}`, true)
+	expectError(t, err, "asset IDs must be a JSON array of strings")
+}
+
+func TestReadOwnersByRange(t *testing.T) {
+	env := newTestEnv(t)
+	var ownerIDs []string
+	for i, name := range []string{"Alice", "Bob", "Carol", "Dave"} {
+		ownerIDs = append(ownerIDs, env.createOwner(aliceIdentity, name, "DOC-"+name, fmt.Sprint(30+i)))
+	}
+
+	readRange := func(startID, endID string) ([]Owner, error) {
+		var owners []Owner
+		err := env.invoke(func(ctx contractapi.TransactionContextInterface) error {
+			var err error
+			owners, err = env.sc.ReadOwnersByRange(ctx, startID, endID)
+			return err
+		})
+		return owners, err
+	}
+	expectOwners := func(owners []Owner, want ...string) {
+		t.Helper()
+
+		got := make([]string, 0, len(owners))
+		for _, owner := range owners {
+			if owner.Name != "" || owner.Age != 0 || owner.DocumentNumber != "" || owner.BoundIdentity != "" {
+				t.Fatalf("owner %d exposes more than its public ID: %+v", owner.ID, owner)
+			}
+			got = append(got, fmt.Sprint(owner.ID))
+		}
+		if strings.Join(got, ",") != strings.Join(want, ",") {
+			t.Fatalf("got owners %v, want %v", got, want)
+		}
+	}
+
+	owners, err := readRange("", "")
+	if err != nil {
+		t.Fatalf("ReadOwnersByRange over all owners: %v", err)
+	}
+	expectOwners(owners, ownerIDs...)
+
+	// The start ID is inclusive and the end ID is exclusive, as with GetStateByRange.
+	owners, err = readRange(ownerIDs[1], ownerIDs[3])
+	if err != nil {
+		t.Fatalf("ReadOwnersByRange(%s, %s): %v", ownerIDs[1], ownerIDs[3], err)
+	}
+	expectOwners(owners, ownerIDs[1], ownerIDs[2])
+
+	owners, err = readRange(ownerIDs[2], "")
+	if err != nil {
+		t.Fatalf("ReadOwnersByRange from %s: %v", ownerIDs[2], err)
+	}
+	expectOwners(owners, ownerIDs[2], ownerIDs[3])
+
+	owners, err = readRange("", ownerIDs[1])
+	if err != nil {
+		t.Fatalf("ReadOwnersByRange up to %s: %v", ownerIDs[1], err)
+	}
+	expectOwners(owners, ownerIDs[0])
+
+	owners, err = readRange(ownerIDs[1], ownerIDs[1])
+	if err != nil {
+		t.Fatalf("ReadOwnersByRange over an empty range: %v", err)
+	}
+	expectOwners(owners)
+
+	_, err = readRange(ownerIDs[3], ownerIDs[0])
+	expectValidationCode(t, err, ValidationOutOfRange)
+}
+
+func TestVerifyCapacityIntegrity(t *testing.T) {
+	env := newTestEnv(t)
+	aliceID := env.createOwner(aliceIdentity, "Alice", "DOC-A", "30")
+
+	verify := func() *CapacityIntegrityReport {
+		t.Helper()
+
+		var report *CapacityIntegrityReport
+		env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+			var err error
+			report, err = env.sc.VerifyCapacityIntegrity(ctx)
+			return err
+		})
+
+		return report
+	}
+	expectReport := func(within bool, total, capacity int64) {
+		t.Helper()
+
+		want := CapacityIntegrityReport{WithinCapacity: within, Total: total, Capacity: capacity}
+		if got := verify(); *got != want {
+			t.Fatalf("VerifyCapacityIntegrity = %+v, want %+v", *got, want)
+		}
+	}
+
+	env.createAsset("asset1", "gold", aliceID, 200)
+	env.createAsset("asset2", "silver", aliceID, 100)
+	expectReport(true, 300, defaultTotalCapacity)
+
+	env.createAsset("asset3", "gold", aliceID, defaultTotalCapacity-300)
+	expectReport(true, defaultTotalCapacity, defaultTotalCapacity)
+
+	// Lowering the capacity below the stored amounts is what the check has to detect.
+	env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+		return env.sc.SetTotalCapacity(ctx, 400)
+	})
+	expectReport(false, defaultTotalCapacity, 400)
+}
+
+func TestUpdateAssetDescriptionIfVersion(t *testing.T) {
+	env := newTestEnv(t)
+	aliceID := env.createOwner(aliceIdentity, "Alice", "DOC-A", "30")
+	env.createAsset("asset1", "gold", aliceID, 10)
+
+	if got := env.readAsset("asset1").Version; got != 1 {
+		t.Fatalf("version of a new asset = %d, want 1", got)
+	}
+
+	updateIfVersion := func(description string, expectedVersion int) error {
+		return env.invoke(func(ctx contractapi.TransactionContextInterface) error {
+			return env.sc.UpdateAssetDescriptionIfVersion(ctx, "asset1", description, expectedVersion)
+		}, as(aliceIdentity))
+	}
+
+	if err := updateIfVersion("first", 1); err != nil {
+		t.Fatalf("update at the current version: %v", err)
+	}
+	asset := env.readAsset("asset1")
+	if asset.Description != "first" || asset.Version != 2 {
+		t.Fatalf("asset after update = %+v, want description first at version 2", asset)
+	}
+
+	// Any other write also bumps the version, so a client holding version 2 is now stale.
+	env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.sc.IncrementAssetAmount(ctx, "asset1", 1)
+		return err
+	}, as(aliceIdentity))
+
+	expectError(t, updateIfVersion("stale", 2), "asset asset1 is at version 3, expected version 2")
+	if got := env.readAsset("asset1").Description; got != "first" {
+		t.Fatalf("description = %q after a stale update, want first", got)
+	}
+
+	if err := updateIfVersion("second", 3); err != nil {
+		t.Fatalf("update at the refreshed version: %v", err)
+	}
+}
+
+func TestExportAssetsNDJSON(t *testing.T) {
+	env := newTestEnv(t)
+	aliceID := env.createOwner(aliceIdentity, "Alice", "DOC-A", "30")
+
+	export := func() string {
+		t.Helper()
+
+		var ndjson string
+		env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+			var err error
+			ndjson, err = env.sc.ExportAssetsNDJSON(ctx)
+			return err
+		})
+
+		return ndjson
+	}
+
+	if got := export(); got != "" {
+		t.Fatalf("export of an empty ledger = %q, want no lines", got)
+	}
+
+	env.createAsset("c3", "gold", aliceID, 3)
+	env.createAsset("a1", "silver", aliceID, 1)
+	env.createAsset("b2", "gold", aliceID, 2)
+
+	ndjson := export()
+	if !strings.HasSuffix(ndjson, "\n") {
+		t.Fatalf("export %q does not end with a newline", ndjson)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(ndjson, "\n"), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("export has %d lines, want 3:\n%s", len(lines), ndjson)
+	}
+
+	assets := make([]Asset, 0, len(lines))
+	for i, line := range lines {
+		var asset Asset
+		if err := json.Unmarshal([]byte(line), &asset); err != nil {
+			t.Fatalf("line %d is not a JSON object: %v\n%s", i+1, err, line)
+		}
+		assets = append(assets, asset)
+	}
+	expectAssetIDs(t, assets, "a1", "b2", "c3")
+
+	if again := export(); again != ndjson {
+		t.Fatal("repeated exports differ")
+	}
+}
+
+func TestDeleteAssetCascadesToPrivateData(t *testing.T) {
+	env := newTestEnv(t)
+	ownerID := env.createOwner(aliceIdentity, "Alice", "DOC-A", "30")
+	env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+		return env.sc.CreateAsset(ctx, "asset1", "", "gold", ownerID)
+	}, withTransient("assetSecret", "s3cret"))
+
+	assetKey := compositeKey(t, "asset", "asset1")
+	if env.ledger.private[privateCollection][assetKey] == nil {
+		t.Fatal("private details were not written")
+	}
+
+	err := env.invoke(func(ctx contractapi.TransactionContextInterface) error {
+		return env.sc.DeleteAsset(ctx, "asset1")
+	}, as(bobIdentity))
+	expectError(t, err, "caller is not the owner of the asset")
+
+	env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+		return env.sc.DeleteAsset(ctx, "asset1")
+	}, as(aliceIdentity))
+
+	if env.assetExists("asset1") {
+		t.Fatal("public asset still exists after deletion")
+	}
+	if _, ok := env.ledger.private[privateCollection][assetKey]; ok {
+		t.Fatal("private details still exist after deletion")
+	}
+}
+
+func TestFrozenAssetRejectsUpdatesButAllowsReads(t *testing.T) {
+	env := newTestEnv(t)
+	aliceID := env.createOwner(aliceIdentity, "Alice", "DOC-A", "30")
+	bobID := env.createOwner(bobIdentity, "Bob", "DOC-B", "40")
+	env.createAsset("asset1", "gold", aliceID, 10)
+
+	var reservationID string
+	env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+		var err error
+		reservationID, err = env.sc.ReserveAmount(ctx, "asset1", 2)
+		return err
+	}, as(aliceIdentity))
+	env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+		return env.sc.ProposeTransfer(ctx, "asset1", bobID)
+	}, as(aliceIdentity))
+
+	err := env.invoke(func(ctx contractapi.TransactionContextInterface) error {
+		return env.sc.FreezeAsset(ctx, "asset1")
+	}, as(aliceIdentity))
+	expectError(t, err, "caller is not authorized")
+
+	env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+		return env.sc.FreezeAsset(ctx, "asset1")
+	})
+
+	mutations := map[string]func(ctx contractapi.TransactionContextInterface) error{
+		"UpdateAssetAmount": func(ctx contractapi.TransactionContextInterface) error {
+			return env.sc.UpdateAssetAmount(ctx, "asset1", `["1"]`)
+		},
+		"IncrementAssetAmount": func(ctx contractapi.TransactionContextInterface) error {
+			_, err := env.sc.IncrementAssetAmount(ctx, "asset1", 1)
+			return err
+		},
+		"UpdateAssetDescription": func(ctx contractapi.TransactionContextInterface) error {
+			_, err := env.sc.UpdateAssetDescription(ctx, "asset1", "changed")
+			return err
+		},
+		"TransferAsset": func(ctx contractapi.TransactionContextInterface) error {
+			return env.sc.TransferAsset(ctx, "asset1", bobID)
+		},
+		"DeleteAsset": func(ctx contractapi.TransactionContextInterface) error {
+			return env.sc.DeleteAsset(ctx, "asset1")
+		},
+		"CommitReservation": func(ctx contractapi.TransactionContextInterface) error {
+			return env.sc.CommitReservation(ctx, reservationID)
+		},
+		"ReleaseReservation": func(ctx contractapi.TransactionContextInterface) error {
+			return env.sc.ReleaseReservation(ctx, reservationID)
+		},
+		"CancelTransfer": func(ctx contractapi.TransactionContextInterface) error {
+			return env.sc.CancelTransfer(ctx, "asset1")
+		},
+	}
+	for name, mutate := range mutations {
+		t.Run(name, func(t *testing.T) {
+			expectError(t, env.invoke(mutate), "is frozen and cannot be modified")
+		})
+	}
+
+	asset := env.readAsset("asset1")
+	if !asset.Frozen || asset.Amount != 8 || asset.PendingOwner != bobID {
+		t.Fatalf("frozen asset changed: %+v", asset)
+	}
+
+	env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+		return env.sc.UnfreezeAsset(ctx, "asset1")
+	})
+	env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+		return env.sc.ReleaseReservation(ctx, reservationID)
+	}, as(aliceIdentity))
+	if got := env.readAsset("asset1").Amount; got != 10 {
+		t.Fatalf("amount after release = %d, want 10", got)
+	}
+}
+
+func TestPingPerformsNoWrites(t *testing.T) {
+	env := newTestEnv(t)
+	env.ledger = newMockLedger()
+
+	ping := func() string {
+		t.Helper()
+
+		var status string
+		env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+			var err error
+			status, err = env.sc.Ping(ctx)
+			return err
+		}, as(outsiderIdentity))
+		if len(env.lastStub.writes) != 0 || len(env.lastStub.privateWrites) != 0 || len(env.lastStub.events) != 0 {
+			t.Fatalf("Ping wrote %v, %v, %v", env.lastStub.writes, env.lastStub.privateWrites, env.lastStub.events)
+		}
+
+		return status
+	}
+
+	if status := ping(); status != "FabricVulnBenchmark initialized=false" {
+		t.Fatalf("Ping on an uninitialized ledger = %q", status)
+	}
+	if len(env.ledger.state) != 0 {
+		t.Fatal("Ping initialized the ledger")
+	}
+
+	env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+		return env.sc.InitContract(ctx, false)
+	})
+	if status := ping(); status != "FabricVulnBenchmark initialized=true" {
+		t.Fatalf("Ping on an initialized ledger = %q", status)
+	}
+}
+
+func TestContractConfigReflectsSetters(t *testing.T) {
+	env := newTestEnv(t)
+
+	env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+		return env.sc.SetTotalCapacity(ctx, 800)
+	})
+	env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+		return env.sc.SetMinimumAge(ctx, 21)
+	})
+	env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+		return env.sc.SetTypeCapacity(ctx, "gold", 300)
+	})
+	env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+		return env.sc.SetTypeCapacity(ctx, "silver", 0)
+	})
+
+	var config *ContractConfig
+	env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+		var err error
+		config, err = env.sc.GetContractConfig(ctx)
+		return err
+	}, as(aliceIdentity))
+
+	if config.TotalCapacity != 800 || config.MinimumOwnerAge != 21 || config.PrivateCollection != privateCollection {
+		t.Fatalf("GetContractConfig = %+v", config)
+	}
+	if len(config.TypeCapacities) != 2 || config.TypeCapacities["gold"] != 300 || config.TypeCapacities["silver"] != 0 {
+		t.Fatalf("type capacities = %v, want gold 300 and silver 0", config.TypeCapacities)
+	}
+}
+
+func TestReadAssetFromChannel(t *testing.T) {
+	env := newTestEnv(t)
+
+	remote := Asset{ID: "remote1", AssetType: "gold", Owner: "7", Amount: 12, Version: 4}
+	remoteBytes, err := json.Marshal(remote)
+	if err != nil {
+		t.Fatalf("unable to marshal asset: %v", err)
+	}
+
+	readFromChannel := func(handler func(string, [][]byte, string) *peer.Response) (*Asset, error) {
+		var asset *Asset
+		err := env.invoke(func(ctx contractapi.TransactionContextInterface) error {
+			var err error
+			asset, err = env.sc.ReadAssetFromChannel(ctx, "asset-transfer", "remote1", "other-channel")
+			return err
+		}, withInvoke(handler))
+		return asset, err
+	}
+
+	var gotName, gotChannel string
+	var gotArgs []string
+	asset, err := readFromChannel(func(name string, args [][]byte, channel string) *peer.Response {
+		gotName, gotChannel = name, channel
+		for _, arg := range args {
+			gotArgs = append(gotArgs, string(arg))
+		}
+		return shim.Success(remoteBytes)
+	})
+	if err != nil {
+		t.Fatalf("ReadAssetFromChannel: %v", err)
+	}
+	if asset.ID != remote.ID || asset.Owner != remote.Owner || asset.Amount != remote.Amount || asset.Version != remote.Version {
+		t.Fatalf("ReadAssetFromChannel = %+v, want %+v", *asset, remote)
+	}
+	if gotName != "asset-transfer" || gotChannel != "other-channel" || strings.Join(gotArgs, ",") != "ReadAsset,remote1" {
+		t.Fatalf("invoked %s on %s with %v", gotName, gotChannel, gotArgs)
+	}
+
+	_, err = readFromChannel(func(string, [][]byte, string) *peer.Response {
+		return shim.Error("asset remote1 does not exist")
+	})
+	expectError(t, err, "asset remote1 does not exist")
+
+	_, err = readFromChannel(func(string, [][]byte, string) *peer.Response {
+		return shim.Success(nil)
+	})
+	expectError(t, err, "empty payload reading asset remote1 from channel other-channel")
+
+	_, err = readFromChannel(func(string, [][]byte, string) *peer.Response {
+		return shim.Success([]byte("not an asset"))
+	})
+	expectError(t, err, "unable to unmarshal asset")
+
+	err = env.invoke(func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.sc.ReadAssetFromChannel(ctx, "asset-transfer", "remote1", "")
+		return err
+	})
+	expectValidationCode(t, err, ValidationEmptyField)
+}
+
+func TestKeyComponentsRejectReservedCharacters(t *testing.T) {
+	env := newTestEnv(t)
+	aliceID := env.createOwner(aliceIdentity, "Alice", "DOC-A", "30")
+	env.createAsset("asset1", "gold", aliceID, 1)
+
+	malicious := map[string]string{
+		"null byte":        "asset1\x00owner",
+		"leading null":     "\x00asset1",
+		"max rune":         "asset1\U0010FFFF",
+		"invalid UTF-8":    "asset1\xff",
+		"composite prefix": "\x00asset\x00asset1\x00",
+	}
+	calls := map[string]func(ctx contractapi.TransactionContextInterface, id string) error{
+		"CreateAsset": func(ctx contractapi.TransactionContextInterface, id string) error {
+			return env.sc.CreateAsset(ctx, id, "description", "gold", aliceID)
+		},
+		"CreateAsset owner": func(ctx contractapi.TransactionContextInterface, id string) error {
+			return env.sc.CreateAsset(ctx, "asset2", "description", "gold", id)
+		},
+		"ReadAsset": func(ctx contractapi.TransactionContextInterface, id string) error {
+			_, err := env.sc.ReadAsset(ctx, id)
+			return err
+		},
+		"TransferAsset": func(ctx contractapi.TransactionContextInterface, id string) error {
+			return env.sc.TransferAsset(ctx, "asset1", id)
+		},
+		"CreateHierarchicalAsset": func(ctx contractapi.TransactionContextInterface, id string) error {
+			return env.sc.CreateHierarchicalAsset(ctx, "gold", id, "description", aliceID)
+		},
+		"SetTypeCapacity": func(ctx contractapi.TransactionContextInterface, id string) error {
+			return env.sc.SetTypeCapacity(ctx, id, 10)
+		},
+	}
+
+	for callName, call := range calls {
+		for idName, id := range malicious {
+			t.Run(callName+"/"+idName, func(t *testing.T) {
+				err := env.invoke(func(ctx contractapi.TransactionContextInterface) error {
+					return call(ctx, id)
+				})
+				expectValidationCode(t, err, ValidationInvalidFormat)
+			})
+		}
+	}
+
+	if asset := env.readAsset("asset1"); asset.Owner != aliceID {
+		t.Fatalf("asset1 owner = %q after rejected calls, want %q", asset.Owner, aliceID)
+	}
+}
+
+func TestTwoStepTransfer(t *testing.T) {
+	env := newTestEnv(t)
+	aliceID := env.createOwner(aliceIdentity, "Alice", "DOC-A", "30")
+	bobID := env.createOwner(bobIdentity, "Bob", "DOC-B", "40")
+	carolID := env.createOwner(outsiderIdentity, "Carol", "DOC-C", "50")
+	env.createAsset("asset1", "gold", aliceID, 10)
+
+	propose := func(toOwnerID string, identity *mockIdentity) error {
+		return env.invoke(func(ctx contractapi.TransactionContextInterface) error {
+			return env.sc.ProposeTransfer(ctx, "asset1", toOwnerID)
+		}, as(identity))
+	}
+	accept := func(identity *mockIdentity) error {
+		return env.invoke(func(ctx contractapi.TransactionContextInterface) error {
+			return env.sc.AcceptTransfer(ctx, "asset1")
+		}, as(identity))
+	}
+
+	expectError(t, propose(bobID, bobIdentity), "caller is not the owner of the asset")
+	expectError(t, propose(aliceID, aliceIdentity), "already owned by the proposed owner")
+	expectError(t, accept(bobIdentity), "has no pending transfer")
+
+	if err := propose(bobID, aliceIdentity); err != nil {
+		t.Fatalf("ProposeTransfer: %v", err)
+	}
+	asset := env.readAsset("asset1")
+	if asset.Owner != aliceID || asset.PendingOwner != bobID {
+		t.Fatalf("after propose owner=%q pending=%q", asset.Owner, asset.PendingOwner)
+	}
+
+	expectError(t, accept(outsiderIdentity), "only the proposed owner can accept the transfer")
+	expectError(t, accept(aliceIdentity), "only the proposed owner can accept the transfer")
+
+	if err := accept(bobIdentity); err != nil {
+		t.Fatalf("AcceptTransfer: %v", err)
+	}
+	asset = env.readAsset("asset1")
+	if asset.Owner != bobID || asset.PendingOwner != "" {
+		t.Fatalf("after accept owner=%q pending=%q", asset.Owner, asset.PendingOwner)
+	}
+
+	// Cancelling clears the proposal so the proposed owner can no longer accept.
+	if err := propose(carolID, bobIdentity); err != nil {
+		t.Fatalf("ProposeTransfer: %v", err)
+	}
+	err := env.invoke(func(ctx contractapi.TransactionContextInterface) error {
+		return env.sc.CancelTransfer(ctx, "asset1")
+	}, as(outsiderIdentity))
+	expectError(t, err, "caller is not the owner of the asset")
+	env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+		return env.sc.CancelTransfer(ctx, "asset1")
+	}, as(bobIdentity))
+	if pending := env.readAsset("asset1").PendingOwner; pending != "" {
+		t.Fatalf("pending owner after cancel = %q", pending)
+	}
+	expectError(t, accept(outsiderIdentity), "has no pending transfer")
+}
+
+func TestDirectTransferClearsPendingTransfer(t *testing.T) {
+	env := newTestEnv(t)
+	aliceID := env.createOwner(aliceIdentity, "Alice", "DOC-A", "30")
+	bobID := env.createOwner(bobIdentity, "Bob", "DOC-B", "40")
+	carolID := env.createOwner(outsiderIdentity, "Carol", "DOC-C", "50")
+	env.createAsset("asset1", "gold", aliceID, 10)
+
+	env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+		return env.sc.ProposeTransfer(ctx, "asset1", bobID)
+	}, as(aliceIdentity))
+	env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+		return env.sc.TransferAsset(ctx, "asset1", carolID)
+	}, as(aliceIdentity))
+
+	asset := env.readAsset("asset1")
+	if asset.Owner != carolID || asset.PendingOwner != "" {
+		t.Fatalf("after direct transfer owner=%q pending=%q", asset.Owner, asset.PendingOwner)
+	}
+
+	// The stale proposal must not let bob take the asset from its new owner.
+	err := env.invoke(func(ctx contractapi.TransactionContextInterface) error {
+		return env.sc.AcceptTransfer(ctx, "asset1")
+	}, as(bobIdentity))
+	expectError(t, err, "has no pending transfer")
+}
+
+func TestTransferWorkflowRejectsArchivedAssets(t *testing.T) {
+	env := newTestEnv(t)
+	aliceID := env.createOwner(aliceIdentity, "Alice", "DOC-A", "30")
+	bobID := env.createOwner(bobIdentity, "Bob", "DOC-B", "40")
+	env.createAsset("asset1", "gold", aliceID, 10)
+	env.createAsset("asset2", "gold", aliceID, 10)
+
+	env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+		return env.sc.ProposeTransfer(ctx, "asset2", bobID)
+	}, as(aliceIdentity))
+	for _, assetID := range []string{"asset1", "asset2"} {
+		id := assetID
+		env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+			return env.sc.ArchiveAsset(ctx, id)
+		}, as(aliceIdentity))
+	}
+
+	err := env.invoke(func(ctx contractapi.TransactionContextInterface) error {
+		return env.sc.ProposeTransfer(ctx, "asset1", bobID)
+	}, as(aliceIdentity))
+	expectError(t, err, "is archived and cannot be transferred")
+
+	err = env.invoke(func(ctx contractapi.TransactionContextInterface) error {
+		return env.sc.AcceptTransfer(ctx, "asset2")
+	}, as(bobIdentity))
+	expectError(t, err, "is archived and cannot be transferred")
+	if owner := env.readAsset("asset2").Owner; owner != aliceID {
+		t.Fatalf("archived asset owner = %q, want %q", owner, aliceID)
+	}
+}
+func TestGetTotalAmountByOwner(t *testing.T) {
+	env := newTestEnv(t)
+	aliceID := env.createOwner(aliceIdentity, "Alice", "DOC-A", "30")
+	bobID := env.createOwner(bobIdentity, "Bob", "DOC-B", "40")
+	carolID := env.createOwner(aliceIdentity, "Carol", "DOC-C", "50")
+	env.createAsset("a1", "gold", aliceID, 120)
+	env.createAsset("a2", "silver", aliceID, 80)
+	env.createAsset("a3", "gold", aliceID, 7)
+	env.createAsset("b1", "gold", bobID, 50)
+
+	totalFor := func(ownerID string) (int64, error) {
+		var total int64
+		err := env.invoke(func(ctx contractapi.TransactionContextInterface) error {
+			var err error
+			total, err = env.sc.GetTotalAmountByOwner(ctx, ownerID)
+			return err
+		})
+		return total, err
+	}
+
+	for ownerID, want := range map[string]int64{aliceID: 207, bobID: 50, carolID: 0} {
+		total, err := totalFor(ownerID)
+		if err != nil || total != want {
+			t.Fatalf("GetTotalAmountByOwner(%s) = %d, %v, want %d", ownerID, total, err, want)
+		}
+	}
+
+	_, err := totalFor("")
+	expectValidationCode(t, err, ValidationEmptyField)
+}
+
+func TestGetAssetsModifiedAfter(t *testing.T) {
+	env := newTestEnv(t)
+	aliceID := env.createOwner(aliceIdentity, "Alice", "DOC-A", "30")
+
+	day := func(d int) time.Time {
+		return time.Date(2024, time.March, d, 12, 0, 0, 0, time.UTC)
+	}
+	createAt := func(assetID string, at time.Time) {
+		t.Helper()
+		env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+			return env.sc.CreateAsset(ctx, assetID, "description", "gold", aliceID)
+		}, withTimestamp(at))
+	}
+	createAt("a1", day(1))
+	createAt("a2", day(3))
+	createAt("a3", day(5))
+	env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.sc.UpdateAssetDescription(ctx, "a1", "changed")
+		return err
+	}, withTimestamp(day(7)))
+
+	modifiedAfter := func(rfc3339 string) ([]Asset, error) {
+		var assets []Asset
+		err := env.invoke(func(ctx contractapi.TransactionContextInterface) error {
+			var err error
+			assets, err = env.sc.GetAssetsModifiedAfter(ctx, rfc3339)
+			return err
+		})
+		return assets, err
+	}
+
+	for _, tc := range []struct {
+		after time.Time
+		want  []string
+	}{
+		{day(0), []string{"a1", "a2", "a3"}},
+		{day(2), []string{"a1", "a2", "a3"}},
+		{day(4), []string{"a1", "a3"}},
+		// Only modifications strictly after the given time count.
+		{day(5), []string{"a1"}},
+		{day(7), nil},
+	} {
+		assets, err := modifiedAfter(tc.after.Format(time.RFC3339))
+		if err != nil {
+			t.Fatalf("GetAssetsModifiedAfter(%s): %v", tc.after, err)
+		}
+		expectAssetIDs(t, assets, tc.want...)
+	}
+
+	// The latest modification wins regardless of the order the history returns it in.
+	assetKey := compositeKey(t, "asset", "a2")
+	env.ledger.history[assetKey] = append([]*queryresult.KeyModification{{TxId: "late", Timestamp: timestamppb.New(day(9))}}, env.ledger.history[assetKey]...)
+	assets, err := modifiedAfter(day(8).Format(time.RFC3339))
+	if err != nil {
+		t.Fatalf("GetAssetsModifiedAfter with reordered history: %v", err)
+	}
+	expectAssetIDs(t, assets, "a2")
+
+	for _, invalid := range []string{"2024-03-04", "2024-03-04 12:00:00", "yesterday", ""} {
+		_, err := modifiedAfter(invalid)
+		expectError(t, err, "time must be in RFC 3339 format")
+	}
+}
+
+func TestMarshalCanonicalIsByteStable(t *testing.T) {
+	type unordered struct {
+		Zeta  string            `json:"zeta"`
+		Alpha float64           `json:"alpha"`
+		Mid   map[string]int    `json:"mid"`
+		Tags  []string          `json:"tags"`
+		Extra map[string]string `json:"extra"`
+	}
+	value := unordered{
+		Zeta:  "z",
+		Alpha: 1e21,
+		Mid:   map[string]int{"b": 2, "a": 1, "c": 3},
+		Tags:  []string{"y", "x"},
+		Extra: map[string]string{"k2": "v2", "k1": "v1"},
+	}
+
+	first, err := marshalCanonical(value)
+	if err != nil {
+		t.Fatalf("marshalCanonical: %v", err)
+	}
+	for i := 0; i < 20; i++ {
+		again, err := marshalCanonical(value)
+		if err != nil || !bytes.Equal(again, first) {
+			t.Fatalf("marshal %d = %s, %v, want %s", i, again, err, first)
+		}
+	}
+
+	const want = `{"alpha":1e+21,"extra":{"k1":"v1","k2":"v2"},"mid":{"a":1,"b":2,"c":3},"tags":["y","x"],"zeta":"z"}`
+	if string(first) != want {
+		t.Fatalf("marshalCanonical = %s, want %s", first, want)
+	}
+
+	// Two peers applying the same transaction persist identical bytes.
+	var stored [][]byte
+	for i := 0; i < 2; i++ {
+		env := newTestEnv(t)
+		aliceID := env.createOwner(aliceIdentity, "Alice", "DOC-A", "30")
+		env.createAsset("asset1", "gold", aliceID, 5)
+
+		assetBytes := env.ledger.state[compositeKey(t, "asset", "asset1")]
+		canonical, err := marshalCanonical(env.readAsset("asset1"))
+		if err != nil || !bytes.Equal(assetBytes, canonical) {
+			t.Fatalf("stored asset %s is not in canonical form %s", assetBytes, canonical)
+		}
+		stored = append(stored, assetBytes)
+	}
+	if !bytes.Equal(stored[0], stored[1]) {
+		t.Fatalf("peers stored %s and %s", stored[0], stored[1])
+	}
+}
+
+func TestRelabelAssetType(t *testing.T) {
+	env := newTestEnv(t)
+	aliceID := env.createOwner(aliceIdentity, "Alice", "DOC-A", "30")
+	env.createAsset("g1", "gold", aliceID, 1)
+	env.createAsset("g2", "gold", aliceID, 2)
+	env.createAsset("s1", "silver", aliceID, 3)
+
+	relabel := func(identity *mockIdentity, oldType, newType string) (int, error) {
+		var count int
+		err := env.invoke(func(ctx contractapi.TransactionContextInterface) error {
+			var err error
+			count, err = env.sc.RelabelAssetType(ctx, oldType, newType)
+			return err
+		}, as(identity))
+		return count, err
+	}
+	byType := func(assetType string) []Asset {
+		t.Helper()
+
+		var assets []Asset
+		env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+			var err error
+			assets, err = env.sc.GetAssetsByType(ctx, assetType)
+			return err
+		})
+
+		return assets
+	}
+
+	_, err := relabel(aliceIdentity, "gold", "bullion")
+	expectError(t, err, "caller is not authorized")
+	_, err = relabel(adminIdentity, "gold", "")
+	expectValidationCode(t, err, ValidationEmptyField)
+	_, err = relabel(adminIdentity, "gold", "bullion")
+	expectValidationCode(t, err, ValidationNotFound)
+	expectAssetIDs(t, byType("gold"), "g1", "g2")
+
+	env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+		return env.sc.RegisterAssetType(ctx, "bullion")
+	})
+	count, err := relabel(adminIdentity, "gold", "bullion")
+	if err != nil || count != 2 {
+		t.Fatalf("RelabelAssetType = %d, %v, want 2", count, err)
+	}
+	expectAssetIDs(t, byType("gold"))
+	expectAssetIDs(t, byType("bullion"), "g1", "g2")
+	expectAssetIDs(t, byType("silver"), "s1")
+
+	count, err = relabel(adminIdentity, "gold", "bullion")
+	if err != nil || count != 0 {
+		t.Fatalf("second RelabelAssetType = %d, %v, want 0", count, err)
+	}
+}
+
+func TestValidationErrorCodes(t *testing.T) {
+	env := newTestEnv(t)
+	aliceID := env.createOwner(aliceIdentity, "Alice", "DOC-A", "30")
+	env.createAsset("asset1", "gold", aliceID, 1)
+
+	for _, tc := range []struct {
+		name string
+		code ValidationCode
+		call func(ctx contractapi.TransactionContextInterface) error
+	}{
+		{"empty asset ID", ValidationEmptyField, func(ctx contractapi.TransactionContextInterface) error {
+			return env.sc.CreateAsset(ctx, "", "description", "gold", aliceID)
+		}},
+		{"duplicate asset", ValidationAlreadyExists, func(ctx contractapi.TransactionContextInterface) error {
+			return env.sc.CreateAsset(ctx, "asset1", "description", "gold", aliceID)
+		}},
+		{"missing owner", ValidationNotFound, func(ctx contractapi.TransactionContextInterface) error {
+			return env.sc.CreateAsset(ctx, "asset2", "description", "gold", "999")
+		}},
+		{"missing asset", ValidationNotFound, func(ctx contractapi.TransactionContextInterface) error {
+			_, err := env.sc.ReadAsset(ctx, "missing")
+			return err
+		}},
+		{"negative amount", ValidationOutOfRange, func(ctx contractapi.TransactionContextInterface) error {
+			return env.sc.CreateAssetWithAmount(ctx, "asset2", "description", "gold", aliceID, -1)
+		}},
+		{"bad time", ValidationInvalidFormat, func(ctx contractapi.TransactionContextInterface) error {
+			_, err := env.sc.GetAssetsCreatedBetween(ctx, "yesterday", "2024-01-01T00:00:00Z")
+			return err
+		}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := env.invoke(tc.call)
+			expectValidationCode(t, err, tc.code)
+
+			// The code prefixes the message so it survives the chaincode boundary as plain text.
+			if !strings.HasPrefix(err.Error(), string(tc.code)+": ") {
+				t.Fatalf("error %q does not start with its code", err)
+			}
+		})
+	}
+}
+
+func TestGetAssetRaw(t *testing.T) {
+	env := newTestEnv(t)
+	aliceID := env.createOwner(aliceIdentity, "Alice", "DOC-A", "30")
+	env.createAsset("asset1", "gold", aliceID, 5)
+
+	getRaw := func(assetID string) (string, error) {
+		var raw string
+		err := env.invoke(func(ctx contractapi.TransactionContextInterface) error {
+			var err error
+			raw, err = env.sc.GetAssetRaw(ctx, assetID)
+			return err
+		})
+		return raw, err
+	}
+
+	raw, err := getRaw("asset1")
+	if err != nil {
+		t.Fatalf("GetAssetRaw: %v", err)
+	}
+	if want := string(env.ledger.state[compositeKey(t, "asset", "asset1")]); raw != want {
+		t.Fatalf("GetAssetRaw = %s, want the stored bytes %s", raw, want)
+	}
+
+	// Bytes written by other tooling come back verbatim rather than re-encoded.
+	const planted = `{ "id": "asset2",  "assetType": "gold", "owner": "1", "amount": 3, "unknownField": true }`
+	env.ledger.state[compositeKey(t, "asset", "asset2")] = []byte(planted)
+	raw, err = getRaw("asset2")
+	if err != nil || raw != planted {
+		t.Fatalf("GetAssetRaw(asset2) = %q, %v, want %q", raw, err, planted)
+	}
+
+	_, err = getRaw("missing")
+	expectValidationCode(t, err, ValidationNotFound)
+}
+
+func TestIncrementAssetAmount(t *testing.T) {
+	env := newTestEnv(t)
+	aliceID := env.createOwner(aliceIdentity, "Alice", "DOC-A", "30")
+	env.createAsset("asset1", "gold", aliceID, 100)
+
+	increment := func(assetID string, delta int32) (int32, error) {
+		var amount int32
+		err := env.invoke(func(ctx contractapi.TransactionContextInterface) error {
+			var err error
+			amount, err = env.sc.IncrementAssetAmount(ctx, assetID, delta)
+			return err
+		}, as(aliceIdentity))
+		return amount, err
+	}
+
+	amount, err := increment("asset1", 25)
+	if err != nil || amount != 125 {
+		t.Fatalf("positive delta = %d, %v, want 125", amount, err)
+	}
+	amount, err = increment("asset1", -40)
+	if err != nil || amount != 85 {
+		t.Fatalf("negative delta = %d, %v, want 85", amount, err)
+	}
+	if got := env.readAsset("asset1").Amount; got != 85 {
+		t.Fatalf("stored amount = %d, want 85", got)
+	}
+
+	_, err = increment("asset1", 0)
+	expectValidationCode(t, err, ValidationOutOfRange)
+
+	_, err = increment("asset1", defaultTotalCapacity-84)
+	expectValidationCode(t, err, ValidationOutOfRange)
+	expectError(t, err, "would exceed the total capacity")
+	amount, err = increment("asset1", defaultTotalCapacity-85)
+	if err != nil || amount != defaultTotalCapacity {
+		t.Fatalf("delta up to the total capacity = %d, %v, want %d", amount, err, defaultTotalCapacity)
+	}
+
+	// With a capacity above the int32 range, the int32 amount itself is the limit.
+	env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+		return env.sc.SetTotalCapacity(ctx, math.MaxInt64)
+	})
+	env.createAsset("big", "gold", aliceID, math.MaxInt32-1)
+	_, err = increment("big", 2)
+	expectValidationCode(t, err, ValidationOutOfRange)
+	expectError(t, err, "asset big amount would overflow")
+	if got := env.readAsset("big").Amount; got != math.MaxInt32-1 {
+		t.Fatalf("amount after overflowing delta = %d, want %d", got, math.MaxInt32-1)
+	}
+	amount, err = increment("big", 1)
+	if err != nil || amount != math.MaxInt32 {
+		t.Fatalf("delta up to MaxInt32 = %d, %v", amount, err)
+	}
+}
+
+func TestGetAssetsCreatedBetween(t *testing.T) {
+	env := newTestEnv(t)
+	ownerID := env.createOwner(aliceIdentity, "Alice", "DOC-A", "30")
+
+	created := map[string]time.Time{
+		"early":  time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC),
+		"middle": time.Date(2024, 3, 2, 10, 0, 0, 0, time.UTC),
+		"late":   time.Date(2024, 3, 3, 10, 0, 0, 0, time.UTC),
+	}
+	for _, assetID := range []string{"early", "middle", "late"} {
+		env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+			return env.sc.CreateAsset(ctx, assetID, "", "gold", ownerID)
+		}, withTimestamp(created[assetID]))
+	}
+
+	if got := env.readAsset("middle").CreationTime; got != "2024-03-02T10:00:00Z" {
+		t.Fatalf("creation time = %q, want the RFC 3339 transaction timestamp", got)
+	}
+
+	between := func(start, end string) ([]Asset, error) {
+		var assets []Asset
+		err := env.invoke(func(ctx contractapi.TransactionContextInterface) error {
+			var err error
+			assets, err = env.sc.GetAssetsCreatedBetween(ctx, start, end)
+			return err
+		})
+		return assets, err
+	}
+
+	assets, err := between("2024-03-01T10:00:00Z", "2024-03-02T10:00:00Z")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(assets) != 2 || assets[0].ID != "early" || assets[1].ID != "middle" {
+		t.Fatalf("inclusive range returned %v, want early and middle", assets)
+	}
+
+	assets, err = between("2024-04-01T00:00:00Z", "2024-04-02T00:00:00Z")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if assets == nil || len(assets) != 0 {
+		t.Fatalf("empty range returned %v, want an empty slice", assets)
+	}
+
+	_, err = between("2024-03-03T00:00:00Z", "2024-03-01T00:00:00Z")
+	expectValidationCode(t, err, ValidationOutOfRange)
+
+	_, err = between("yesterday", "2024-03-01T00:00:00Z")
+	expectValidationCode(t, err, ValidationInvalidFormat)
+
+	_, err = between("2024-03-01T00:00:00Z", "tomorrow")
+	expectValidationCode(t, err, ValidationInvalidFormat)
+}
+
+func TestOwnerBindingAuthorizesAssetUpdates(t *testing.T) {
+	env := newTestEnv(t)
+	aliceID := env.createOwner(aliceIdentity, "Alice", "DOC-A", "30")
+	bobID := env.createOwner(bobIdentity, "Bob", "DOC-B", "40")
+	env.createAsset("asset1", "gold", aliceID, 10)
+
+	if got := env.readAsset("asset1").Owner; got != aliceID {
+		t.Fatalf("asset owner = %q, want owner ID %q", got, aliceID)
+	}
+
+	env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.sc.IncrementAssetAmount(ctx, "asset1", 5)
+		return err
+	}, as(aliceIdentity))
+	env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.sc.UpdateAssetDescription(ctx, "asset1", "updated by alice")
+		return err
+	}, as(aliceIdentity))
+
+	asset := env.readAsset("asset1")
+	if asset.Amount != 15 || asset.Description != "updated by alice" {
+		t.Fatalf("owner updates not applied: amount %d, description %q", asset.Amount, asset.Description)
+	}
+
+	err := env.invoke(func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.sc.IncrementAssetAmount(ctx, "asset1", 1)
+		return err
+	}, as(bobIdentity))
+	expectError(t, err, "caller is not the owner of the asset")
+
+	err = env.invoke(func(ctx contractapi.TransactionContextInterface) error {
+		return env.sc.TransferAsset(ctx, "asset1", bobID)
+	}, as(bobIdentity))
+	expectError(t, err, "caller is not the owner of the asset")
+
+	env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+		return env.sc.TransferAsset(ctx, "asset1", bobID)
+	}, as(aliceIdentity))
+	if got := env.readAsset("asset1").Owner; got != bobID {
+		t.Fatalf("asset owner after transfer = %q, want %q", got, bobID)
+	}
+
+	// Alice no longer owns the asset, but an admin can still move it back.
+	err = env.invoke(func(ctx contractapi.TransactionContextInterface) error {
+		return env.sc.TransferAsset(ctx, "asset1", aliceID)
+	}, as(aliceIdentity))
+	expectError(t, err, "caller is not the owner of the asset")
+
+	env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+		return env.sc.TransferAsset(ctx, "asset1", aliceID)
+	}, as(adminIdentity))
+	if got := env.readAsset("asset1").Owner; got != aliceID {
+		t.Fatalf("asset owner after admin transfer = %q, want %q", got, aliceID)
+	}
+}
+
+func TestAssetReadsBehaveUniformly(t *testing.T) {
+	env := newTestEnv(t)
+	aliceID := env.createOwner(aliceIdentity, "Alice", "DOC-A", "30")
+	bobID := env.createOwner(bobIdentity, "Bob", "DOC-B", "40")
+	env.createAsset("asset1", "gold", aliceID, 5)
+	env.ledger.state[compositeKey(t, "asset", "corrupt")] = []byte("{not json")
+
+	reads := map[string]func(ctx contractapi.TransactionContextInterface, assetID string) error{
+		"ReadAsset": func(ctx contractapi.TransactionContextInterface, assetID string) error {
+			_, err := env.sc.ReadAsset(ctx, assetID)
+			return err
+		},
+		"UpdateAssetDescription": func(ctx contractapi.TransactionContextInterface, assetID string) error {
+			_, err := env.sc.UpdateAssetDescription(ctx, assetID, "updated")
+			return err
+		},
+		"GetAssetRaw": func(ctx contractapi.TransactionContextInterface, assetID string) error {
+			_, err := env.sc.GetAssetRaw(ctx, assetID)
+			return err
+		},
+		"GetAssetField": func(ctx contractapi.TransactionContextInterface, assetID string) error {
+			_, err := env.sc.GetAssetField(ctx, assetID, "amount")
+			return err
+		},
+		"TransferAsset": func(ctx contractapi.TransactionContextInterface, assetID string) error {
+			return env.sc.TransferAsset(ctx, assetID, bobID)
+		},
+	}
+
+	for name, read := range reads {
+		t.Run(name, func(t *testing.T) {
+			err := env.invoke(func(ctx contractapi.TransactionContextInterface) error {
+				return read(ctx, "missing")
+			})
+			expectValidationCode(t, err, ValidationNotFound)
+			expectError(t, err, "missing. Does not exist")
+
+			err = env.invoke(func(ctx contractapi.TransactionContextInterface) error {
+				return read(ctx, "corrupt")
+			})
+			expectError(t, err, "unable to unmarshal asset")
+
+			if err := env.invoke(func(ctx contractapi.TransactionContextInterface) error {
+				return read(ctx, "asset1")
+			}); err != nil {
+				t.Fatalf("%s(asset1): %v", name, err)
+			}
+		})
+	}
+
+	// UpdateAssetDescription reads and writes the key of the asset it was given.
+	asset := env.readAsset("asset1")
+	if asset.Description != "updated" || asset.Amount != 5 {
+		t.Fatalf("asset1 after UpdateAssetDescription = %+v", asset)
+	}
+}
+
+func TestGetAssetOwnershipChain(t *testing.T) {
+	env := newTestEnv(t)
+	aliceID := env.createOwner(aliceIdentity, "Alice", "DOC-A", "30")
+	bobID := env.createOwner(bobIdentity, "Bob", "DOC-B", "40")
+
+	at := func(hour int) time.Time {
+		return time.Date(2024, time.May, 1, hour, 0, 0, 0, time.UTC)
+	}
+	env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+		return env.sc.CreateAsset(ctx, "asset1", "description", "gold", aliceID)
+	}, withTxID("tx-create"), withTimestamp(at(1)))
+	env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.sc.UpdateAssetDescription(ctx, "asset1", "same owner")
+		return err
+	}, withTxID("tx-describe"), withTimestamp(at(2)))
+	env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+		return env.sc.TransferAsset(ctx, "asset1", bobID)
+	}, withTxID("tx-to-bob"), withTimestamp(at(3)))
+	env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+		return env.sc.TransferAsset(ctx, "asset1", aliceID)
+	}, withTxID("tx-to-alice"), withTimestamp(at(4)))
+
+	chainOf := func(assetID string) []OwnershipRecord {
+		t.Helper()
+
+		var chain []OwnershipRecord
+		env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+			var err error
+			chain, err = env.sc.GetAssetOwnershipChain(ctx, assetID)
+			return err
+		})
+
+		return chain
+	}
+
+	want := []OwnershipRecord{
+		{Owner: aliceID, TxID: "tx-create", Timestamp: at(1).Format(time.RFC3339)},
+		{Owner: bobID, TxID: "tx-to-bob", Timestamp: at(3).Format(time.RFC3339)},
+		{Owner: aliceID, TxID: "tx-to-alice", Timestamp: at(4).Format(time.RFC3339)},
+	}
+	chain := chainOf("asset1")
+	if len(chain) != len(want) {
+		t.Fatalf("ownership chain = %+v, want %+v", chain, want)
+	}
+	for i := range want {
+		if chain[i] != want[i] {
+			t.Fatalf("ownership chain[%d] = %+v, want %+v", i, chain[i], want[i])
+		}
+	}
+
+	if chain := chainOf("missing"); len(chain) != 0 {
+		t.Fatalf("ownership chain of a missing asset = %+v, want none", chain)
+	}
+}
+
+func TestMaxAssetsPerOwner(t *testing.T) {
+	env := newTestEnv(t)
+	aliceID := env.createOwner(aliceIdentity, "Alice", "DOC-A", "30")
+	bobID := env.createOwner(bobIdentity, "Bob", "DOC-B", "40")
+
+	// Unlimited by default.
+	for _, assetID := range []string{"a1", "a2", "a3"} {
+		env.createAsset(assetID, "gold", aliceID, 1)
+	}
+
+	err := env.invoke(func(ctx contractapi.TransactionContextInterface) error {
+		return env.sc.SetMaxAssetsPerOwner(ctx, 4)
+	}, as(aliceIdentity))
+	expectError(t, err, "caller is not authorized")
+	err = env.invoke(func(ctx contractapi.TransactionContextInterface) error {
+		return env.sc.SetMaxAssetsPerOwner(ctx, -1)
+	})
+	expectValidationCode(t, err, ValidationOutOfRange)
+	env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+		return env.sc.SetMaxAssetsPerOwner(ctx, 4)
+	})
+
+	env.createAsset("a4", "gold", aliceID, 1)
+	err = env.invoke(func(ctx contractapi.TransactionContextInterface) error {
+		return env.sc.CreateAsset(ctx, "a5", "fifth", "gold", aliceID)
+	})
+	expectValidationCode(t, err, ValidationOutOfRange)
+
+	// The limit is per owner.
+	env.createAsset("b1", "gold", bobID, 1)
+
+	// Archived assets still count towards the limit.
+	env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+		return env.sc.ArchiveAsset(ctx, "a1")
+	})
+	err = env.invoke(func(ctx contractapi.TransactionContextInterface) error {
+		return env.sc.CreateAsset(ctx, "a5", "fifth", "gold", aliceID)
+	})
+	expectValidationCode(t, err, ValidationOutOfRange)
+
+	env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+		return env.sc.SetMaxAssetsPerOwner(ctx, 0)
+	})
+	env.createAsset("a5", "gold", aliceID, 1)
+}
+
+func TestMaxAssetsPerOwnerAppliesToTransfers(t *testing.T) {
+	env := newTestEnv(t)
+	aliceID := env.createOwner(aliceIdentity, "Alice", "DOC-A", "30")
+	bobID := env.createOwner(bobIdentity, "Bob", "DOC-B", "40")
+	env.createAsset("a1", "gold", aliceID, 1)
+	env.createAsset("a2", "gold", aliceID, 1)
+	env.createAsset("b1", "gold", bobID, 1)
+	env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+		return env.sc.SetMaxAssetsPerOwner(ctx, 1)
+	})
+
+	err := env.invoke(func(ctx contractapi.TransactionContextInterface) error {
+		return env.sc.TransferAsset(ctx, "a1", bobID)
+	}, as(aliceIdentity))
+	expectValidationCode(t, err, ValidationOutOfRange)
+
+	err = env.invoke(func(ctx contractapi.TransactionContextInterface) error {
+		return env.sc.TransferAssetToDocument(ctx, "a1")
+	}, as(aliceIdentity), withTransient("documentNumber", "DOC-B"))
+	expectValidationCode(t, err, ValidationOutOfRange)
+
+	env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+		return env.sc.ProposeTransfer(ctx, "a2", bobID)
+	}, as(aliceIdentity))
+	err = env.invoke(func(ctx contractapi.TransactionContextInterface) error {
+		return env.sc.AcceptTransfer(ctx, "a2")
+	}, as(bobIdentity))
+	expectValidationCode(t, err, ValidationOutOfRange)
+
+	for _, assetID := range []string{"a1", "a2"} {
+		if got := env.readAsset(assetID).Owner; got != aliceID {
+			t.Fatalf("%s owner = %q after rejected transfers, want %q", assetID, got, aliceID)
+		}
+	}
+
+	// Repairs count towards the limit of the owner the assets are assigned to.
+	env.plantAsset(Asset{ID: "bad1", AssetType: "gold", Owner: "0xc000123abc", Amount: 1})
+	err = env.invoke(func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.sc.RepairAssetOwners(ctx, bobID)
+		return err
+	})
+	expectValidationCode(t, err, ValidationOutOfRange)
+
+	// Once bob gives an asset away, the transfer fits within the limit.
+	env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+		return env.sc.DeleteAsset(ctx, "b1")
+	}, as(bobIdentity))
+	env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+		return env.sc.AcceptTransfer(ctx, "a2")
+	}, as(bobIdentity))
+}
+
+func TestCreateAssetsBatchCountsBatchEntriesPerOwner(t *testing.T) {
+	env := newTestEnv(t)
+	aliceID := env.createOwner(aliceIdentity, "Alice", "DOC-A", "30")
+	bobID := env.createOwner(bobIdentity, "Bob", "DOC-B", "40")
+	env.createAsset("a1", "gold", aliceID, 1)
+	env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+		return env.sc.SetMaxAssetsPerOwner(ctx, 3)
+	})
+
+	// Each entry passes the limit on its own, but alice would end up with four assets.
+	err := env.createAssetsBatch(
+		AssetInput{ID: "a2", AssetType: "gold", OwnerID: aliceID, Amount: 1},
+		AssetInput{ID: "b1", AssetType: "gold", OwnerID: bobID, Amount: 1},
+		AssetInput{ID: "a3", AssetType: "gold", OwnerID: aliceID, Amount: 1},
+		AssetInput{ID: "a4", AssetType: "gold", OwnerID: aliceID, Amount: 1},
+	)
+	expectValidationCode(t, err, ValidationOutOfRange)
+	expectError(t, err, "owner "+aliceID+" already holds the maximum number of assets")
+	for _, assetID := range []string{"a2", "b1", "a3", "a4"} {
+		if env.assetExists(assetID) {
+			t.Fatalf("asset %s was created by a rejected batch", assetID)
+		}
+	}
+
+	err = env.createAssetsBatch(
+		AssetInput{ID: "a2", AssetType: "gold", OwnerID: aliceID, Amount: 1},
+		AssetInput{ID: "b1", AssetType: "gold", OwnerID: bobID, Amount: 1},
+		AssetInput{ID: "a3", AssetType: "gold", OwnerID: aliceID, Amount: 1},
+	)
+	if err != nil {
+		t.Fatalf("CreateAssetsBatch at the limit: %v", err)
+	}
+}
+
+func TestValidateAssetCreationMatchesCreateAssetWithoutWriting(t *testing.T) {
+	env := newTestEnv(t)
+	aliceID := env.createOwner(aliceIdentity, "Alice", "DOC-A", "30")
+	bobID := env.createOwner(bobIdentity, "Bob", "DOC-B", "40")
+	env.createAsset("taken", "gold", aliceID, 1)
+	env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+		return env.sc.SetMaxAssetsPerOwner(ctx, 1)
+	})
+	env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+		return env.sc.SetMaxDescriptionLength(ctx, "20")
+	})
+
+	validate := func(assetID, description, ownerID string) error {
+		err := env.invoke(func(ctx contractapi.TransactionContextInterface) error {
+			return env.sc.ValidateAssetCreation(ctx, assetID, description, "gold", ownerID)
+		})
+		if len(env.lastStub.writes) != 0 || len(env.lastStub.privateWrites) != 0 || len(env.lastStub.events) != 0 {
+			t.Fatalf("ValidateAssetCreation(%q) wrote to the ledger", assetID)
+		}
+		return err
+	}
+	create := func(assetID, description, ownerID string) error {
+		return env.invoke(func(ctx contractapi.TransactionContextInterface) error {
+			return env.sc.CreateAsset(ctx, assetID, description, "gold", ownerID)
+		})
+	}
+
+	for _, tc := range []struct {
+		name, assetID, description, ownerID string
+	}{
+		{"empty ID", "", "description", bobID},
+		{"duplicate", "taken", "description", bobID},
+		{"reserved character", "new\x00asset", "description", bobID},
+		{"missing owner", "new", "description", "999"},
+		{"long description", "new", strings.Repeat("x", 21), bobID},
+		{"owner limit", "new", "description", aliceID},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			validateErr := validate(tc.assetID, tc.description, tc.ownerID)
+			createErr := create(tc.assetID, tc.description, tc.ownerID)
+			if validateErr == nil || createErr == nil || validateErr.Error() != createErr.Error() {
+				t.Fatalf("ValidateAssetCreation = %v, CreateAsset = %v, want the same error", validateErr, createErr)
+			}
+		})
+	}
+
+	err := env.invoke(func(ctx contractapi.TransactionContextInterface) error {
+		return env.sc.ValidateAssetCreation(ctx, "new", "description", "bullion", bobID)
+	})
+	expectValidationCode(t, err, ValidationNotFound)
+	expectError(t, err, "asset type bullion is not registered")
+
+	if err := validate("new", "description", bobID); err != nil {
+		t.Fatalf("ValidateAssetCreation of valid input: %v", err)
+	}
+	if env.assetExists("new") {
+		t.Fatal("ValidateAssetCreation created the asset")
+	}
+	if err := create("new", "description", bobID); err != nil {
+		t.Fatalf("CreateAsset after a passing validation: %v", err)
+	}
+}
+
+func TestGetOwnersWithoutAssets(t *testing.T) {
+	env := newTestEnv(t)
+
+	// More than nine owners, so the key order of the owner IDs differs from their numeric order.
+	ownerIDs := make([]string, 0, 11)
+	for i := 0; i < 11; i++ {
+		ownerIDs = append(ownerIDs, env.createOwner(aliceIdentity, fmt.Sprintf("Owner %d", i), fmt.Sprintf("DOC-%d", i), "30"))
+	}
+	env.createAsset("a2", "gold", ownerIDs[1], 1)
+	env.createAsset("a5", "gold", ownerIDs[4], 1)
+	env.createAsset("a10", "gold", ownerIDs[9], 1)
+
+	withoutAssets := func() string {
+		t.Helper()
+
+		var ids []int
+		env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+			var err error
+			ids, err = env.sc.GetOwnersWithoutAssets(ctx)
+			return err
+		})
+
+		return fmt.Sprint(ids)
+	}
+
+	if got := withoutAssets(); got != "[1 3 4 6 7 8 9 11]" {
+		t.Fatalf("owners without assets = %s", got)
+	}
+
+	env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+		return env.sc.DeleteAsset(ctx, "a5")
+	})
+	env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+		return env.sc.TransferAsset(ctx, "a10", ownerIDs[10])
+	})
+	if got := withoutAssets(); got != "[1 3 4 5 6 7 8 9 10]" {
+		t.Fatalf("owners without assets after delete and transfer = %s", got)
+	}
+}
+
+func TestQueryAssetsPaginated(t *testing.T) {
+	env := newTestEnv(t)
+	aliceID := env.createOwner(aliceIdentity, "Alice", "DOC-A", "30")
+	for _, assetID := range []string{"g1", "g2", "g3", "g4", "g5"} {
+		env.createAsset(assetID, "gold", aliceID, 1)
+	}
+	env.createAsset("s1", "silver", aliceID, 1)
+	// A hierarchical asset matches the selector but lives outside the asset keyspace.
+	env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+		return env.sc.CreateHierarchicalAsset(ctx, "gold", "h1", "description", aliceID)
+	})
+
+	query := func(selectorJSON string, pageSize int32, bookmark string) (*PaginatedAssetResult, error) {
+		var result *PaginatedAssetResult
+		err := env.invoke(func(ctx contractapi.TransactionContextInterface) error {
+			var err error
+			result, err = env.sc.QueryAssetsPaginated(ctx, selectorJSON, pageSize, bookmark)
+			return err
+		}, as(aliceIdentity))
+		return result, err
+	}
+
+	var pages [][]Asset
+	var fetched []int32
+	bookmark := ""
+	for {
+		result, err := query(`{"assetType":"gold"}`, 2, bookmark)
+		if err != nil {
+			t.Fatalf("QueryAssetsPaginated(bookmark %q): %v", bookmark, err)
+		}
+		pages = append(pages, result.Assets)
+		fetched = append(fetched, result.FetchedRecordsCount)
+		if result.Bookmark == "" {
+			break
+		}
+		bookmark = result.Bookmark
+	}
+
+	if len(pages) != 3 || fmt.Sprint(fetched) != "[2 2 2]" {
+		t.Fatalf("got %d pages with fetched counts %v, want 3 pages of 2 records", len(pages), fetched)
+	}
+	expectAssetIDs(t, pages[0], "g1", "g2")
+	expectAssetIDs(t, pages[1], "g3", "g4")
+	expectAssetIDs(t, pages[2], "g5")
+
+	for _, selector := range []string{`not json`, `["assetType"]`, `null`} {
+		_, err := query(selector, 2, "")
+		expectValidationCode(t, err, ValidationInvalidFormat)
+	}
+	_, err := query(`{"assetType":"gold"}`, 0, "")
+	expectValidationCode(t, err, ValidationOutOfRange)
+}
+
+func TestCreateAssetsBatchChecksCombinedAmounts(t *testing.T) {
+	env := newTestEnv(t)
+	aliceID := env.createOwner(aliceIdentity, "Alice", "DOC-A", "30")
+	env.createAsset("existing", "gold", aliceID, 300)
+	env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+		return env.sc.SetTypeCapacity(ctx, "silver", 100)
+	})
+
+	// Each entry fits the total capacity of 500 on its own, the batch does not.
+	err := env.createAssetsBatch(
+		AssetInput{ID: "g1", AssetType: "gold", OwnerID: aliceID, Amount: 150},
+		AssetInput{ID: "g2", AssetType: "gold", OwnerID: aliceID, Amount: 150},
+	)
+	expectValidationCode(t, err, ValidationOutOfRange)
+	expectError(t, err, "batch amounts would exceed the total capacity")
+
+	// Each entry fits the silver capacity of 100 on its own, the batch does not.
+	err = env.createAssetsBatch(
+		AssetInput{ID: "s1", AssetType: "silver", OwnerID: aliceID, Amount: 60},
+		AssetInput{ID: "g1", AssetType: "gold", OwnerID: aliceID, Amount: 10},
+		AssetInput{ID: "s2", AssetType: "silver", OwnerID: aliceID, Amount: 60},
+	)
+	expectValidationCode(t, err, ValidationOutOfRange)
+	expectError(t, err, "capacity of asset type silver")
+
+	for _, assetID := range []string{"g1", "g2", "s1", "s2"} {
+		if env.assetExists(assetID) {
+			t.Fatalf("asset %s was created by a rejected batch", assetID)
+		}
+	}
+
+	err = env.createAssetsBatch(
+		AssetInput{ID: "s1", AssetType: "silver", OwnerID: aliceID, Amount: 60},
+		AssetInput{ID: "g1", AssetType: "gold", OwnerID: aliceID, Amount: 100},
+		AssetInput{ID: "s2", AssetType: "silver", OwnerID: aliceID, Amount: 40},
+	)
+	if err != nil {
+		t.Fatalf("CreateAssetsBatch within capacity: %v", err)
+	}
+	if got := env.readAsset("s2").Amount; got != 40 {
+		t.Fatalf("s2 amount = %d, want 40", got)
+	}
+}
+
+func TestGetAssetField(t *testing.T) {
+	env := newTestEnv(t)
+	stored := Asset{
+		AssetType:         "gold",
+		ID:                "asset1",
+		Description:       "ten bars",
+		Amount:            42,
+		Owner:             "7",
+		CreationTime:      "2024-01-02T03:04:05Z",
+		Archived:          true,
+		MinAmount:         3,
+		CreatedBy:         "alice",
+		Version:           9,
+		Frozen:            true,
+		PendingOwner:      "8",
+		RetiredAt:         "2024-02-03T04:05:06Z",
+		Tags:              []string{"vault", "audited"},
+		PrivateCollection: "vaultCollection",
+	}
+	storedBytes, err := marshalCanonical(stored)
+	if err != nil {
+		t.Fatalf("unable to marshal asset: %v", err)
+	}
+	env.ledger.state[compositeKey(t, "asset", "asset1")] = storedBytes
+
+	getField := func(assetID, fieldName string) (string, error) {
+		var value string
+		err := env.invoke(func(ctx contractapi.TransactionContextInterface) error {
+			var err error
+			value, err = env.sc.GetAssetField(ctx, assetID, fieldName)
+			return err
+		})
+		return value, err
+	}
+
+	for fieldName, want := range map[string]string{
+		"assetType":         "gold",
+		"id":                "asset1",
+		"description":       "ten bars",
+		"amount":            "42",
+		"owner":             "7",
+		"creationTime":      "2024-01-02T03:04:05Z",
+		"archived":          "true",
+		"minAmount":         "3",
+		"createdBy":         "alice",
+		"version":           "9",
+		"frozen":            "true",
+		"pendingOwner":      "8",
+		"retiredAt":         "2024-02-03T04:05:06Z",
+		"tags":              "vault,audited",
+		"privateCollection": "vaultCollection",
+	} {
+		value, err := getField("asset1", fieldName)
+		if err != nil || value != want {
+			t.Fatalf("GetAssetField(%s) = %q, %v, want %q", fieldName, value, err, want)
+		}
+	}
+
+	for _, fieldName := range []string{"Amount", "secret", ""} {
+		_, err := getField("asset1", fieldName)
+		expectValidationCode(t, err, ValidationInvalidFormat)
+	}
+
+	_, err = getField("missing", "amount")
+	expectValidationCode(t, err, ValidationNotFound)
+}
+
+func TestUpdateAssetsByTypeSafe(t *testing.T) {
+	env := newTestEnv(t)
+	aliceID := env.createOwner(aliceIdentity, "Alice", "DOC-A", "30")
+	bobID := env.createOwner(bobIdentity, "Bob", "DOC-B", "40")
+	env.createAsset("c", "gold", aliceID, 1)
+	env.createAsset("a", "gold", bobID, 1)
+	env.createAsset("b", "gold", aliceID, 1)
+	env.createAsset("archived", "gold", aliceID, 1)
+	env.createAsset("frozen", "gold", aliceID, 1)
+	env.createAsset("silver", "silver", aliceID, 1)
+	env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+		return env.sc.ArchiveAsset(ctx, "archived")
+	})
+	env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+		return env.sc.FreezeAsset(ctx, "frozen")
+	})
+
+	update := func() (int, AssetsUpdatedEvent) {
+		t.Helper()
+
+		var updated int
+		env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+			var err error
+			updated, err = env.sc.UpdateAssetsByTypeSafe(ctx, "gold")
+			return err
+		})
+
+		var event AssetsUpdatedEvent
+		if err := json.Unmarshal(env.lastStub.events["AssetsUpdated"], &event); err != nil {
+			t.Fatalf("unable to decode AssetsUpdated event: %v", err)
+		}
+
+		return updated, event
+	}
+
+	for round := 0; round < 2; round++ {
+		updated, event := update()
+		if updated != 3 || event.AssetType != "gold" ||
+			len(event.AssetIDs) != 3 || event.AssetIDs[0] != "a" || event.AssetIDs[1] != "b" || event.AssetIDs[2] != "c" {
+			t.Fatalf("round %d: updated=%d event=%+v", round, updated, event)
+		}
+	}
+
+	for assetID, want := range map[string]int32{"a": 3, "b": 3, "c": 3, "archived": 1, "frozen": 1, "silver": 1} {
+		if got := env.readAsset(assetID).Amount; got != want {
+			t.Fatalf("asset %s amount = %d, want %d", assetID, got, want)
+		}
+	}
+}
+
+func TestUpdateAssetsByTypeSafeEnforcesLimits(t *testing.T) {
+	env := newTestEnv(t)
+	aliceID := env.createOwner(aliceIdentity, "Alice", "DOC-A", "30")
+	bobID := env.createOwner(bobIdentity, "Bob", "DOC-B", "40")
+	env.createAsset("a1", "gold", aliceID, 1)
+	env.createAsset("a2", "gold", aliceID, 1)
+	env.createAsset("b1", "gold", bobID, 1)
+	env.createAsset("full", "platinum", bobID, 500)
+
+	// Every asset of the type must stay within the total capacity.
+	err := env.invoke(func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.sc.UpdateAssetsByTypeSafe(ctx, "platinum")
+		return err
+	})
+	expectValidationCode(t, err, ValidationOutOfRange)
+	expectError(t, err, "asset full would exceed the total capacity")
+
+	// Alice's two assets use two units of her daily allowance in a single transaction.
+	env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+		return env.sc.SetMaxDailyIncrement(ctx, 3)
+	})
+	env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.sc.UpdateAssetsByTypeSafe(ctx, "gold")
+		return err
+	})
+	err = env.invoke(func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.sc.UpdateAssetsByTypeSafe(ctx, "gold")
+		return err
+	})
+	expectValidationCode(t, err, ValidationOutOfRange)
+	expectError(t, err, "owner "+aliceID+" would exceed the daily increment limit")
+	if got := env.readAsset("b1").Amount; got != 2 {
+		t.Fatalf("b1 amount = %d, want 2 after the rejected update", got)
+	}
+}
+
+func TestReserveCommitRelease(t *testing.T) {
+	env := newTestEnv(t)
+	aliceID := env.createOwner(aliceIdentity, "Alice", "DOC-A", "30")
+	env.createOwner(bobIdentity, "Bob", "DOC-B", "40")
+	env.createAsset("asset1", "gold", aliceID, 10)
+
+	reserve := func(amount int32, identity *mockIdentity) (string, error) {
+		var reservationID string
+		err := env.invoke(func(ctx contractapi.TransactionContextInterface) error {
+			var err error
+			reservationID, err = env.sc.ReserveAmount(ctx, "asset1", amount)
+			return err
+		}, as(identity))
+		return reservationID, err
+	}
+
+	_, err := reserve(0, aliceIdentity)
+	expectValidationCode(t, err, ValidationOutOfRange)
+	_, err = reserve(1, bobIdentity)
+	expectError(t, err, "caller is not the owner of the asset")
+
+	first, err := reserve(8, aliceIdentity)
+	if err != nil {
+		t.Fatalf("ReserveAmount: %v", err)
+	}
+	if got := env.readAsset("asset1").Amount; got != 2 {
+		t.Fatalf("amount after reserving 8 = %d, want 2", got)
+	}
+
+	// The reserved amount cannot be reserved or spent again.
+	_, err = reserve(3, aliceIdentity)
+	expectValidationCode(t, err, ValidationOutOfRange)
+	err = env.invoke(func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.sc.IncrementAssetAmount(ctx, "asset1", -3)
+		return err
+	}, as(aliceIdentity))
+	expectValidationCode(t, err, ValidationOutOfRange)
+
+	second, err := reserve(2, aliceIdentity)
+	if err != nil {
+		t.Fatalf("ReserveAmount: %v", err)
+	}
+
+	commit := func(reservationID string) error {
+		return env.invoke(func(ctx contractapi.TransactionContextInterface) error {
+			return env.sc.CommitReservation(ctx, reservationID)
+		}, as(aliceIdentity))
+	}
+	release := func(reservationID string) error {
+		return env.invoke(func(ctx contractapi.TransactionContextInterface) error {
+			return env.sc.ReleaseReservation(ctx, reservationID)
+		}, as(aliceIdentity))
+	}
+
+	if err := commit(first); err != nil {
+		t.Fatalf("CommitReservation: %v", err)
+	}
+	if got := env.readAsset("asset1").Amount; got != 0 {
+		t.Fatalf("amount after commit = %d, want 0", got)
+	}
+	expectValidationCode(t, commit(first), ValidationNotFound)
+	expectValidationCode(t, release(first), ValidationNotFound)
+
+	if err := release(second); err != nil {
+		t.Fatalf("ReleaseReservation: %v", err)
+	}
+	if got := env.readAsset("asset1").Amount; got != 2 {
+		t.Fatalf("amount after release = %d, want 2", got)
+	}
+	expectValidationCode(t, release(second), ValidationNotFound)
+
+	if n := len(env.ledger.scan(compositeKey(t, "asset~reservation"), "", 0)); n != 0 {
+		t.Fatalf("%d reservation index entries left after commit and release", n)
+	}
+}
+
+func TestReleaseReservationRechecksCapacity(t *testing.T) {
+	env := newTestEnv(t)
+	aliceID := env.createOwner(aliceIdentity, "Alice", "DOC-A", "30")
+	env.createAsset("gold1", "gold", aliceID, 10)
+	env.createAsset("gold2", "gold", aliceID, 5)
+	env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+		return env.sc.SetTypeCapacity(ctx, "gold", 20)
+	})
+
+	reserve := func(assetID string, amount int32) string {
+		t.Helper()
+
+		var reservationID string
+		env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+			var err error
+			reservationID, err = env.sc.ReserveAmount(ctx, assetID, amount)
+			return err
+		}, as(aliceIdentity))
+
+		return reservationID
+	}
+	increment := func(assetID string, delta int32) {
+		t.Helper()
+
+		env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+			_, err := env.sc.IncrementAssetAmount(ctx, assetID, delta)
+			return err
+		}, as(aliceIdentity))
+	}
+	release := func(reservationID string) error {
+		return env.invoke(func(ctx contractapi.TransactionContextInterface) error {
+			return env.sc.ReleaseReservation(ctx, reservationID)
+		}, as(aliceIdentity))
+	}
+
+	// The type capacity freed by the reservation is filled by another asset.
+	typeReservation := reserve("gold1", 4)
+	increment("gold2", 9)
+	expectValidationCode(t, release(typeReservation), ValidationOutOfRange)
+	if got := env.readAsset("gold1").Amount; got != 6 {
+		t.Fatalf("gold1 amount after the rejected release = %d, want 6", got)
+	}
+
+	// The reservation is still open, so it can be released once there is room again.
+	increment("gold2", -4)
+	if err := release(typeReservation); err != nil {
+		t.Fatalf("ReleaseReservation: %v", err)
+	}
+	if got := env.readAsset("gold1").Amount; got != 10 {
+		t.Fatalf("gold1 amount after release = %d, want 10", got)
+	}
+
+	// The asset itself is refilled up to the total capacity.
+	env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+		return env.sc.SetTotalCapacity(ctx, 10)
+	})
+	totalReservation := reserve("gold1", 3)
+	increment("gold1", 3)
+	expectValidationCode(t, release(totalReservation), ValidationOutOfRange)
+	if got := env.readAsset("gold1").Amount; got != 10 {
+		t.Fatalf("gold1 amount after the rejected release = %d, want 10", got)
+	}
+}
+
+func TestReservationsBlockRetireAndDelete(t *testing.T) {
+	env := newTestEnv(t)
+	aliceID := env.createOwner(aliceIdentity, "Alice", "DOC-A", "30")
+	env.createAsset("asset1", "gold", aliceID, 10)
+
+	var reservationID string
+	env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+		var err error
+		reservationID, err = env.sc.ReserveAmount(ctx, "asset1", 4)
+		return err
+	}, as(aliceIdentity))
+
+	lifecycle := map[string]func(ctx contractapi.TransactionContextInterface) error{
+		"RetireAsset": func(ctx contractapi.TransactionContextInterface) error {
+			return env.sc.RetireAsset(ctx, "asset1")
+		},
+		"DeleteAsset": func(ctx contractapi.TransactionContextInterface) error {
+			return env.sc.DeleteAsset(ctx, "asset1")
+		},
+		"DeleteAssets": func(ctx contractapi.TransactionContextInterface) error {
+			_, err := env.sc.DeleteAssets(ctx, `["asset1"]`, false)
+			return err
+		},
+		"DeleteAssetsByType": func(ctx contractapi.TransactionContextInterface) error {
+			_, err := env.sc.DeleteAssetsByType(ctx, "gold")
+			return err
+		},
+	}
+	for name, change := range lifecycle {
+		t.Run(name, func(t *testing.T) {
+			expectError(t, env.invoke(change), "asset asset1 has open reservations")
+		})
+	}
+
+	// An archived asset cannot take the reserved amount back until it is unarchived.
+	env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+		return env.sc.ArchiveAsset(ctx, "asset1")
+	})
+	err := env.invoke(func(ctx contractapi.TransactionContextInterface) error {
+		return env.sc.ReleaseReservation(ctx, reservationID)
+	}, as(aliceIdentity))
+	expectError(t, err, "asset asset1 is archived")
+	err = env.invoke(func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.sc.ReserveAmount(ctx, "asset1", 1)
+		return err
+	}, as(aliceIdentity))
+	expectError(t, err, "asset asset1 is archived")
+
+	env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+		return env.sc.UnarchiveAsset(ctx, "asset1")
+	})
+	env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+		return env.sc.ReleaseReservation(ctx, reservationID)
+	}, as(aliceIdentity))
+	env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+		return env.sc.RetireAsset(ctx, "asset1")
+	}, as(aliceIdentity))
+	if asset := env.readAsset("asset1"); asset.RetiredAt == "" || asset.Amount != 0 {
+		t.Fatalf("asset after retire: %+v", asset)
+	}
+}
+
+func TestCreateAssetReturningTxID(t *testing.T) {
+	env := newTestEnv(t)
+	aliceID := env.createOwner(aliceIdentity, "Alice", "DOC-A", "30")
+
+	var txID string
+	env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+		var err error
+		txID, err = env.sc.CreateAssetReturningTxID(ctx, "asset1", "description", "gold", aliceID)
+		return err
+	}, as(aliceIdentity), withTxID("9c0ffee1"))
+	if txID != "9c0ffee1" {
+		t.Fatalf("CreateAssetReturningTxID = %q, want the mocked transaction ID", txID)
+	}
+	if asset := env.readAsset("asset1"); asset.Owner != aliceID {
+		t.Fatalf("created asset owner = %q, want %q", asset.Owner, aliceID)
+	}
+
+	err := env.invoke(func(ctx contractapi.TransactionContextInterface) error {
+		var err error
+		txID, err = env.sc.CreateAssetReturningTxID(ctx, "asset1", "description", "gold", aliceID)
+		return err
+	}, as(aliceIdentity), withTxID("0badc0de"))
+	expectValidationCode(t, err, ValidationAlreadyExists)
+	if txID != "" {
+		t.Fatalf("failed create returned transaction ID %q", txID)
+	}
+}
+
+func TestValidateAge(t *testing.T) {
+	for ageStr, want := range map[string]uint64{"1": 1, "18": 18, "42": 42, "150": maxOwnerAge} {
+		age, err := validateAge(ageStr)
+		if err != nil || age != want {
+			t.Fatalf("validateAge(%q) = %d, %v, want %d", ageStr, age, err, want)
+		}
+	}
+
+	for _, ageStr := range []string{"0", "151", "18446744073709551615"} {
+		_, err := validateAge(ageStr)
+		expectValidationCode(t, err, ValidationOutOfRange)
+		if strings.Contains(err.Error(), ageStr) {
+			t.Fatalf("error for age %s leaks the age: %v", ageStr, err)
+		}
+	}
+
+	for _, ageStr := range []string{"", " 30", "-1", "3.5", "thirty", "18446744073709551616"} {
+		_, err := validateAge(ageStr)
+		expectError(t, err, "unable to parse string to uint")
+		if ageStr != "" && strings.Contains(err.Error(), ageStr) {
+			t.Fatalf("error for age %q leaks the input: %v", ageStr, err)
+		}
+	}
+
+	// UpdateOwner applies the same checks as owner creation.
+	env := newTestEnv(t)
+	aliceID := env.createOwner(aliceIdentity, "Alice", "DOC-A", "30")
+	update := func(ageStr string) error {
+		return env.invoke(func(ctx contractapi.TransactionContextInterface) error {
+			return env.sc.UpdateOwner(ctx, aliceID)
+		}, as(aliceIdentity), withTransient("ownerAge", ageStr))
+	}
+	expectValidationCode(t, update("151"), ValidationOutOfRange)
+	expectError(t, update("thirty"), "unable to parse string to uint")
+	if err := update("31"); err != nil {
+		t.Fatalf("UpdateOwner with a valid age: %v", err)
+	}
+}
+
+func TestGetAssetsByTypeAndOwner(t *testing.T) {
+	env := newTestEnv(t)
+	aliceID := env.createOwner(aliceIdentity, "Alice", "DOC-A", "30")
+	bobID := env.createOwner(bobIdentity, "Bob", "DOC-B", "40")
+	env.createAsset("ag2", "gold", aliceID, 1)
+	env.createAsset("ag1", "gold", aliceID, 1)
+	env.createAsset("as1", "silver", aliceID, 1)
+	env.createAsset("bg1", "gold", bobID, 1)
+	env.createAsset("bs1", "silver", bobID, 1)
+	env.createAsset("bs2", "silver", bobID, 1)
+
+	query := func(assetType, ownerID string) ([]Asset, error) {
+		var assets []Asset
+		err := env.invoke(func(ctx contractapi.TransactionContextInterface) error {
+			var err error
+			assets, err = env.sc.GetAssetsByTypeAndOwner(ctx, assetType, ownerID)
+			return err
+		})
+		return assets, err
+	}
+
+	for _, tc := range []struct {
+		assetType, ownerID string
+		want               []string
+	}{
+		{"gold", aliceID, []string{"ag1", "ag2"}},
+		{"silver", aliceID, []string{"as1"}},
+		{"gold", bobID, []string{"bg1"}},
+		{"silver", bobID, []string{"bs1", "bs2"}},
+		{"copper", aliceID, nil},
+	} {
+		assets, err := query(tc.assetType, tc.ownerID)
+		if err != nil {
+			t.Fatalf("GetAssetsByTypeAndOwner(%s, %s): %v", tc.assetType, tc.ownerID, err)
+		}
+		expectAssetIDs(t, assets, tc.want...)
+	}
+
+	_, err := query("", aliceID)
+	expectValidationCode(t, err, ValidationEmptyField)
+	_, err = query("gold", "")
+	expectValidationCode(t, err, ValidationEmptyField)
+}
+
+func TestMaxDescriptionLength(t *testing.T) {
+	env := newTestEnv(t)
+	aliceID := env.createOwner(aliceIdentity, "Alice", "DOC-A", "30")
+	env.createAsset("asset1", "gold", aliceID, 1)
+
+	create := func(assetID, description string) error {
+		return env.invoke(func(ctx contractapi.TransactionContextInterface) error {
+			return env.sc.CreateAsset(ctx, assetID, description, "gold", aliceID)
+		}, as(aliceIdentity))
+	}
+	update := func(description string) error {
+		return env.invoke(func(ctx contractapi.TransactionContextInterface) error {
+			_, err := env.sc.UpdateAssetDescription(ctx, "asset1", description)
+			return err
+		}, as(aliceIdentity))
+	}
+	setMaxLength := func(identity *mockIdentity, n string) error {
+		return env.invoke(func(ctx contractapi.TransactionContextInterface) error {
+			return env.sc.SetMaxDescriptionLength(ctx, n)
+		}, as(identity))
+	}
+
+	// The default limit applies until a deployment configures its own.
+	expectValidationCode(t, create("long", strings.Repeat("x", defaultMaxDescriptionLength+1)), ValidationOutOfRange)
+	if err := create("default", strings.Repeat("x", defaultMaxDescriptionLength)); err != nil {
+		t.Fatalf("description at the default limit: %v", err)
+	}
+
+	expectError(t, setMaxLength(aliceIdentity, "10"), "caller is not authorized")
+	expectValidationCode(t, setMaxLength(adminIdentity, "ten"), ValidationInvalidFormat)
+	expectValidationCode(t, setMaxLength(adminIdentity, "0"), ValidationOutOfRange)
+	if err := setMaxLength(adminIdentity, "10"); err != nil {
+		t.Fatalf("SetMaxDescriptionLength: %v", err)
+	}
+
+	err := create("over", "eleven char")
+	expectValidationCode(t, err, ValidationOutOfRange)
+	expectError(t, err, "maximum length of 10 bytes")
+	expectValidationCode(t, update("eleven char"), ValidationOutOfRange)
+
+	if err := create("under", "ten chars!"); err != nil {
+		t.Fatalf("description within the configured limit rejected by CreateAsset: %v", err)
+	}
+	if err := update("short"); err != nil {
+		t.Fatalf("description within the configured limit rejected by UpdateAssetDescription: %v", err)
+	}
+}
+
+func TestDeleteAssets(t *testing.T) {
+	env := newTestEnv(t)
+	aliceID := env.createOwner(aliceIdentity, "Alice", "DOC-A", "30")
+	bobID := env.createOwner(bobIdentity, "Bob", "DOC-B", "40")
+	for _, assetID := range []string{"a1", "a2", "a3"} {
+		env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+			return env.sc.CreateAsset(ctx, assetID, "", "gold", aliceID)
+		}, withTransient("assetSecret", "secret-"+assetID))
+	}
+	env.createAsset("b1", "gold", bobID, 1)
+
+	deleteAssets := func(identity *mockIdentity, idsJSON string, skipMissing bool) (int, error) {
+		var deleted int
+		err := env.invoke(func(ctx contractapi.TransactionContextInterface) error {
+			var err error
+			deleted, err = env.sc.DeleteAssets(ctx, idsJSON, skipMissing)
+			return err
+		}, as(identity))
+		return deleted, err
+	}
+
+	// Without skipMissing a partially missing list fails as a whole.
+	_, err := deleteAssets(aliceIdentity, `["a1","missing"]`, false)
+	expectValidationCode(t, err, ValidationNotFound)
+	if !env.assetExists("a1") {
+		t.Fatal("a1 was deleted by a failed transaction")
+	}
+
+	// Alice cannot delete Bob's asset, so nothing in the list is deleted.
+	_, err = deleteAssets(aliceIdentity, `["a1","b1"]`, true)
+	expectError(t, err, "caller is not the owner of the asset")
+	if !env.assetExists("a1") || !env.assetExists("b1") {
+		t.Fatal("assets were deleted by a failed transaction")
+	}
+
+	deleted, err := deleteAssets(aliceIdentity, `["a1","missing","a2","a1"]`, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if deleted != 2 {
+		t.Fatalf("deleted %d assets, want 2", deleted)
+	}
+	for _, assetID := range []string{"a1", "a2"} {
+		if env.assetExists(assetID) {
+			t.Fatalf("%s still exists", assetID)
+		}
+		if _, ok := env.ledger.private[privateCollection][compositeKey(t, "asset", assetID)]; ok {
+			t.Fatalf("private details of %s still exist", assetID)
+		}
+	}
+
+	deleted, err = deleteAssets(aliceIdentity, `["a3"]`, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if deleted != 1 || env.assetExists("a3") {
+		t.Fatalf("strict mode deleted %d assets, want a3 deleted", deleted)
+	}
+}
+
+func TestRepairAssetOwners(t *testing.T) {
+	env := newTestEnv(t)
+	aliceID := env.createOwner(aliceIdentity, "Alice", "DOC-A", "30")
+	bobID := env.createOwner(bobIdentity, "Bob", "DOC-B", "40")
+	env.createAsset("good", "gold", bobID, 1)
+
+	plant := func(asset Asset) {
+		t.Helper()
+
+		assetBytes, err := marshalCanonical(asset)
+		if err != nil {
+			t.Fatalf("unable to marshal asset: %v", err)
+		}
+		env.ledger.state[compositeKey(t, "asset", asset.ID)] = assetBytes
+	}
+	plant(Asset{ID: "bad1", AssetType: "gold", Owner: "0xc000123abc", Amount: 1})
+	plant(Asset{ID: "bad2", AssetType: "gold", Owner: "0xc0001f2e40", Amount: 1, Archived: true})
+	plant(Asset{ID: "frozen", AssetType: "gold", Owner: "0xc000aaaaaa", Amount: 1, Frozen: true})
+	plant(Asset{ID: "odd1", AssetType: "gold", Owner: "0x", Amount: 1})
+	plant(Asset{ID: "odd2", AssetType: "gold", Owner: "0xZZ", Amount: 1})
+
+	repair := func(identity *mockIdentity, ownerID string) (int, error) {
+		var count int
+		err := env.invoke(func(ctx contractapi.TransactionContextInterface) error {
+			var err error
+			count, err = env.sc.RepairAssetOwners(ctx, ownerID)
+			return err
+		}, as(identity))
+		return count, err
+	}
+
+	_, err := repair(aliceIdentity, aliceID)
+	expectError(t, err, "caller is not authorized")
+	_, err = repair(adminIdentity, "999")
+	expectValidationCode(t, err, ValidationNotFound)
+
+	count, err := repair(adminIdentity, aliceID)
+	if err != nil || count != 2 {
+		t.Fatalf("RepairAssetOwners = %d, %v, want 2", count, err)
+	}
+	for assetID, want := range map[string]string{
+		"bad1":   aliceID,
+		"bad2":   aliceID,
+		"frozen": "0xc000aaaaaa",
+		"odd1":   "0x",
+		"odd2":   "0xZZ",
+		"good":   bobID,
+	} {
+		if got := env.readAsset(assetID).Owner; got != want {
+			t.Fatalf("%s owner = %q, want %q", assetID, got, want)
+		}
+	}
+
+	count, err = repair(adminIdentity, aliceID)
+	if err != nil || count != 0 {
+		t.Fatalf("second RepairAssetOwners = %d, %v, want 0", count, err)
+	}
+}
+
+func TestGetAssetSummary(t *testing.T) {
+	env := newTestEnv(t)
+	aliceID := env.createOwner(aliceIdentity, "Alice", "DOC-A", "30")
+
+	summarize := func() *AssetSummary {
+		t.Helper()
+
+		var summary *AssetSummary
+		env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+			var err error
+			summary, err = env.sc.GetAssetSummary(ctx)
+			return err
+		})
+
+		return summary
+	}
+
+	empty := summarize()
+	if empty.AssetCount != 0 || empty.TotalAmount != 0 || len(empty.CountByType) != 0 || empty.TotalCapacity != defaultTotalCapacity {
+		t.Fatalf("summary of an empty ledger = %+v", empty)
+	}
+
+	env.createAsset("g1", "gold", aliceID, 100)
+	env.createAsset("s1", "silver", aliceID, 20)
+	env.createAsset("g2", "gold", aliceID, 30)
+	env.createAsset("c1", "copper", aliceID, 5)
+
+	summary := summarize()
+	if summary.AssetCount != 4 || summary.TotalAmount != 155 || summary.UsedCapacity != 155 || summary.TotalCapacity != defaultTotalCapacity {
+		t.Fatalf("summary = %+v", summary)
+	}
+
+	summaryBytes, err := json.Marshal(summary)
+	if err != nil {
+		t.Fatalf("unable to marshal summary: %v", err)
+	}
+	if !strings.Contains(string(summaryBytes), `"countByType":{"copper":1,"gold":2,"silver":1}`) {
+		t.Fatalf("summary JSON = %s, want per-type counts in sorted key order", summaryBytes)
+	}
+}
+
+func TestReadAllAssetsDistinguishesEmptyFromUninitialized(t *testing.T) {
+	env := newTestEnv(t)
+	env.ledger = newMockLedger()
+
+	state := func() ([]Asset, bool) {
+		t.Helper()
+
+		var assets []Asset
+		var initialized bool
+		env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+			var err error
+			assets, err = env.sc.ReadAllAssets(ctx)
+			if err != nil {
+				return err
+			}
+			initialized, err = env.sc.IsInitialized(ctx)
+			return err
+		})
+		if assets == nil {
+			t.Fatal("ReadAllAssets returned a nil slice")
+		}
+
+		return assets, initialized
+	}
+
+	assets, initialized := state()
+	if initialized || len(assets) != 0 {
+		t.Fatalf("uninitialized ledger: %d assets, initialized %v", len(assets), initialized)
+	}
+
+	env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+		return env.sc.InitContract(ctx, false)
+	})
+	assets, initialized = state()
+	if !initialized || len(assets) != 0 {
+		t.Fatalf("initialized empty ledger: %d assets, initialized %v", len(assets), initialized)
+	}
+	if assetsBytes, err := json.Marshal(assets); err != nil || string(assetsBytes) != "[]" {
+		t.Fatalf("empty asset list serializes as %s, %v, want []", assetsBytes, err)
+	}
+
+	env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+		return env.sc.RegisterAssetType(ctx, "gold")
+	})
+	aliceID := env.createOwner(aliceIdentity, "Alice", "DOC-A", "30")
+	env.createAsset("asset2", "gold", aliceID, 1)
+	env.createAsset("asset1", "gold", aliceID, 1)
+	assets, initialized = state()
+	if !initialized {
+		t.Fatal("populated ledger is not initialized")
+	}
+	// ReadAllAssets does not promise an order, so compare the sorted IDs.
+	sortAssetsByID(assets)
+	expectAssetIDs(t, assets, "asset1", "asset2")
+}
+
+func TestDailyIncrementLimit(t *testing.T) {
+	env := newTestEnv(t)
+	aliceID := env.createOwner(aliceIdentity, "Alice", "DOC-A", "30")
+	bobID := env.createOwner(bobIdentity, "Bob", "DOC-B", "40")
+	env.createAsset("a1", "gold", aliceID, 1)
+	env.createAsset("a2", "gold", aliceID, 1)
+	env.createAsset("b1", "gold", bobID, 1)
+
+	err := env.invoke(func(ctx contractapi.TransactionContextInterface) error {
+		return env.sc.SetMaxDailyIncrement(ctx, 10)
+	}, as(aliceIdentity))
+	expectError(t, err, "caller is not authorized")
+	env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+		return env.sc.SetMaxDailyIncrement(ctx, 10)
+	})
+
+	day := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	increment := func(assetID string, delta int32, at time.Time) error {
+		return env.invoke(func(ctx contractapi.TransactionContextInterface) error {
+			_, err := env.sc.IncrementAssetAmount(ctx, assetID, delta)
+			return err
+		}, withTimestamp(at))
+	}
+
+	// Increments accumulate per owner across assets and transactions within a day.
+	if err := increment("a1", 4, day.Add(time.Hour)); err != nil {
+		t.Fatalf("first increment: %v", err)
+	}
+	if err := increment("a2", 4, day.Add(2*time.Hour)); err != nil {
+		t.Fatalf("second increment: %v", err)
+	}
+	err = increment("a1", 3, day.Add(3*time.Hour))
+	expectValidationCode(t, err, ValidationOutOfRange)
+	expectError(t, err, "owner "+aliceID+" would exceed the daily increment limit")
+
+	// Decrements do not free up allowance, and other owners have their own window.
+	if err := increment("a1", -2, day.Add(4*time.Hour)); err != nil {
+		t.Fatalf("decrement: %v", err)
+	}
+	expectValidationCode(t, increment("a2", 3, day.Add(5*time.Hour)), ValidationOutOfRange)
+	if err := increment("a1", 2, day.Add(23*time.Hour+59*time.Minute)); err != nil {
+		t.Fatalf("increment up to the limit: %v", err)
+	}
+	if err := increment("b1", 10, day.Add(6*time.Hour)); err != nil {
+		t.Fatalf("increment for another owner: %v", err)
+	}
+
+	// The window resets at the next UTC day.
+	nextDay := day.Add(24 * time.Hour)
+	if err := increment("a1", 10, nextDay); err != nil {
+		t.Fatalf("increment on the next day: %v", err)
+	}
+	expectValidationCode(t, increment("a2", 1, nextDay.Add(time.Minute)), ValidationOutOfRange)
+
+	if got := env.readAsset("a1").Amount; got != 15 {
+		t.Fatalf("a1 amount = %d, want 15", got)
+	}
+	if got := env.readAsset("a2").Amount; got != 5 {
+		t.Fatalf("a2 amount = %d, want 5", got)
+	}
+}
+
+func TestGetOwnerStatement(t *testing.T) {
+	env := newTestEnv(t)
+	aliceID := env.createOwner(aliceIdentity, "Alice", "DOC-A", "30")
+	bobID := env.createOwner(bobIdentity, "Bob", "DOC-B", "40")
+	env.createAsset("a2", "gold", aliceID, 30)
+	env.createAsset("a1", "silver", aliceID, 12)
+	env.createAsset("b1", "gold", bobID, 99)
+
+	statementFor := func(ownerID string, identity *mockIdentity) (*OwnerStatement, error) {
+		var statement *OwnerStatement
+		err := env.invoke(func(ctx contractapi.TransactionContextInterface) error {
+			var err error
+			statement, err = env.sc.GetOwnerStatement(ctx, ownerID)
+			return err
+		}, as(identity))
+		return statement, err
+	}
+
+	member, err := statementFor(aliceID, bobIdentity)
+	if err != nil {
+		t.Fatalf("GetOwnerStatement as a member: %v", err)
+	}
+	if fmt.Sprint(member.OwnerID) != aliceID || member.Name != "Alice" || member.TotalAmount != 42 {
+		t.Fatalf("member statement = %+v", member)
+	}
+	expectAssetIDs(t, member.Assets, "a1", "a2")
+
+	outsider, err := statementFor(aliceID, outsiderIdentity)
+	if err != nil {
+		t.Fatalf("GetOwnerStatement as a non-member: %v", err)
+	}
+	if outsider.Name != "" || outsider.TotalAmount != 42 || fmt.Sprint(outsider.OwnerID) != aliceID {
+		t.Fatalf("non-member statement = %+v, want the public fields only", outsider)
+	}
+	expectAssetIDs(t, outsider.Assets, "a1", "a2")
+	if statementBytes, err := json.Marshal(outsider); err != nil || strings.Contains(string(statementBytes), `"name"`) {
+		t.Fatalf("non-member statement JSON = %s, %v, want no name field", statementBytes, err)
+	}
+
+	_, err = statementFor("999", bobIdentity)
+	expectValidationCode(t, err, ValidationNotFound)
+}
+
+func TestContractConfigIsPersistedAndSetByAdmins(t *testing.T) {
+	env := newTestEnv(t)
+	aliceID := env.createOwner(aliceIdentity, "Alice", "DOC-A", "30")
+
+	readConfig := func() *ContractConfig {
+		t.Helper()
+
+		var config *ContractConfig
+		env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+			var err error
+			config, err = env.sc.GetContractConfig(ctx)
+			return err
+		})
+
+		return config
+	}
+
+	if config := readConfig(); config.TotalCapacity != defaultTotalCapacity || config.MinimumOwnerAge != minOwnerAge {
+		t.Fatalf("defaults after init: %+v", config)
+	}
+
+	err := env.invoke(func(ctx contractapi.TransactionContextInterface) error {
+		return env.sc.SetTotalCapacity(ctx, 50)
+	}, as(aliceIdentity))
+	expectError(t, err, "caller is not authorized")
+	err = env.invoke(func(ctx contractapi.TransactionContextInterface) error {
+		return env.sc.SetTotalCapacity(ctx, -1)
+	})
+	expectValidationCode(t, err, ValidationOutOfRange)
+	env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+		return env.sc.SetTotalCapacity(ctx, 50)
+	})
+	env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+		return env.sc.SetMinimumAge(ctx, 21)
+	})
+
+	// Re-initializing requires force and an admin, and keeps the values set through the setters.
+	err = env.invoke(func(ctx contractapi.TransactionContextInterface) error {
+		return env.sc.InitContract(ctx, false)
+	})
+	expectError(t, err, "contract is already initialized")
+	err = env.invoke(func(ctx contractapi.TransactionContextInterface) error {
+		return env.sc.InitContract(ctx, true)
+	}, as(aliceIdentity))
+	expectError(t, err, "caller is not authorized")
+	env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+		return env.sc.InitContract(ctx, true)
+	})
+	for _, name := range []string{totalCapacityConfig, minimumOwnerAgeConfig} {
+		if _, ok := env.lastStub.writes[compositeKey(t, "config", name)]; ok {
+			t.Fatalf("forced re-init rewrote config %s", name)
+		}
+	}
+
+	// A fresh contract instance, as on another peer, reads the same capacity from world state.
+	env.sc = &FabricVulnBenchmark{}
+	if config := readConfig(); config.TotalCapacity != 50 || config.MinimumOwnerAge != 21 {
+		t.Fatalf("config after forced re-init: %+v", config)
+	}
+	err = env.invoke(func(ctx contractapi.TransactionContextInterface) error {
+		return env.sc.CreateAssetWithAmount(ctx, "big", "too big", "gold", aliceID, 51)
+	})
+	expectValidationCode(t, err, ValidationOutOfRange)
+	env.createAsset("fits", "gold", aliceID, 50)
+
+	// ChangeTotalCapacity goes through the same admin check.
+	err = env.invoke(func(ctx contractapi.TransactionContextInterface) error {
+		return env.sc.ChangeTotalCapacity(ctx, "70")
+	}, as(aliceIdentity))
+	expectError(t, err, "caller is not authorized")
+	err = env.invoke(func(ctx contractapi.TransactionContextInterface) error {
+		return env.sc.ChangeTotalCapacity(ctx, "seventy")
+	})
+	expectError(t, err, "unable to parse string to int")
+	env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+		return env.sc.ChangeTotalCapacity(ctx, "70")
+	})
+
+	var remaining int64
+	env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+		var err error
+		remaining, err = env.sc.GetRemainingCapacity(ctx)
+		return err
+	})
+	if remaining != 20 {
+		t.Fatalf("remaining capacity = %d, want 20", remaining)
+	}
+}
+
+func TestGetAssetsAboveAmount(t *testing.T) {
+	env := newTestEnv(t)
+	aliceID := env.createOwner(aliceIdentity, "Alice", "DOC-A", "30")
+	env.createAsset("d", "gold", aliceID, 50)
+	env.createAsset("a", "gold", aliceID, 9)
+	env.createAsset("c", "silver", aliceID, 11)
+	env.createAsset("b", "gold", aliceID, 10)
+
+	above := func(threshold int32) []Asset {
+		t.Helper()
+
+		var assets []Asset
+		env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+			var err error
+			assets, err = env.sc.GetAssetsAboveAmount(ctx, threshold)
+			return err
+		})
+
+		return assets
+	}
+
+	// The threshold itself is excluded.
+	expectAssetIDs(t, above(10), "c", "d")
+	expectAssetIDs(t, above(8), "a", "b", "c", "d")
+	expectAssetIDs(t, above(-1), "a", "b", "c", "d")
+	expectAssetIDs(t, above(50))
+
+	if assets := above(100); assets == nil || len(assets) != 0 {
+		t.Fatalf("GetAssetsAboveAmount(100) = %v, want an empty slice", assets)
+	}
+}
+
+func TestTransferAssetLifecycleFlags(t *testing.T) {
+	env := newTestEnv(t)
+	aliceID := env.createOwner(aliceIdentity, "Alice", "DOC-A", "30")
+	bobID := env.createOwner(bobIdentity, "Bob", "DOC-B", "40")
+	for _, assetID := range []string{"frozen", "archived", "both", "clean"} {
+		env.createAsset(assetID, "gold", aliceID, 1)
+	}
+
+	archive := func(assetID string) {
+		t.Helper()
+		env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+			return env.sc.ArchiveAsset(ctx, assetID)
+		})
+	}
+	freeze := func(assetID string) {
+		t.Helper()
+		env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+			return env.sc.FreezeAsset(ctx, assetID)
+		})
+	}
+	freeze("frozen")
+	archive("archived")
+	archive("both")
+	freeze("both")
+
+	transfer := func(assetID string) error {
+		return env.invoke(func(ctx contractapi.TransactionContextInterface) error {
+			return env.sc.TransferAsset(ctx, assetID, bobID)
+		}, as(aliceIdentity))
+	}
+
+	expectError(t, transfer("frozen"), "asset frozen is frozen and cannot be modified")
+	expectError(t, transfer("archived"), "asset archived is archived and cannot be transferred")
+	// The frozen check runs first, so an asset with both flags reports the freeze.
+	expectError(t, transfer("both"), "asset both is frozen and cannot be modified")
+	for _, assetID := range []string{"frozen", "archived", "both"} {
+		if got := env.readAsset(assetID).Owner; got != aliceID {
+			t.Fatalf("%s owner = %q after a rejected transfer, want %q", assetID, got, aliceID)
+		}
+	}
+
+	if err := transfer("clean"); err != nil {
+		t.Fatalf("TransferAsset(clean): %v", err)
+	}
+	if got := env.readAsset("clean").Owner; got != bobID {
+		t.Fatalf("clean owner = %q, want %q", got, bobID)
+	}
+}
+
+func TestGetRequiredIndexes(t *testing.T) {
+	env := newTestEnv(t)
+
+	var indexes []string
+	env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+		var err error
+		indexes, err = env.sc.GetRequiredIndexes(ctx)
+		return err
+	}, as(outsiderIdentity))
+
+	var fields []string
+	for _, definition := range indexes {
+		var index struct {
+			Index struct {
+				Fields []string `json:"fields"`
+			} `json:"index"`
+			DDoc string `json:"ddoc"`
+			Name string `json:"name"`
+			Type string `json:"type"`
+		}
+		if err := decodeStrict(definition, &index); err != nil {
+			t.Fatalf("index definition %s is not valid JSON: %v", definition, err)
+		}
+		if index.Name == "" || index.DDoc == "" || index.Type != "json" || len(index.Index.Fields) != 1 {
+			t.Fatalf("incomplete index definition %s", definition)
+		}
+
+		// The definition must match the file packaged with the chaincode.
+		packaged, err := os.ReadFile(filepath.Join("..", "META-INF", "statedb", "couchdb", "indexes", index.Name+".json"))
+		if err != nil {
+			t.Fatalf("index %s is not packaged: %v", index.Name, err)
+		}
+		if strings.TrimSpace(string(packaged)) != definition {
+			t.Fatalf("packaged index %s = %s, want %s", index.Name, packaged, definition)
+		}
+
+		fields = append(fields, index.Index.Fields[0])
+	}
+
+	if strings.Join(fields, ",") != "amount,assetType,owner" {
+		t.Fatalf("indexed fields = %v, want amount, assetType and owner", fields)
+	}
+}
+
+func TestFindDuplicateOwnerIDs(t *testing.T) {
+	env := newTestEnv(t)
+	env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+		return env.sc.SetOwnerIDScheme(ctx, "counter")
+	})
+
+	findDuplicates := func(identity *mockIdentity) ([]int, error) {
+		var duplicates []int
+		err := env.invoke(func(ctx contractapi.TransactionContextInterface) error {
+			var err error
+			duplicates, err = env.sc.FindDuplicateOwnerIDs(ctx)
+			return err
+		}, as(identity))
+		return duplicates, err
+	}
+
+	env.createOwner(aliceIdentity, "Alice", "DOC-A", "30")
+	env.createOwner(bobIdentity, "Bob", "DOC-B", "40")
+	duplicates, err := findDuplicates(adminIdentity)
+	if err != nil || len(duplicates) != 0 {
+		t.Fatalf("consistent owners: duplicates=%v err=%v", duplicates, err)
+	}
+
+	_, err = findDuplicates(outsiderIdentity)
+	expectError(t, err, "client is not authorized to access private data")
+
+	// A restarted peer starts the in-memory counter again and overwrites owner 1.
+	env.sc.ownerCounter = 1
+	carolID := env.createOwner(aliceIdentity, "Carol", "DOC-C", "50")
+	if carolID != "1" {
+		t.Fatalf("restarted counter minted %s, want 1", carolID)
+	}
+
+	// A public record stored under a key that does not match its ID.
+	env.ledger.state[compositeKey(t, "owner", "7")] = []byte(`{"id":5}`)
+
+	duplicates, err = findDuplicates(adminIdentity)
+	if err != nil {
+		t.Fatalf("FindDuplicateOwnerIDs: %v", err)
+	}
+	if len(duplicates) != 2 || duplicates[0] != 1 || duplicates[1] != 5 {
+		t.Fatalf("duplicates = %v, want [1 5]", duplicates)
+	}
+}
+
+func TestToInt32Checked(t *testing.T) {
+	for _, value := range []int64{0, 1, -1, math.MaxInt32, math.MinInt32, math.MaxInt32 - 1} {
+		converted, err := toInt32Checked(value)
+		if err != nil || int64(converted) != value {
+			t.Fatalf("toInt32Checked(%d) = %d, %v", value, converted, err)
+		}
+	}
+
+	for _, value := range []int64{math.MaxInt32 + 1, math.MinInt32 - 1, math.MaxInt64, math.MinInt64, 1 << 32} {
+		converted, err := toInt32Checked(value)
+		if converted != 0 {
+			t.Fatalf("toInt32Checked(%d) = %d, want 0 alongside the error", value, converted)
+		}
+		expectValidationCode(t, err, ValidationOutOfRange)
+	}
+}
+
+func TestTouchAssetReadsWithoutWriting(t *testing.T) {
+	env := newTestEnv(t)
+	aliceID := env.createOwner(aliceIdentity, "Alice", "DOC-A", "30")
+	env.createAsset("asset1", "gold", aliceID, 1)
+	before := env.readAsset("asset1")
+
+	touch := func(assetID string) error {
+		return env.invoke(func(ctx contractapi.TransactionContextInterface) error {
+			return env.sc.TouchAsset(ctx, assetID)
+		}, as(bobIdentity))
+	}
+
+	if err := touch("asset1"); err != nil {
+		t.Fatalf("TouchAsset: %v", err)
+	}
+	assetKey := compositeKey(t, "asset", "asset1")
+	if strings.Join(env.lastStub.reads, ",") != assetKey {
+		t.Fatalf("TouchAsset read %q, want only the asset key", env.lastStub.reads)
+	}
+	if len(env.lastStub.writes) != 0 || len(env.lastStub.privateWrites) != 0 || len(env.lastStub.events) != 0 {
+		t.Fatal("TouchAsset wrote to the ledger")
+	}
+	if after := env.readAsset("asset1"); after.Version != before.Version {
+		t.Fatalf("asset version changed from %d to %d", before.Version, after.Version)
+	}
+
+	expectValidationCode(t, touch("missing"), ValidationNotFound)
+}
+
+func TestExportAssetsCSV(t *testing.T) {
+	env := newTestEnv(t)
+	aliceID := env.createOwner(aliceIdentity, "Alice", "DOC-A", "30")
+
+	descriptions := map[string]string{
+		"c": "plain",
+		"a": "bars, coins and dust",
+		"b": `the "good" vault`,
+		"d": "multi\nline, \"quoted\"",
+	}
+	for _, assetID := range []string{"c", "a", "b", "d"} {
+		env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+			return env.sc.CreateAsset(ctx, assetID, descriptions[assetID], "gold", aliceID)
+		})
+	}
+
+	var export string
+	env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+		var err error
+		export, err = env.sc.ExportAssetsCSV(ctx)
+		return err
+	})
+
+	if !strings.Contains(export, `"bars, coins and dust"`) || !strings.Contains(export, `"the ""good"" vault"`) {
+		t.Fatalf("export does not quote commas and quotes:\n%s", export)
+	}
+
+	records, err := csv.NewReader(strings.NewReader(export)).ReadAll()
+	if err != nil {
+		t.Fatalf("export is not valid CSV: %v\n%s", err, export)
+	}
+	if len(records) != 5 {
+		t.Fatalf("export has %d records, want a header and 4 rows", len(records))
+	}
+	if header := records[0]; header[0] != "id" || header[2] != "description" || header[4] != "owner" {
+		t.Fatalf("header = %v", header)
+	}
+
+	for i, assetID := range []string{"a", "b", "c", "d"} {
+		row := records[i+1]
+		if len(row) != len(records[0]) {
+			t.Fatalf("row %d has %d fields, want %d", i+1, len(row), len(records[0]))
+		}
+		if row[0] != assetID || row[2] != descriptions[assetID] || row[4] != aliceID {
+			t.Fatalf("row %d = %q, want asset %s with description %q", i+1, row, assetID, descriptions[assetID])
+		}
+	}
+}
+
+func TestGetOwnerByDocumentNumber(t *testing.T) {
+	env := newTestEnv(t)
+	env.createOwner(aliceIdentity, "Alice", "DOC-A", "30")
+	bobID := env.createOwner(bobIdentity, "Bob", "DOC-B", "40")
+
+	lookup := func(identity *mockIdentity, opts ...txOption) (int, error) {
+		var ownerID int
+		err := env.invoke(func(ctx contractapi.TransactionContextInterface) error {
+			var err error
+			ownerID, err = env.sc.GetOwnerByDocumentNumber(ctx)
+			return err
+		}, append([]txOption{as(identity)}, opts...)...)
+		return ownerID, err
+	}
+
+	ownerID, err := lookup(aliceIdentity, withTransient("documentNumber", "DOC-B"))
+	if err != nil || fmt.Sprint(ownerID) != bobID {
+		t.Fatalf("GetOwnerByDocumentNumber(DOC-B) = %d, %v, want %s", ownerID, err, bobID)
+	}
+
+	_, err = lookup(aliceIdentity, withTransient("documentNumber", "DOC-Z"))
+	expectValidationCode(t, err, ValidationNotFound)
+	if strings.Contains(err.Error(), "DOC-Z") {
+		t.Fatalf("not-found error leaks the document number: %v", err)
+	}
+
+	_, err = lookup(aliceIdentity)
+	expectValidationCode(t, err, ValidationEmptyField)
+
+	_, err = lookup(outsiderIdentity, withTransient("documentNumber", "DOC-B"))
+	expectError(t, err, "client is not authorized to access private data")
+}
+
+func TestCreateAssetsBatchPreservesInputOrder(t *testing.T) {
+	env := newTestEnv(t)
+	aliceID := env.createOwner(aliceIdentity, "Alice", "DOC-A", "30")
+
+	// The input order is deliberately neither sorted nor reverse sorted.
+	order := []string{"m", "z", "a", "q", "b"}
+	inputs := make([]AssetInput, 0, len(order))
+	for _, assetID := range order {
+		inputs = append(inputs, AssetInput{ID: assetID, AssetType: "gold", OwnerID: aliceID, Amount: 1})
+	}
+	inputsJSON, err := json.Marshal(inputs)
+	if err != nil {
+		t.Fatalf("unable to marshal batch: %v", err)
+	}
+
+	var assetIDs []string
+	env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+		var err error
+		assetIDs, err = env.sc.CreateAssetsBatch(ctx, string(inputsJSON))
+		return err
+	})
+	if strings.Join(assetIDs, ",") != strings.Join(order, ",") {
+		t.Fatalf("CreateAssetsBatch returned %v, want %v", assetIDs, order)
+	}
+
+	if len(env.lastStub.events) != 1 {
+		t.Fatalf("batch set %d events, want one aggregate event", len(env.lastStub.events))
+	}
+	var event AssetsCreatedEvent
+	if err := json.Unmarshal(env.lastStub.events["AssetsCreated"], &event); err != nil {
+		t.Fatalf("unable to unmarshal AssetsCreated event: %v", err)
+	}
+	if strings.Join(event.AssetIDs, ",") != strings.Join(order, ",") {
+		t.Fatalf("AssetsCreated lists %v, want %v", event.AssetIDs, order)
+	}
+}
+
+func TestReadAssetAtTxID(t *testing.T) {
+	env := newTestEnv(t)
+	aliceID := env.createOwner(aliceIdentity, "Alice", "DOC-A", "30")
+
+	env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+		return env.sc.CreateAssetWithAmount(ctx, "asset1", "v1", "gold", aliceID, 10)
+	}, withTxID("tx-v1"))
+	env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.sc.UpdateAssetDescription(ctx, "asset1", "v2")
+		return err
+	}, withTxID("tx-v2"))
+	env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.sc.IncrementAssetAmount(ctx, "asset1", 5)
+		return err
+	}, withTxID("tx-v3"))
+	env.createAsset("asset2", "gold", aliceID, 1)
+	env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+		return env.sc.DeleteAsset(ctx, "asset1")
+	}, withTxID("tx-delete"))
+
+	readAt := func(assetID, txID string) (*Asset, error) {
+		var asset *Asset
+		err := env.invoke(func(ctx contractapi.TransactionContextInterface) error {
+			var err error
+			asset, err = env.sc.ReadAssetAtTxID(ctx, assetID, txID)
+			return err
+		})
+		return asset, err
+	}
+
+	for _, tc := range []struct {
+		txID        string
+		description string
+		amount      int32
+		version     int
+	}{
+		{"tx-v1", "v1", 10, 1},
+		{"tx-v2", "v2", 10, 2},
+		{"tx-v3", "v2", 15, 3},
+	} {
+		asset, err := readAt("asset1", tc.txID)
+		if err != nil {
+			t.Fatalf("ReadAssetAtTxID(%s): %v", tc.txID, err)
+		}
+		if asset.Description != tc.description || asset.Amount != tc.amount || asset.Version != tc.version {
+			t.Fatalf("asset at %s = %+v, want description %s, amount %d, version %d", tc.txID, asset, tc.description, tc.amount, tc.version)
+		}
+	}
+
+	_, err := readAt("asset1", "tx-delete")
+	expectValidationCode(t, err, ValidationNotFound)
+	expectError(t, err, "was deleted by transaction tx-delete")
+
+	// A transaction that only touched another key is not part of this key's history.
+	_, err = readAt("asset2", "tx-v2")
+	expectValidationCode(t, err, ValidationNotFound)
+	_, err = readAt("asset1", "tx-unknown")
+	expectValidationCode(t, err, ValidationNotFound)
+}
+
+func TestAssertSameType(t *testing.T) {
+	gold1 := &Asset{ID: "g1", AssetType: "gold"}
+	gold2 := &Asset{ID: "g2", AssetType: "gold"}
+	silver := &Asset{ID: "s1", AssetType: "silver"}
+
+	if err := assertSameType(gold1, gold2); err != nil {
+		t.Fatalf("assertSameType(gold, gold): %v", err)
+	}
+	expectError(t, assertSameType(gold1, silver), "assets g1 and s1 must be of the same type")
+	expectError(t, assertSameType(silver, gold1), "assets s1 and g1 must be of the same type")
+
+	// TransferAmount rejects mismatched types with the helper's message and moves nothing.
+	env := newTestEnv(t)
+	aliceID := env.createOwner(aliceIdentity, "Alice", "DOC-A", "30")
+	env.createAsset("g1", "gold", aliceID, 10)
+	env.createAsset("s1", "silver", aliceID, 10)
+
+	err := env.invoke(func(ctx contractapi.TransactionContextInterface) error {
+		return env.sc.TransferAmount(ctx, "g1", "s1", 4)
+	}, as(aliceIdentity))
+	if want := assertSameType(gold1, silver); err == nil || err.Error() != want.Error() {
+		t.Fatalf("TransferAmount across types = %v, want %v", err, want)
+	}
+	if env.readAsset("g1").Amount != 10 || env.readAsset("s1").Amount != 10 {
+		t.Fatal("a rejected cross-type transfer moved an amount")
+	}
+}
+
+func TestReadAllAssetsLimited(t *testing.T) {
+	env := newTestEnv(t)
+	aliceID := env.createOwner(aliceIdentity, "Alice", "DOC-A", "30")
+	for _, assetID := range []string{"c", "a", "b"} {
+		env.createAsset(assetID, "gold", aliceID, 1)
+	}
+
+	readLimited := func(limit int) ([]Asset, error) {
+		var assets []Asset
+		err := env.invoke(func(ctx contractapi.TransactionContextInterface) error {
+			var err error
+			assets, err = env.sc.ReadAllAssetsLimited(ctx, limit)
+			return err
+		})
+		return assets, err
+	}
+
+	assets, err := readLimited(10)
+	if err != nil {
+		t.Fatalf("ReadAllAssetsLimited under the limit: %v", err)
+	}
+	expectAssetIDs(t, assets, "a", "b", "c")
+
+	assets, err = readLimited(3)
+	if err != nil {
+		t.Fatalf("ReadAllAssetsLimited at the limit: %v", err)
+	}
+	expectAssetIDs(t, assets, "a", "b", "c")
+
+	assets, err = readLimited(2)
+	expectValidationCode(t, err, ValidationOutOfRange)
+	expectError(t, err, "use QueryAssetsPaginated instead")
+	if assets != nil {
+		t.Fatalf("ReadAllAssetsLimited over the limit returned %d assets", len(assets))
+	}
+
+	_, err = readLimited(0)
+	expectValidationCode(t, err, ValidationOutOfRange)
+}
+
+func TestAuditLog(t *testing.T) {
+	env := newTestEnv(t)
+	aliceID := env.createOwner(aliceIdentity, "Alice", "DOC-A", "30")
+	bobID := env.createOwner(bobIdentity, "Bob", "DOC-B", "40")
+
+	env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+		return env.sc.CreateAssetWithAmount(ctx, "asset1", "first", "gold", aliceID, 1)
+	}, withFunction("CreateAssetWithAmount"))
+	env.createAsset("asset10", "silver", aliceID, 1)
+	env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.sc.IncrementAssetAmount(ctx, "asset1", 2)
+		return err
+	}, as(aliceIdentity), withFunction("IncrementAssetAmount"))
+	env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+		return env.sc.UpdateAssetsByType(ctx, "gold")
+	}, withFunction("UpdateAssetsByType"))
+	env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+		return env.sc.TransferAsset(ctx, "asset1", bobID)
+	}, as(aliceIdentity), withFunction("TransferAsset"))
+	env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+		return env.sc.DeleteAsset(ctx, "asset1")
+	}, as(bobIdentity), withFunction("DeleteAsset"))
+	env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+		return env.sc.CreateHierarchicalAsset(ctx, "gold", "serial1", "bar", aliceID)
+	}, withFunction("CreateHierarchicalAsset"))
+
+	readLog := func(assetID string) []AuditRecord {
+		t.Helper()
+
+		var records []AuditRecord
+		env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+			var err error
+			records, err = env.sc.GetAuditLog(ctx, assetID)
+			return err
+		})
+
+		return records
+	}
+
+	records := readLog("asset1")
+	want := []struct {
+		operation string
+		caller    *mockIdentity
+	}{
+		{"CreateAssetWithAmount", adminIdentity},
+		{"IncrementAssetAmount", aliceIdentity},
+		{"UpdateAssetsByType", adminIdentity},
+		{"TransferAsset", aliceIdentity},
+		{"DeleteAsset", bobIdentity},
+	}
+	if len(records) != len(want) {
+		t.Fatalf("asset1 has %d audit records, want %d: %+v", len(records), len(want), records)
+	}
+	for i, record := range records {
+		if record.ObjectType != "asset" || len(record.Key) != 1 || record.Key[0] != "asset1" || record.Operation != want[i].operation || record.Caller != want[i].caller.id {
+			t.Fatalf("record %d = %+v, want %s by %s", i, record, want[i].operation, want[i].caller.id)
+		}
+		if i > 0 && record.Timestamp <= records[i-1].Timestamp {
+			t.Fatalf("records are not in chronological order: %+v", records)
+		}
+	}
+
+	// The range of asset1 does not include asset10, whose ID shares the prefix.
+	if records := readLog("asset10"); len(records) != 1 {
+		t.Fatalf("asset10 has %d audit records, want 1", len(records))
+	}
+	if records := readLog("missing"); records == nil || len(records) != 0 {
+		t.Fatalf("audit log of an unknown asset = %#v, want an empty slice", records)
+	}
+
+	// A plain asset whose ID looks like type/serial does not share the hierarchical asset's log.
+	env.createAsset("gold/serial1", "gold", aliceID, 1)
+	if records := readLog("gold/serial1"); len(records) != 1 || records[0].ObjectType != "asset" {
+		t.Fatalf("plain asset audit log = %+v", records)
+	}
+	var hierarchical []AuditRecord
+	env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+		var err error
+		hierarchical, err = env.sc.GetHierarchicalAssetAuditLog(ctx, "gold", "serial1")
+		return err
+	})
+	if len(hierarchical) != 1 || hierarchical[0].Operation != "CreateHierarchicalAsset" || hierarchical[0].ObjectType != "hierarchicalAsset" {
+		t.Fatalf("hierarchical asset audit log = %+v", hierarchical)
+	}
+}
+
+func TestAuditLogCoversOwnerConfigAndReservationWrites(t *testing.T) {
+	env := newTestEnv(t)
+	aliceID := env.createOwner(aliceIdentity, "Alice", "DOC-A", "30")
+	env.createAsset("asset1", "gold", aliceID, 10)
+
+	env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+		return env.sc.UpdateOwner(ctx, aliceID)
+	}, as(aliceIdentity), withTransient("ownerAge", "31"))
+	env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+		return env.sc.SetTotalCapacity(ctx, 800)
+	})
+	env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+		return env.sc.SetTypeCapacity(ctx, "gold", 100)
+	})
+	var reservationID string
+	env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+		var err error
+		reservationID, err = env.sc.ReserveAmount(ctx, "asset1", 3)
+		return err
+	}, as(aliceIdentity))
+	env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+		return env.sc.ReleaseReservation(ctx, reservationID)
+	}, as(aliceIdentity))
+
+	countRecords := func(objectType string, keyParts ...string) int {
+		prefix := compositeKey(t, "audit~key~txid", append([]string{objectType}, keyParts...)...)
+		count := 0
+		for key := range env.ledger.state {
+			if strings.HasPrefix(key, prefix) {
+				count++
+			}
+		}
+		return count
+	}
+
+	for _, tc := range []struct {
+		objectType string
+		keyParts   []string
+		want       int
+	}{
+		{"owner", []string{aliceID}, 2},
+		{"config", []string{totalCapacityConfig}, 2},
+		{"typeCapacity", []string{"gold"}, 1},
+		{"reservation", []string{reservationID}, 2},
+	} {
+		if got := countRecords(tc.objectType, tc.keyParts...); got != tc.want {
+			t.Fatalf("%s %v has %d audit records, want %d", tc.objectType, tc.keyParts, got, tc.want)
+		}
+	}
+}
+
+func TestTransferAssetToDocument(t *testing.T) {
+	env := newTestEnv(t)
+	aliceID := env.createOwner(aliceIdentity, "Alice", "DOC-A", "30")
+	bobID := env.createOwner(bobIdentity, "Bob", "DOC-BOB-7781", "40")
+	env.createAsset("asset1", "gold", aliceID, 1)
+
+	transfer := func(identity *mockIdentity, opts ...txOption) error {
+		return env.invoke(func(ctx contractapi.TransactionContextInterface) error {
+			return env.sc.TransferAssetToDocument(ctx, "asset1")
+		}, append([]txOption{as(identity)}, opts...)...)
+	}
+
+	err := transfer(aliceIdentity, withTransient("documentNumber", "DOC-UNKNOWN"))
+	expectValidationCode(t, err, ValidationNotFound)
+	if strings.Contains(err.Error(), "DOC-UNKNOWN") {
+		t.Fatalf("error leaks the document number: %v", err)
+	}
+	expectValidationCode(t, transfer(aliceIdentity), ValidationEmptyField)
+	expectError(t, transfer(outsiderIdentity, withTransient("documentNumber", "DOC-BOB-7781")), "client is not authorized to access private data")
+	if got := env.readAsset("asset1").Owner; got != aliceID {
+		t.Fatalf("owner = %q after rejected transfers, want %q", got, aliceID)
+	}
+
+	if err := transfer(aliceIdentity, withTransient("documentNumber", "DOC-BOB-7781")); err != nil {
+		t.Fatalf("TransferAssetToDocument: %v", err)
+	}
+	if got := env.readAsset("asset1").Owner; got != bobID {
+		t.Fatalf("owner = %q, want the owner registered with the document %q", got, bobID)
+	}
+	for key, value := range env.lastStub.writes {
+		if strings.Contains(key, "DOC-BOB-7781") || strings.Contains(string(value.value), "DOC-BOB-7781") {
+			t.Fatalf("write to %q leaks the document number", key)
+		}
+	}
+	for name, payload := range env.lastStub.events {
+		if strings.Contains(string(payload), "DOC-BOB-7781") {
+			t.Fatalf("event %s leaks the document number", name)
+		}
+	}
+}
+
+func TestTransferAssetRejectsSelfTransfer(t *testing.T) {
+	env := newTestEnv(t)
+	aliceID := env.createOwner(aliceIdentity, "Alice", "DOC-A", "30")
+	bobID := env.createOwner(bobIdentity, "Bob", "DOC-B", "40")
+	env.createAsset("asset1", "gold", aliceID, 1)
+	before := env.readAsset("asset1")
+
+	transfer := func(ownerID string) error {
+		return env.invoke(func(ctx contractapi.TransactionContextInterface) error {
+			return env.sc.TransferAsset(ctx, "asset1", ownerID)
+		}, as(aliceIdentity))
+	}
+
+	expectError(t, transfer(aliceID), "asset asset1 is already owned by owner "+aliceID)
+	if after := env.readAsset("asset1"); after.Version != before.Version {
+		t.Fatalf("self-transfer wrote the asset: version %d, want %d", after.Version, before.Version)
+	}
+
+	if err := transfer(bobID); err != nil {
+		t.Fatalf("TransferAsset to another owner: %v", err)
+	}
+	if got := env.readAsset("asset1").Owner; got != bobID {
+		t.Fatalf("owner = %q, want %q", got, bobID)
+	}
+}
+
+func TestGetAssetCountsByType(t *testing.T) {
+	env := newTestEnv(t)
+	aliceID := env.createOwner(aliceIdentity, "Alice", "DOC-A", "30")
+	for assetID, assetType := range map[string]string{"g1": "gold", "g2": "gold", "g3": "gold", "s1": "silver", "c1": "copper", "c2": "copper"} {
+		env.createAsset(assetID, assetType, aliceID, 1)
+	}
+
+	var counts map[string]int
+	env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+		var err error
+		counts, err = env.sc.GetAssetCountsByType(ctx)
+		return err
+	})
+
+	if len(counts) != 3 || counts["gold"] != 3 || counts["silver"] != 1 || counts["copper"] != 2 {
+		t.Fatalf("GetAssetCountsByType = %v", counts)
+	}
+	for i := 0; i < 10; i++ {
+		countsBytes, err := json.Marshal(counts)
+		if err != nil || string(countsBytes) != `{"copper":2,"gold":3,"silver":1}` {
+			t.Fatalf("counts serialize as %s, %v, want sorted keys", countsBytes, err)
+		}
+	}
+}
+
+func TestRetireAsset(t *testing.T) {
+	env := newTestEnv(t)
+	aliceID := env.createOwner(aliceIdentity, "Alice", "DOC-A", "30")
+	env.createAsset("asset1", "gold", aliceID, 40)
+
+	retire := func(identity *mockIdentity, opts ...txOption) error {
+		return env.invoke(func(ctx contractapi.TransactionContextInterface) error {
+			return env.sc.RetireAsset(ctx, "asset1")
+		}, append([]txOption{as(identity)}, opts...)...)
+	}
+
+	expectError(t, retire(bobIdentity), "caller is not the owner of the asset")
+
+	retiredAt := time.Date(2024, time.June, 30, 23, 59, 0, 0, time.FixedZone("CEST", 2*60*60))
+	if err := retire(aliceIdentity, withTimestamp(retiredAt)); err != nil {
+		t.Fatalf("RetireAsset: %v", err)
+	}
+	eventPayload := env.lastStub.events["AssetRetired"]
+
+	// The retirement time is the transaction timestamp in UTC, so every endorser records the same value.
+	const wantRetiredAt = "2024-06-30T21:59:00Z"
+	asset := env.readAsset("asset1")
+	if asset.Amount != 0 || !asset.Archived || asset.RetiredAt != wantRetiredAt {
+		t.Fatalf("retired asset = %+v", asset)
+	}
+
+	var event AssetRetiredEvent
+	if err := json.Unmarshal(eventPayload, &event); err != nil {
+		t.Fatalf("unable to unmarshal AssetRetired event: %v", err)
+	}
+	if event.AssetID != "asset1" || event.RetiredAt != wantRetiredAt {
+		t.Fatalf("AssetRetired event = %+v", event)
+	}
+
+	expectError(t, retire(aliceIdentity), "asset asset1 is already retired")
+	if got := env.readAsset("asset1").RetiredAt; got != wantRetiredAt {
+		t.Fatalf("retirement time changed to %s", got)
+	}
+}
+
+func TestRetiredAssetsCannotBeRefunded(t *testing.T) {
+	env := newTestEnv(t)
+	aliceID := env.createOwner(aliceIdentity, "Alice", "DOC-A", "30")
+	env.createAsset("retired", "gold", aliceID, 40)
+	env.createAsset("active", "gold", aliceID, 40)
+	env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+		return env.sc.RetireAsset(ctx, "retired")
+	}, as(aliceIdentity))
+
+	refunds := map[string]func(ctx contractapi.TransactionContextInterface) error{
+		"IncrementAssetAmount": func(ctx contractapi.TransactionContextInterface) error {
+			_, err := env.sc.IncrementAssetAmount(ctx, "retired", 5)
+			return err
+		},
+		"UpdateAssetAmount": func(ctx contractapi.TransactionContextInterface) error {
+			return env.sc.UpdateAssetAmount(ctx, "retired", `["5"]`)
+		},
+		"TransferAmount into": func(ctx contractapi.TransactionContextInterface) error {
+			return env.sc.TransferAmount(ctx, "active", "retired", 5)
+		},
+		"TransferAmount out of": func(ctx contractapi.TransactionContextInterface) error {
+			return env.sc.TransferAmount(ctx, "retired", "active", 5)
+		},
+		"SetAssetMinAmount": func(ctx contractapi.TransactionContextInterface) error {
+			return env.sc.SetAssetMinAmount(ctx, "retired", 0)
+		},
+	}
+	for name, refund := range refunds {
+		t.Run(name, func(t *testing.T) {
+			expectError(t, env.invoke(refund, as(aliceIdentity)), "asset retired is retired")
+		})
+	}
+
+	if asset := env.readAsset("retired"); asset.Amount != 0 || asset.MinAmount != 0 {
+		t.Fatalf("retired asset after the rejected refunds = %+v", asset)
+	}
+	if got := env.readAsset("active").Amount; got != 40 {
+		t.Fatalf("active asset amount = %d, want 40", got)
+	}
+}
+
+func TestRequireTransientField(t *testing.T) {
+	transientMap := map[string][]byte{"ownerAge": []byte("30"), "empty": {}}
+
+	value, err := requireTransientField(transientMap, "ownerAge")
+	if err != nil || string(value) != "30" {
+		t.Fatalf("requireTransientField(ownerAge) = %q, %v, want 30", value, err)
+	}
+	for _, name := range []string{"empty", "absent"} {
+		_, err := requireTransientField(transientMap, name)
+		expectValidationCode(t, err, ValidationEmptyField)
+		expectError(t, err, "missing transient field "+name)
+	}
+
+	env := newTestEnv(t)
+	create := func(opts ...txOption) error {
+		return env.invoke(func(ctx contractapi.TransactionContextInterface) error {
+			_, err := env.sc.CreateOwner(ctx, "Alice", "DOC-A")
+			return err
+		}, append([]txOption{as(aliceIdentity)}, opts...)...)
+	}
+
+	expectError(t, create(), "missing transient field ownerAge")
+	expectError(t, create(withTransient("ownerAge", "")), "missing transient field ownerAge")
+	expectError(t, create(withTransient("age", "30")), "missing transient field ownerAge")
+	if err := create(withTransient("ownerAge", "30")); err != nil {
+		t.Fatalf("CreateOwner with ownerAge present: %v", err)
+	}
+}
+
+func TestGetDistinctAssetTypes(t *testing.T) {
+	env := newTestEnv(t)
+	aliceID := env.createOwner(aliceIdentity, "Alice", "DOC-A", "30")
+
+	distinctTypes := func() []string {
+		t.Helper()
+
+		var assetTypes []string
+		env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+			var err error
+			assetTypes, err = env.sc.GetDistinctAssetTypes(ctx)
+			return err
+		})
+
+		return assetTypes
+	}
+
+	if got := distinctTypes(); got == nil || len(got) != 0 {
+		t.Fatalf("distinct types of an empty ledger = %v, want an empty slice", got)
+	}
+
+	env.createAsset("s1", "silver", aliceID, 1)
+	env.createAsset("g1", "gold", aliceID, 1)
+	env.createAsset("s2", "silver", aliceID, 1)
+	env.createAsset("c1", "copper", aliceID, 1)
+	env.createAsset("g2", "gold", aliceID, 1)
+	env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+		return env.sc.ArchiveAsset(ctx, "c1")
+	})
+	// Registering a type that no asset uses does not make it appear.
+	env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+		return env.sc.RegisterAssetType(ctx, "platinum")
+	})
+
+	if got := strings.Join(distinctTypes(), ","); got != "copper,gold,silver" {
+		t.Fatalf("distinct types = %s, want copper,gold,silver", got)
+	}
+}
+
+func TestGetAssetWithIntegrity(t *testing.T) {
+	env := newTestEnv(t)
+	aliceID := env.createOwner(aliceIdentity, "Alice", "DOC-A", "30")
+	env.createAsset("valid", "gold", aliceID, 500)
+	env.plantAsset(Asset{ID: "negative", AssetType: "gold", Owner: aliceID, Amount: -1})
+	env.plantAsset(Asset{ID: "overCapacity", AssetType: "gold", Owner: aliceID, Amount: 501})
+
+	tests := []struct {
+		assetID string
+		amount  int32
+		valid   bool
+	}{
+		{assetID: "valid", amount: 500, valid: true},
+		{assetID: "negative", amount: -1, valid: false},
+		{assetID: "overCapacity", amount: 501, valid: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.assetID, func(t *testing.T) {
+			var result *AssetIntegrityResult
+			env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+				var err error
+				result, err = env.sc.GetAssetWithIntegrity(ctx, tt.assetID)
+				return err
+			})
+			if len(env.lastStub.writes) != 0 {
+				t.Fatalf("integrity check wrote %v", env.lastStub.writes)
+			}
+
+			if result.Valid != tt.valid {
+				t.Fatalf("valid = %t, want %t", result.Valid, tt.valid)
+			}
+			// The stored amount is reported as-is, never clamped.
+			if result.Asset.ID != tt.assetID || result.Asset.Amount != tt.amount {
+				t.Fatalf("asset = %s with amount %d, want %s with amount %d", result.Asset.ID, result.Asset.Amount, tt.assetID, tt.amount)
+			}
+		})
+	}
+
+	err := env.invoke(func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.sc.GetAssetWithIntegrity(ctx, "missing")
+		return err
+	})
+	expectValidationCode(t, err, ValidationNotFound)
+}
+
+func TestSortAssetsByIDBreaksTies(t *testing.T) {
+	want := []Asset{
+		{ID: "a", CreationTime: "2024-01-01T00:00:00Z", Owner: "2"},
+		{ID: "b", CreationTime: "2024-01-01T00:00:00Z", Owner: "1"},
+		{ID: "b", CreationTime: "2024-01-01T00:00:00Z", Owner: "2"},
+		{ID: "b", CreationTime: "2024-01-02T00:00:00Z", Owner: "1"},
+		{ID: "c", CreationTime: "2023-01-01T00:00:00Z", Owner: "9"},
+	}
+
+	// Every rotation and its reverse must sort to the same order.
+	for shift := range want {
+		for _, reverse := range []bool{false, true} {
+			assets := make([]Asset, 0, len(want))
+			assets = append(assets, want[shift:]...)
+			assets = append(assets, want[:shift]...)
+			if reverse {
+				for i, j := 0, len(assets)-1; i < j; i, j = i+1, j-1 {
+					assets[i], assets[j] = assets[j], assets[i]
+				}
+			}
+
+			sortAssetsByID(assets)
+
+			for i := range want {
+				if assets[i].ID != want[i].ID || assets[i].CreationTime != want[i].CreationTime || assets[i].Owner != want[i].Owner {
+					t.Fatalf("shift %d reverse %t: position %d = %+v, want %+v", shift, reverse, i, assets[i], want[i])
+				}
+			}
+		}
+	}
+}
+
+func TestGetAssetsNeedingRepair(t *testing.T) {
+	env := newTestEnv(t)
+	aliceID := env.createOwner(aliceIdentity, "Alice", "DOC-A", "30")
+
+	repairReport := func() *RepairReport {
+		t.Helper()
+
+		var report *RepairReport
+		env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+			var err error
+			report, err = env.sc.GetAssetsNeedingRepair(ctx)
+			return err
+		})
+
+		return report
+	}
+
+	if got := *repairReport(); got != (RepairReport{}) {
+		t.Fatalf("report of an empty ledger = %+v, want all zero", got)
+	}
+
+	validTime := "2024-01-01T00:00:00Z"
+	env.createAsset("clean", "gold", aliceID, 10)
+	env.plantAsset(Asset{ID: "pointer", AssetType: "gold", Owner: "0xc000012345", Amount: 1, CreationTime: validTime})
+	env.plantAsset(Asset{ID: "negative", AssetType: "gold", Owner: aliceID, Amount: -5, CreationTime: validTime})
+	env.plantAsset(Asset{ID: "overCapacity", AssetType: "gold", Owner: aliceID, Amount: 501, CreationTime: validTime})
+	env.plantAsset(Asset{ID: "badTime", AssetType: "gold", Owner: aliceID, Amount: 1, CreationTime: "yesterday"})
+	// An asset with every issue counts once towards each of them.
+	env.plantAsset(Asset{ID: "broken", AssetType: "gold", Owner: "0xdeadbeef", Amount: -1, Archived: true})
+
+	want := RepairReport{AssetsScanned: 6, PointerOwners: 2, OutOfRangeAmounts: 3, InvalidCreationTimes: 2}
+	if got := *repairReport(); got != want {
+		t.Fatalf("report = %+v, want %+v", got, want)
+	}
+	if len(env.lastStub.writes) != 0 {
+		t.Fatalf("diagnostic wrote %v", env.lastStub.writes)
+	}
+}
+
+func TestSequentialOwnerIDsAreTheDefaultAndSurviveRestarts(t *testing.T) {
+	env := newTestEnv(t)
+
+	first := env.createOwner(aliceIdentity, "Alice", "DOC-A", "30")
+	second := env.createOwner(bobIdentity, "Bob", "DOC-B", "40")
+
+	// A fresh contract instance, as after a peer restart, continues the durable sequence.
+	env.sc = &FabricVulnBenchmark{}
+	third := env.createOwner(outsiderIdentity, "Carol", "DOC-C", "50")
+	if first != "1" || second != "2" || third != "3" {
+		t.Fatalf("sequential IDs = %s, %s, %s, want 1, 2, 3", first, second, third)
+	}
+
+	// IDs already taken by the in-memory counter are skipped.
+	env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+		return env.sc.SetOwnerIDScheme(ctx, "counter")
+	})
+	env.sc.ownerCounter = 4
+	if id := env.createOwner(aliceIdentity, "Dave", "DOC-D", "60"); id != "4" {
+		t.Fatalf("counter ID = %s, want 4", id)
+	}
+	env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+		return env.sc.SetOwnerIDScheme(ctx, "sequential")
+	})
+	if id := env.createOwner(aliceIdentity, "Erin", "DOC-E", "60"); id != "5" {
+		t.Fatalf("sequential ID after counter = %s, want 5", id)
+	}
+
+	err := env.invoke(func(ctx contractapi.TransactionContextInterface) error {
+		return env.sc.SetOwnerIDScheme(ctx, "random")
+	})
+	expectValidationCode(t, err, ValidationInvalidFormat)
+	err = env.invoke(func(ctx contractapi.TransactionContextInterface) error {
+		return env.sc.SetOwnerIDScheme(ctx, "counter")
+	}, as(aliceIdentity))
+	expectError(t, err, "caller is not authorized")
+}
+
+func TestDocumentHashOwnerIDsAreKeyedBySalt(t *testing.T) {
+	const salt = "0123456789abcdef"
+
+	newHashEnv := func(salt string) *testEnv {
+		env := newTestEnv(t)
+		env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+			return env.sc.SetOwnerIDScheme(ctx, "documentHash")
+		})
+		env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+			return env.sc.SetOwnerIDSalt(ctx)
+		}, withTransient("ownerIdSalt", salt))
+		return env
+	}
+
+	env := newTestEnv(t)
+	env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+		return env.sc.SetOwnerIDScheme(ctx, "documentHash")
+	})
+	err := env.invoke(func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.sc.createOwner(ctx, "Alice", "DOC-A")
+		return err
+	}, withTransient("ownerAge", "30"))
+	expectError(t, err, "owner ID salt is not set")
+
+	err = env.invoke(func(ctx contractapi.TransactionContextInterface) error {
+		return env.sc.SetOwnerIDSalt(ctx)
+	}, as(aliceIdentity), withTransient("ownerIdSalt", salt))
+	expectError(t, err, "caller is not authorized")
+	err = env.invoke(func(ctx contractapi.TransactionContextInterface) error {
+		return env.sc.SetOwnerIDSalt(ctx)
+	}, withTransient("ownerIdSalt", "short"))
+	expectValidationCode(t, err, ValidationOutOfRange)
+	env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+		return env.sc.SetOwnerIDSalt(ctx)
+	}, withTransient("ownerIdSalt", salt))
+	err = env.invoke(func(ctx contractapi.TransactionContextInterface) error {
+		return env.sc.SetOwnerIDSalt(ctx)
+	}, withTransient("ownerIdSalt", "fedcba9876543210"))
+	expectValidationCode(t, err, ValidationAlreadyExists)
+
+	for key, value := range env.ledger.state {
+		if string(value) == salt {
+			t.Fatalf("salt is stored in world state under %q", key)
+		}
+	}
+
+	aliceID := env.createOwner(aliceIdentity, "Alice", "DOC-A", "30")
+	bobID := env.createOwner(bobIdentity, "Bob", "DOC-B", "40")
+	if aliceID == bobID {
+		t.Fatalf("different documents share owner ID %s", aliceID)
+	}
+
+	err = env.invoke(func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.sc.createOwner(ctx, "Alice again", "DOC-A")
+		return err
+	}, withTransient("ownerAge", "30"))
+	expectValidationCode(t, err, ValidationAlreadyExists)
+
+	// The ID is stable for a given salt and changes with the salt.
+	if id := newHashEnv(salt).createOwner(aliceIdentity, "Alice", "DOC-A", "30"); id != aliceID {
+		t.Fatalf("same salt derived %s, want %s", id, aliceID)
+	}
+	if id := newHashEnv("fedcba9876543210").createOwner(aliceIdentity, "Alice", "DOC-A", "30"); id == aliceID {
+		t.Fatalf("different salts derived the same ID %s", id)
+	}
+}
+
+func TestCheckOwnerEligibility(t *testing.T) {
+	env := newTestEnv(t)
+
+	checkEligibility := func(opts ...txOption) (bool, error) {
+		var eligible bool
+		err := env.invoke(func(ctx contractapi.TransactionContextInterface) error {
+			var err error
+			eligible, err = env.sc.CheckOwnerEligibility(ctx)
+			return err
+		}, append([]txOption{as(aliceIdentity)}, opts...)...)
+		return eligible, err
+	}
+
+	for ageStr, want := range map[string]bool{"17": false, "18": true, "65": true} {
+		eligible, err := checkEligibility(withTransient("ownerAge", ageStr))
+		if err != nil || eligible != want {
+			t.Fatalf("CheckOwnerEligibility(%s) = %t, %v, want %t", ageStr, eligible, err, want)
+		}
+		if len(env.lastStub.writes) != 0 || len(env.lastStub.privateWrites) != 0 {
+			t.Fatalf("eligibility check for age %s wrote %v and %v", ageStr, env.lastStub.writes, env.lastStub.privateWrites)
+		}
+	}
+
+	// The check follows the configured minimum rather than the default.
+	env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+		return env.sc.SetMinimumAge(ctx, 21)
+	})
+	if eligible, err := checkEligibility(withTransient("ownerAge", "20")); err != nil || eligible {
+		t.Fatalf("CheckOwnerEligibility(20) with minimum 21 = %t, %v, want false", eligible, err)
+	}
+
+	_, err := checkEligibility(withTransient("ownerAge", "151"))
+	expectValidationCode(t, err, ValidationOutOfRange)
+	if strings.Contains(err.Error(), "151") {
+		t.Fatalf("error leaks the age: %v", err)
+	}
+
+	_, err = checkEligibility()
+	expectValidationCode(t, err, ValidationEmptyField)
+}
+
+func TestAssetTags(t *testing.T) {
+	env := newTestEnv(t)
+	aliceID := env.createOwner(aliceIdentity, "Alice", "DOC-A", "30")
+	env.createOwner(bobIdentity, "Bob", "DOC-B", "40")
+	env.createAsset("asset1", "gold", aliceID, 1)
+	env.createAsset("asset2", "gold", aliceID, 1)
+	env.createAsset("asset3", "gold", aliceID, 1)
+
+	tagAsset := func(assetID, tag string, add bool, opts ...txOption) error {
+		return env.invoke(func(ctx contractapi.TransactionContextInterface) error {
+			if add {
+				return env.sc.AddAssetTag(ctx, assetID, tag)
+			}
+			return env.sc.RemoveAssetTag(ctx, assetID, tag)
+		}, append([]txOption{as(aliceIdentity)}, opts...)...)
+	}
+	assetsByTag := func(tag string) []Asset {
+		t.Helper()
+
+		var assets []Asset
+		env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+			var err error
+			assets, err = env.sc.GetAssetsByTag(ctx, tag)
+			return err
+		})
+
+		return assets
+	}
+
+	for _, tag := range []string{"vault", "audited", "eu"} {
+		if err := tagAsset("asset1", tag, true); err != nil {
+			t.Fatalf("AddAssetTag(asset1, %s): %v", tag, err)
+		}
+	}
+	if err := tagAsset("asset2", "eu", true); err != nil {
+		t.Fatalf("AddAssetTag(asset2, eu): %v", err)
+	}
+
+	if got := strings.Join(env.readAsset("asset1").Tags, ","); got != "audited,eu,vault" {
+		t.Fatalf("asset1 tags = %s, want audited,eu,vault", got)
+	}
+
+	// Adding a present tag and removing an absent one change nothing.
+	if err := tagAsset("asset1", "eu", true); err != nil {
+		t.Fatalf("re-adding a tag: %v", err)
+	}
+	if len(env.lastStub.writes) != 0 {
+		t.Fatalf("re-adding a tag wrote %v", env.lastStub.writes)
+	}
+	if err := tagAsset("asset2", "vault", false); err != nil {
+		t.Fatalf("removing an absent tag: %v", err)
+	}
+	if len(env.lastStub.writes) != 0 {
+		t.Fatalf("removing an absent tag wrote %v", env.lastStub.writes)
+	}
+
+	expectAssetIDs(t, assetsByTag("eu"), "asset1", "asset2")
+	expectAssetIDs(t, assetsByTag("vault"), "asset1")
+	expectAssetIDs(t, assetsByTag("missing"))
+
+	if err := tagAsset("asset1", "eu", false); err != nil {
+		t.Fatalf("RemoveAssetTag(asset1, eu): %v", err)
+	}
+	if got := strings.Join(env.readAsset("asset1").Tags, ","); got != "audited,vault" {
+		t.Fatalf("asset1 tags after removal = %s, want audited,vault", got)
+	}
+	expectAssetIDs(t, assetsByTag("eu"), "asset2")
+
+	// Removing the last tag drops the field from the stored JSON.
+	if err := tagAsset("asset2", "eu", false); err != nil {
+		t.Fatalf("RemoveAssetTag(asset2, eu): %v", err)
+	}
+	if stored := env.ledger.state[compositeKey(t, "asset", "asset2")]; bytes.Contains(stored, []byte(`"tags"`)) {
+		t.Fatalf("untagged asset still stores tags: %s", stored)
+	}
+
+	expectError(t, tagAsset("asset3", "eu", true, as(bobIdentity)), "caller is not the owner of the asset")
+	expectValidationCode(t, tagAsset("asset3", "", true), ValidationEmptyField)
+	err := env.invoke(func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.sc.GetAssetsByTag(ctx, "")
+		return err
+	})
+	expectValidationCode(t, err, ValidationEmptyField)
+}
+
+func TestRecreatedAssetDoesNotInheritPrivateData(t *testing.T) {
+	env := newTestEnv(t)
+	aliceID := env.createOwner(aliceIdentity, "Alice", "DOC-A", "30")
+	assetKey := compositeKey(t, "asset", "asset1")
+
+	env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+		return env.sc.CreateAssetPrivate(ctx, "asset1", "gold", aliceID)
+	}, as(aliceIdentity), withTransient("assetDescription", "old secret"))
+	if _, ok := env.ledger.private[privateCollection][assetKey]; !ok {
+		t.Fatal("private details were not stored")
+	}
+
+	env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+		return env.sc.DeleteAsset(ctx, "asset1")
+	}, as(aliceIdentity))
+	if _, ok := env.ledger.private[privateCollection][assetKey]; ok {
+		t.Fatal("DeleteAsset left the private details behind")
+	}
+
+	env.createAsset("asset1", "silver", aliceID, 5)
+	err := env.invoke(func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.sc.ReadAssetPrivateDescription(ctx, "asset1")
+		return err
+	}, as(aliceIdentity))
+	expectValidationCode(t, err, ValidationNotFound)
+
+	// Private data that outlived its asset blocks the ID instead of being inherited.
+	env.ledger.private[privateCollection][compositeKey(t, "asset", "asset2")] = []byte(`{"description":"stale secret"}`)
+	err = env.invoke(func(ctx contractapi.TransactionContextInterface) error {
+		return env.sc.CreateAssetWithAmount(ctx, "asset2", "fresh", "gold", aliceID, 1)
+	})
+	expectValidationCode(t, err, ValidationAlreadyExists)
+	if env.assetExists("asset2") {
+		t.Fatal("asset was created over stale private data")
+	}
+}
+
+func TestSampleAssets(t *testing.T) {
+	env := newTestEnv(t)
+	aliceID := env.createOwner(aliceIdentity, "Alice", "DOC-A", "30")
+	var assetIDs []string
+	for i := 0; i < 10; i++ {
+		assetID := fmt.Sprintf("asset%02d", i)
+		env.createAsset(assetID, "gold", aliceID, 1)
+		assetIDs = append(assetIDs, assetID)
+	}
+	env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+		return env.sc.ArchiveAsset(ctx, "asset09")
+	})
+
+	sample := func(n int, seed string) ([]Asset, error) {
+		var assets []Asset
+		err := env.invoke(func(ctx contractapi.TransactionContextInterface) error {
+			var err error
+			assets, err = env.sc.SampleAssets(ctx, n, seed)
+			return err
+		})
+		return assets, err
+	}
+	sampleIDs := func(n int, seed string) string {
+		t.Helper()
+
+		assets, err := sample(n, seed)
+		if err != nil {
+			t.Fatalf("SampleAssets(%d, %q): %v", n, seed, err)
+		}
+
+		ids := make([]string, 0, len(assets))
+		for _, asset := range assets {
+			ids = append(ids, asset.ID)
+		}
+
+		return strings.Join(ids, ",")
+	}
+
+	// The selection ranks the live assets by SHA-256 of seed and ID and returns the first n by ID.
+	ranked := append([]string(nil), assetIDs[:9]...)
+	rank := func(id string) string {
+		digest := sha256.Sum256([]byte("audit-1\x00" + id))
+		return hex.EncodeToString(digest[:])
+	}
+	sort.Slice(ranked, func(i, j int) bool { return rank(ranked[i]) < rank(ranked[j]) })
+	want := ranked[:3]
+	sort.Strings(want)
+
+	first := sampleIDs(3, "audit-1")
+	if first != strings.Join(want, ",") {
+		t.Fatalf("sample = %s, want %s", first, strings.Join(want, ","))
+	}
+	for i := 0; i < 5; i++ {
+		if again := sampleIDs(3, "audit-1"); again != first {
+			t.Fatalf("sample %d = %s, want the same selection %s", i, again, first)
+		}
+	}
+
+	differs := false
+	for _, seed := range []string{"audit-2", "audit-3", "audit-4", "audit-5"} {
+		if sampleIDs(3, seed) != first {
+			differs = true
+		}
+	}
+	if !differs {
+		t.Fatal("every seed selected the same assets")
+	}
+
+	if got := sampleIDs(50, "audit-1"); got != strings.Join(assetIDs[:9], ",") {
+		t.Fatalf("oversized sample = %s, want every live asset", got)
+	}
+
+	for _, n := range []int{0, -1} {
+		_, err := sample(n, "audit-1")
+		expectValidationCode(t, err, ValidationOutOfRange)
+	}
+}
+
+func TestReassignAssetCollection(t *testing.T) {
+	const description = "vault 7"
+
+	env := newTestEnv(t)
+	aliceID := env.createOwner(aliceIdentity, "Alice", "DOC-A", "30")
+	env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+		return env.sc.CreateAssetPrivate(ctx, "asset1", "gold", aliceID)
+	}, as(aliceIdentity), withTransient("assetDescription", description))
+	env.createAsset("public", "gold", aliceID, 1)
+	assetKey := compositeKey(t, "asset", "asset1")
+
+	reassign := func(assetID, collection string, opts ...txOption) error {
+		return env.invoke(func(ctx contractapi.TransactionContextInterface) error {
+			return env.sc.ReassignAssetCollection(ctx, assetID, collection)
+		}, opts...)
+	}
+	readDescription := func() string {
+		t.Helper()
+
+		var value string
+		env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+			var err error
+			value, err = env.sc.ReadAssetPrivateDescription(ctx, "asset1")
+			return err
+		}, as(aliceIdentity))
+
+		return value
+	}
+
+	expectError(t, reassign("asset1", "euCollection", as(aliceIdentity)), "caller is not authorized")
+	if _, ok := env.ledger.private[privateCollection][assetKey]; !ok {
+		t.Fatal("a rejected reassignment moved the private details")
+	}
+
+	if err := reassign("asset1", "euCollection"); err != nil {
+		t.Fatalf("ReassignAssetCollection: %v", err)
+	}
+	if _, ok := env.ledger.private[privateCollection][assetKey]; ok {
+		t.Fatal("the old collection still holds the private details")
+	}
+	if _, ok := env.ledger.private["euCollection"][assetKey]; !ok {
+		t.Fatal("the new collection does not hold the private details")
+	}
+	if got := env.readAsset("asset1").PrivateCollection; got != "euCollection" {
+		t.Fatalf("asset collection = %q, want euCollection", got)
+	}
+	if got := readDescription(); got != description {
+		t.Fatalf("description after the move = %q, want %q", got, description)
+	}
+
+	// Moving back to the default collection clears the recorded collection.
+	if err := reassign("asset1", privateCollection); err != nil {
+		t.Fatalf("ReassignAssetCollection back: %v", err)
+	}
+	if _, ok := env.ledger.private["euCollection"][assetKey]; ok {
+		t.Fatal("euCollection still holds the private details")
+	}
+	if got := env.readAsset("asset1").PrivateCollection; got != "" {
+		t.Fatalf("asset collection = %q, want the default", got)
+	}
+	if got := readDescription(); got != description {
+		t.Fatalf("description after moving back = %q, want %q", got, description)
+	}
+
+	expectError(t, reassign("asset1", privateCollection), "already stored in collection")
+	expectValidationCode(t, reassign("asset1", ""), ValidationEmptyField)
+	expectValidationCode(t, reassign("public", "euCollection"), ValidationNotFound)
+	expectValidationCode(t, reassign("missing", "euCollection"), ValidationNotFound)
+}
+
+func TestClientJSONIsDecodedStrictly(t *testing.T) {
+	var input AssetInput
+	if err := decodeStrict(`{"id":"asset1","amount":5}`, &input); err != nil || input.ID != "asset1" || input.Amount != 5 {
+		t.Fatalf("decodeStrict = %+v, %v, want asset1 with amount 5", input, err)
+	}
+	expectError(t, decodeStrict(`{"id":"asset1","amont":5}`, &input), `unknown field "amont"`)
+	expectError(t, decodeStrict(`{"id":"asset1"} {"id":"asset2"}`, &input), "unexpected data after the JSON value")
+
+	env := newTestEnv(t)
+	aliceID := env.createOwner(aliceIdentity, "Alice", "DOC-A", "30")
+	env.createAsset("asset1", "gold", aliceID, 1)
+
+	err := env.createAssetsBatch(AssetInput{ID: "asset2", AssetType: "gold", OwnerID: aliceID, Amount: 1})
+	if err != nil {
+		t.Fatalf("CreateAssetsBatch: %v", err)
+	}
+	// A misspelled field fails the whole batch instead of silently creating a zero amount.
+	err = env.invoke(func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.sc.CreateAssetsBatch(ctx, `[{"id":"asset3","assetType":"gold","ownerId":"`+aliceID+`","amont":7}]`)
+		return err
+	})
+	expectValidationCode(t, err, ValidationInvalidFormat)
+	expectError(t, err, `unknown field "amont"`)
+	if env.assetExists("asset3") {
+		t.Fatal("asset3 was created from input with an unknown field")
+	}
+
+	err = env.invoke(func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.sc.ReadAssets(ctx, `["asset1"] ["asset2"]`, false)
+		return err
+	})
+	expectError(t, err, "unexpected data after the JSON value")
+
+	err = env.invoke(func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.sc.DeleteAssets(ctx, `["asset2"],`, false)
+		return err
+	})
+	expectError(t, err, "asset IDs must be a JSON array of strings")
+	if !env.assetExists("asset2") {
+		t.Fatal("asset2 was deleted from malformed input")
+	}
+
+	_, err = parseAmountsJSON(`["5"] []`)
+	expectError(t, err, "unexpected data after the JSON value")
+
+	// A trailing object must not be dropped from the selector sent to CouchDB.
+	err = env.invoke(func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.sc.QueryAssetsPaginated(ctx, `{"assetType":"gold"} {"owner":"`+aliceID+`"}`, 10, "")
+		return err
+	})
+	expectValidationCode(t, err, ValidationInvalidFormat)
+	expectError(t, err, "unexpected data after the JSON value")
+}
+
+func TestRebuildAssetTypeRegistry(t *testing.T) {
+	env := newTestEnv(t)
+	aliceID := env.createOwner(aliceIdentity, "Alice", "DOC-A", "30")
+	env.createAsset("gold1", "gold", aliceID, 1)
+	env.createAsset("silver1", "silver", aliceID, 1)
+	env.createAsset("copper1", "copper", aliceID, 1)
+	env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+		return env.sc.ArchiveAsset(ctx, "copper1")
+	})
+	// The registry drifts from the stored assets when silver and copper lose their entries.
+	delete(env.ledger.state, compositeKey(t, "assetTypeRegistry", "silver"))
+	delete(env.ledger.state, compositeKey(t, "assetTypeRegistry", "copper"))
+	// A legacy record without a type is not registered under the empty name.
+	env.plantAsset(Asset{ID: "untyped", Owner: aliceID, Amount: 1})
+
+	registeredTypes := func() map[string]bool {
+		t.Helper()
+
+		registered := make(map[string]bool)
+		env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+			for _, assetType := range []string{"gold", "silver", "copper", "platinum"} {
+				ok, err := isAssetTypeRegistered(ctx, assetType)
+				if err != nil {
+					return err
+				}
+				registered[assetType] = ok
+			}
+			return nil
+		})
+
+		return registered
+	}
+
+	if got := registeredTypes(); got["silver"] || got["copper"] {
+		t.Fatalf("registry before the rebuild = %v, want silver and copper unregistered", got)
+	}
+
+	rebuild := func(identity *mockIdentity) (int, error) {
+		var count int
+		err := env.invoke(func(ctx contractapi.TransactionContextInterface) error {
+			var err error
+			count, err = env.sc.RebuildAssetTypeRegistry(ctx)
+			return err
+		}, as(identity))
+		return count, err
+	}
+
+	_, err := rebuild(aliceIdentity)
+	expectError(t, err, "caller is not authorized")
+
+	count, err := rebuild(adminIdentity)
+	if err != nil || count != 3 {
+		t.Fatalf("RebuildAssetTypeRegistry = %d, %v, want 3", count, err)
+	}
+	if _, ok := env.ledger.state[compositeKey(t, "assetTypeRegistry", "")]; ok {
+		t.Fatal("the empty asset type was registered")
+	}
+
+	// In-use types are added and registered types without assets are kept.
+	want := map[string]bool{"gold": true, "silver": true, "copper": true, "platinum": true}
+	if got := registeredTypes(); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("registry after the rebuild = %v, want %v", got, want)
+	}
+
+	// A second rebuild finds the same types.
+	if count, err := rebuild(adminIdentity); err != nil || count != 3 {
+		t.Fatalf("second RebuildAssetTypeRegistry = %d, %v, want 3", count, err)
+	}
+}
+
+func TestGetAssetsByOwnerPaginated(t *testing.T) {
+	env := newTestEnv(t)
+	aliceID := env.createOwner(aliceIdentity, "Alice", "DOC-A", "30")
+	bobID := env.createOwner(bobIdentity, "Bob", "DOC-B", "40")
+	for _, assetID := range []string{"a5", "a1", "a4", "a2", "a3"} {
+		env.createAsset(assetID, "gold", aliceID, 1)
+	}
+	env.createAsset("b1", "gold", bobID, 1)
+	env.createAsset("a0", "gold", bobID, 1)
+	env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+		return env.sc.ArchiveAsset(ctx, "a3")
+	})
+
+	page := func(pageSize int32, bookmark string) (*PaginatedAssetResult, error) {
+		var result *PaginatedAssetResult
+		err := env.invoke(func(ctx contractapi.TransactionContextInterface) error {
+			var err error
+			result, err = env.sc.GetAssetsByOwnerPaginated(ctx, aliceID, pageSize, bookmark)
+			return err
+		})
+		return result, err
+	}
+	assetIDs := func(result *PaginatedAssetResult) []string {
+		ids := make([]string, 0, len(result.Assets))
+		for _, asset := range result.Assets {
+			ids = append(ids, asset.ID)
+		}
+		return ids
+	}
+
+	var walked []string
+	bookmark := ""
+	pages := 0
+	for {
+		result, err := page(2, bookmark)
+		if err != nil {
+			t.Fatalf("GetAssetsByOwnerPaginated: %v", err)
+		}
+		if result.FetchedRecordsCount > 2 {
+			t.Fatalf("page %d fetched %d records, want at most 2", pages, result.FetchedRecordsCount)
+		}
+		walked = append(walked, assetIDs(result)...)
+		pages++
+		if result.Bookmark == "" {
+			break
+		}
+		bookmark = result.Bookmark
+	}
+
+	// a3 is archived and b1 and a0 belong to bob.
+	if pages != 3 || len(walked) != 4 || walked[0] != "a1" || walked[1] != "a2" || walked[2] != "a4" || walked[3] != "a5" {
+		t.Fatalf("walked %v in %d pages, want [a1 a2 a4 a5] in 3 pages", walked, pages)
+	}
+
+	// The index follows transfers.
+	env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+		return env.sc.TransferAsset(ctx, "a1", bobID)
+	})
+	result, err := page(10, "")
+	if err != nil {
+		t.Fatalf("GetAssetsByOwnerPaginated: %v", err)
+	}
+	if ids := assetIDs(result); len(ids) != 3 || ids[0] != "a2" || result.Bookmark != "" {
+		t.Fatalf("after transfer got %v with bookmark %q", ids, result.Bookmark)
+	}
+
+	_, err = page(0, "")
+	expectValidationCode(t, err, ValidationOutOfRange)
+	err = env.invoke(func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.sc.GetAssetsByOwnerPaginated(ctx, "", 2, "")
+		return err
+	})
+	expectValidationCode(t, err, ValidationEmptyField)
+}
+
+func TestOwnerIndexFollowsCreateTransferDelete(t *testing.T) {
+	env := newTestEnv(t)
+	aliceID := env.createOwner(aliceIdentity, "Alice", "DOC-A", "30")
+	bobID := env.createOwner(bobIdentity, "Bob", "DOC-B", "40")
+
+	indexed := func(ownerID, assetID string) bool {
+		_, ok := env.ledger.state[compositeKey(t, "owner~asset", ownerID, assetID)]
+		return ok
+	}
+	ownedIDs := func(ownerID string) []string {
+		t.Helper()
+
+		var assets []Asset
+		env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+			var err error
+			assets, err = env.sc.GetAssetsByOwner(ctx, ownerID)
+			return err
+		})
+
+		ids := make([]string, 0, len(assets))
+		for _, asset := range assets {
+			ids = append(ids, asset.ID)
+		}
+		return ids
+	}
+
+	env.createAsset("asset2", "gold", aliceID, 1)
+	env.createAsset("asset1", "gold", aliceID, 1)
+	if !indexed(aliceID, "asset1") || !indexed(aliceID, "asset2") {
+		t.Fatal("created assets are not indexed under their owner")
+	}
+	if ids := ownedIDs(aliceID); len(ids) != 2 || ids[0] != "asset1" || ids[1] != "asset2" {
+		t.Fatalf("alice owns %v, want [asset1 asset2]", ids)
+	}
+
+	env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+		return env.sc.TransferAsset(ctx, "asset1", bobID)
+	}, as(aliceIdentity))
+	if indexed(aliceID, "asset1") || !indexed(bobID, "asset1") {
+		t.Fatal("transfer did not move the index entry")
+	}
+	if ids := ownedIDs(bobID); len(ids) != 1 || ids[0] != "asset1" {
+		t.Fatalf("bob owns %v, want [asset1]", ids)
+	}
+
+	env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+		return env.sc.DeleteAsset(ctx, "asset1")
+	}, as(bobIdentity))
+	if indexed(bobID, "asset1") {
+		t.Fatal("delete left the index entry behind")
+	}
+	if ids := ownedIDs(bobID); len(ids) != 0 {
+		t.Fatalf("bob owns %v after delete, want none", ids)
+	}
+}
+
+func TestRebuildOwnerIndex(t *testing.T) {
+	env := newTestEnv(t)
+	aliceID := env.createOwner(aliceIdentity, "Alice", "DOC-A", "30")
+	bobID := env.createOwner(bobIdentity, "Bob", "DOC-B", "40")
+	env.createAsset("asset1", "gold", aliceID, 1)
+
+	// An asset written before the index existed, and an entry left behind for a vanished asset.
+	env.ledger.state[compositeKey(t, "asset", "legacy")] = []byte(`{"id":"legacy","assetType":"gold","owner":"` + bobID + `","amount":1}`)
+	env.ledger.state[compositeKey(t, "owner~asset", aliceID, "ghost")] = []byte{0x00}
+
+	rebuild := func(identity *mockIdentity) (int, error) {
+		var changed int
+		err := env.invoke(func(ctx contractapi.TransactionContextInterface) error {
+			var err error
+			changed, err = env.sc.RebuildOwnerIndex(ctx)
+			return err
+		}, as(identity))
+		return changed, err
+	}
+
+	_, err := rebuild(aliceIdentity)
+	expectError(t, err, "caller is not authorized")
+
+	changed, err := rebuild(adminIdentity)
+	if err != nil || changed != 2 {
+		t.Fatalf("RebuildOwnerIndex = %d, %v, want 2 changes", changed, err)
+	}
+	if _, ok := env.ledger.state[compositeKey(t, "owner~asset", bobID, "legacy")]; !ok {
+		t.Fatal("legacy asset was not backfilled")
+	}
+	if _, ok := env.ledger.state[compositeKey(t, "owner~asset", aliceID, "ghost")]; ok {
+		t.Fatal("stale index entry was not removed")
+	}
+	if _, ok := env.ledger.state[compositeKey(t, "owner~asset", aliceID, "asset1")]; !ok {
+		t.Fatal("existing index entry was removed")
+	}
+
+	changed, err = rebuild(adminIdentity)
+	if err != nil || changed != 0 {
+		t.Fatalf("second RebuildOwnerIndex = %d, %v, want no changes", changed, err)
+	}
+}
+
+func TestOnboardOwnerWithAsset(t *testing.T) {
+	env := newTestEnv(t)
+
+	var ownerID string
+	env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+		var err error
+		ownerID, err = env.sc.OnboardOwnerWithAsset(ctx, "Alice", "DOC-A", "asset1", "first asset", "gold")
+		return err
+	}, as(aliceIdentity), withTransient("ownerAge", "30", "assetSecret", "s3cret"))
+
+	if ownerID == "" {
+		t.Fatal("expected the new owner ID")
+	}
+	if env.ledger.private[privateCollection][ownerID] == nil {
+		t.Fatal("private owner record was not written")
+	}
+
+	asset := env.readAsset("asset1")
+	if asset.Owner != ownerID || asset.Amount != 1 || asset.CreatedBy != aliceIdentity.id {
+		t.Fatalf("unexpected onboarded asset %+v", asset)
+	}
+	if _, err := time.Parse(time.RFC3339, asset.CreationTime); err != nil {
+		t.Fatalf("creation time %q is not RFC 3339", asset.CreationTime)
+	}
+
+	var secret string
+	env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+		var err error
+		secret, err = env.sc.ReadAssetPrivateDetails(ctx, "asset1")
+		return err
+	}, as(aliceIdentity))
+	if secret != "s3cret" {
+		t.Fatalf("asset secret = %q, want s3cret", secret)
+	}
+
+	// The owner can modify the asset without an admin.
+	env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.sc.IncrementAssetAmount(ctx, "asset1", 1)
+		return err
+	}, as(aliceIdentity))
+}
+
+func TestOnboardOwnerWithAssetRejectsUnderageOwnerWithoutPrivateData(t *testing.T) {
+	env := newTestEnv(t)
+
+	err := env.invoke(func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.sc.OnboardOwnerWithAsset(ctx, "Bob Underage", "DOC-B-1204", "asset1", "first asset", "gold")
+		return err
+	}, as(bobIdentity), withTransient("ownerAge", "12"))
+	expectValidationCode(t, err, ValidationOutOfRange)
+	for _, private := range []string{"Bob Underage", "DOC-B-1204"} {
+		if strings.Contains(err.Error(), private) {
+			t.Fatalf("error leaks private value %q: %v", private, err)
+		}
+	}
+	if env.assetExists("asset1") {
+		t.Fatal("asset of an underage owner was created")
+	}
+}
+
+func TestOnboardOwnerWithAssetFailingAssetStepWritesNothing(t *testing.T) {
+	env := newTestEnv(t)
+	existingOwner := env.createOwner(aliceIdentity, "Alice", "DOC-A", "30")
+	env.createAsset("taken", "gold", existingOwner, 1)
+
+	err := env.invoke(func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.sc.OnboardOwnerWithAsset(ctx, "Bob", "DOC-B", "taken", "duplicate", "gold")
+		return err
+	}, as(bobIdentity), withTransient("ownerAge", "40"))
+	expectValidationCode(t, err, ValidationAlreadyExists)
+
+	var count int
+	env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+		var err error
+		count, err = env.sc.GetOwnerCount(ctx)
+		return err
+	})
+	if count != 1 {
+		t.Fatalf("owner count = %d after a failed onboarding, want 1", count)
+	}
+
+	var registered bool
+	env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+		var err error
+		registered, err = env.sc.IsDocumentRegistered(ctx)
+		return err
+	}, withTransient("documentNumber", "DOC-B"))
+	if registered {
+		t.Fatal("document of the failed onboarding was registered")
+	}
+}
+
+func TestChangeAssetType(t *testing.T) {
+	env := newTestEnv(t)
+	aliceID := env.createOwner(aliceIdentity, "Alice", "DOC-A", "30")
+	env.createOwner(bobIdentity, "Bob", "DOC-B", "40")
+	env.createAsset("gold1", "gold", aliceID, 60)
+	env.createAsset("gold2", "gold", aliceID, 40)
+	env.createAsset("silver1", "silver", aliceID, 30)
+	env.createAsset("gold3", "gold", aliceID, 1)
+	env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+		return env.sc.ArchiveAsset(ctx, "gold3")
+	})
+	env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+		return env.sc.SetTypeCapacity(ctx, "silver", 80)
+	})
+
+	changeType := func(assetID, newType string, opts ...txOption) error {
+		return env.invoke(func(ctx contractapi.TransactionContextInterface) error {
+			return env.sc.ChangeAssetType(ctx, assetID, newType)
+		}, append([]txOption{as(aliceIdentity)}, opts...)...)
+	}
+	expectType := func(assetID, want string) {
+		t.Helper()
+
+		if got := env.readAsset(assetID).AssetType; got != want {
+			t.Fatalf("%s type = %s, want %s", assetID, got, want)
+		}
+	}
+
+	expectValidationCode(t, changeType("gold1", "bullion"), ValidationNotFound)
+	expectType("gold1", "gold")
+	expectError(t, changeType("gold3", "copper"), "asset gold3 is archived")
+	expectType("gold3", "gold")
+
+	// 30 silver plus 60 exceeds the silver cap of 80.
+	expectValidationCode(t, changeType("gold1", "silver"), ValidationOutOfRange)
+	expectType("gold1", "gold")
+
+	expectError(t, changeType("gold2", "silver", as(bobIdentity)), "caller is not the owner of the asset")
+	expectError(t, changeType("silver1", "silver"), "asset already has the requested type")
+	expectValidationCode(t, changeType("gold2", ""), ValidationEmptyField)
+
+	// 30 silver plus 40 fits.
+	if err := changeType("gold2", "silver"); err != nil {
+		t.Fatalf("ChangeAssetType: %v", err)
+	}
+	asset := env.readAsset("gold2")
+	if asset.AssetType != "silver" || asset.Amount != 40 || asset.Owner != aliceID {
+		t.Fatalf("changed asset = %+v, want silver with amount 40 owned by %s", asset, aliceID)
+	}
+
+	// The moved amount now counts against the silver cap.
+	err := env.invoke(func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.sc.IncrementAssetAmount(ctx, "silver1", 11)
+		return err
+	}, as(aliceIdentity))
+	expectValidationCode(t, err, ValidationOutOfRange)
+}
+
+func TestAssetTypesMustBeRegistered(t *testing.T) {
+	env := newTestEnv(t)
+	aliceID := env.createOwner(aliceIdentity, "Alice", "DOC-A", "30")
+
+	err := env.invoke(func(ctx contractapi.TransactionContextInterface) error {
+		return env.sc.CreateAsset(ctx, "asset1", "description", "bullion", aliceID)
+	})
+	expectValidationCode(t, err, ValidationNotFound)
+
+	err = env.createAssetsBatch(
+		AssetInput{ID: "asset1", AssetType: "gold", OwnerID: aliceID, Amount: 1},
+		AssetInput{ID: "asset2", AssetType: "bullion", OwnerID: aliceID, Amount: 1},
+	)
+	expectValidationCode(t, err, ValidationNotFound)
+
+	err = env.invoke(func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.sc.OnboardOwnerWithAsset(ctx, "Bob", "DOC-B", "asset3", "description", "bullion")
+		return err
+	}, as(bobIdentity), withTransient("ownerAge", "40"))
+	expectValidationCode(t, err, ValidationNotFound)
+
+	for _, assetID := range []string{"asset1", "asset2", "asset3"} {
+		if env.assetExists(assetID) {
+			t.Fatalf("%s was created with an unregistered type", assetID)
+		}
+	}
+
+	env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+		return env.sc.RegisterAssetType(ctx, "bullion")
+	})
+	env.createAsset("asset1", "bullion", aliceID, 1)
+}
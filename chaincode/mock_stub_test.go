@@ -0,0 +1,741 @@
+package chaincode
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-chaincode-go/v2/pkg/cid"
+	"github.com/hyperledger/fabric-chaincode-go/v2/shim"
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+	"github.com/hyperledger/fabric-protos-go-apiv2/ledger/queryresult"
+	"github.com/hyperledger/fabric-protos-go-apiv2/peer"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const testPeerMSPID = "Org1MSP"
+
+// mockIdentity is a client identity with an ID, an MSP ID and attributes.
+type mockIdentity struct {
+	cid.ClientIdentity
+
+	id    string
+	mspID string
+	attrs map[string]string
+}
+
+var (
+	adminIdentity    = &mockIdentity{id: "admin", mspID: testPeerMSPID, attrs: map[string]string{adminRoleAttr: adminRoleValue}}
+	aliceIdentity    = &mockIdentity{id: "alice", mspID: testPeerMSPID}
+	bobIdentity      = &mockIdentity{id: "bob", mspID: testPeerMSPID}
+	outsiderIdentity = &mockIdentity{id: "mallory", mspID: "Org2MSP"}
+)
+
+func (m *mockIdentity) GetID() (string, error) {
+	return m.id, nil
+}
+
+func (m *mockIdentity) GetMSPID() (string, error) {
+	return m.mspID, nil
+}
+
+func (m *mockIdentity) GetAttributeValue(attrName string) (string, bool, error) {
+	value, ok := m.attrs[attrName]
+
+	return value, ok, nil
+}
+
+func (m *mockIdentity) AssertAttributeValue(attrName, attrValue string) error {
+	if value, ok := m.attrs[attrName]; !ok || value != attrValue {
+		return fmt.Errorf("attribute %s does not equal %s", attrName, attrValue)
+	}
+
+	return nil
+}
+
+type mockContext struct {
+	stub     *mockStub
+	identity *mockIdentity
+}
+
+func (c *mockContext) GetStub() shim.ChaincodeStubInterface {
+	return c.stub
+}
+
+func (c *mockContext) GetClientIdentity() cid.ClientIdentity {
+	return c.identity
+}
+
+// mockLedger is an in-memory ledger shared by the transactions of a test.
+// Like a peer, it only exposes committed data to reads.
+type mockLedger struct {
+	state      map[string][]byte
+	private    map[string]map[string][]byte
+	history    map[string][]*queryresult.KeyModification
+	validation map[string][]byte
+
+	clock time.Time
+	txSeq int
+}
+
+func newMockLedger() *mockLedger {
+	return &mockLedger{
+		state:      make(map[string][]byte),
+		private:    make(map[string]map[string][]byte),
+		history:    make(map[string][]*queryresult.KeyModification),
+		validation: make(map[string][]byte),
+		clock:      time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+}
+
+// mockWrite is a pending write; a nil value is a delete.
+type mockWrite struct {
+	value []byte
+}
+
+// mockStub simulates one transaction against a mockLedger. Writes are buffered
+// and only applied by commit, so reads never see writes of the same transaction.
+type mockStub struct {
+	shim.ChaincodeStubInterface
+
+	ledger        *mockLedger
+	txID          string
+	timestamp     time.Time
+	function      string
+	args          []string
+	transient     map[string][]byte
+	events        map[string][]byte
+	writes        map[string]mockWrite
+	privateWrites map[string]map[string]mockWrite
+	validation    map[string][]byte
+	reads         []string
+	invoke        func(chaincodeName string, args [][]byte, channel string) *peer.Response
+	getStateErr   error
+}
+
+func (s *mockStub) GetTxID() string {
+	return s.txID
+}
+
+func (s *mockStub) GetFunctionAndParameters() (string, []string) {
+	return s.function, s.args
+}
+
+// GetCreator fails, so transactions invoked through contractapi run without a client identity.
+func (s *mockStub) GetCreator() ([]byte, error) {
+	return nil, errors.New("no creator in mock stub")
+}
+
+func (s *mockStub) GetTxTimestamp() (*timestamppb.Timestamp, error) {
+	return timestamppb.New(s.timestamp), nil
+}
+
+func (s *mockStub) GetTransient() (map[string][]byte, error) {
+	return s.transient, nil
+}
+
+func (s *mockStub) SetEvent(name string, payload []byte) error {
+	if name == "" {
+		return errors.New("event name can not be empty string")
+	}
+
+	s.events[name] = payload
+
+	return nil
+}
+
+func (s *mockStub) InvokeChaincode(chaincodeName string, args [][]byte, channel string) *peer.Response {
+	if s.invoke == nil {
+		return &peer.Response{Status: shim.ERROR, Message: "chaincode " + chaincodeName + " is not installed"}
+	}
+
+	return s.invoke(chaincodeName, args, channel)
+}
+
+func (s *mockStub) CreateCompositeKey(objectType string, attributes []string) (string, error) {
+	return shim.CreateCompositeKey(objectType, attributes)
+}
+
+func (s *mockStub) SplitCompositeKey(compositeKey string) (string, []string, error) {
+	if !strings.HasPrefix(compositeKey, "\x00") {
+		return "", nil, fmt.Errorf("key %q is not a composite key", compositeKey)
+	}
+
+	components := strings.Split(strings.TrimSuffix(compositeKey[1:], "\x00"), "\x00")
+
+	return components[0], components[1:], nil
+}
+
+func (s *mockStub) GetState(key string) ([]byte, error) {
+	if s.getStateErr != nil {
+		return nil, s.getStateErr
+	}
+
+	s.reads = append(s.reads, key)
+
+	return s.ledger.state[key], nil
+}
+
+func (s *mockStub) PutState(key string, value []byte) error {
+	if key == "" {
+		return errors.New("key must not be an empty string")
+	}
+	if value == nil {
+		value = []byte{}
+	}
+
+	s.writes[key] = mockWrite{value: value}
+
+	return nil
+}
+
+func (s *mockStub) DelState(key string) error {
+	s.writes[key] = mockWrite{}
+
+	return nil
+}
+
+func (s *mockStub) SetStateValidationParameter(key string, ep []byte) error {
+	s.validation[key] = ep
+
+	return nil
+}
+
+func (s *mockStub) GetStateValidationParameter(key string) ([]byte, error) {
+	return s.ledger.validation[key], nil
+}
+
+func (s *mockStub) GetStateByPartialCompositeKey(objectType string, keys []string) (shim.StateQueryIteratorInterface, error) {
+	prefix, err := shim.CreateCompositeKey(objectType, keys)
+	if err != nil {
+		return nil, err
+	}
+
+	return &mockStateIterator{results: s.ledger.scan(prefix, "", 0)}, nil
+}
+
+func (s *mockStub) GetStateByPartialCompositeKeyWithPagination(objectType string, keys []string, pageSize int32, bookmark string) (shim.StateQueryIteratorInterface, *peer.QueryResponseMetadata, error) {
+	prefix, err := shim.CreateCompositeKey(objectType, keys)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	results := s.ledger.scan(prefix, bookmark, int(pageSize)+1)
+
+	return paginate(results, pageSize)
+}
+
+func (s *mockStub) GetQueryResult(query string) (shim.StateQueryIteratorInterface, error) {
+	selector, err := parseSelector(query)
+	if err != nil {
+		return nil, err
+	}
+
+	return &mockStateIterator{results: s.ledger.query(selector, "", 0)}, nil
+}
+
+func (s *mockStub) GetQueryResultWithPagination(query string, pageSize int32, bookmark string) (shim.StateQueryIteratorInterface, *peer.QueryResponseMetadata, error) {
+	selector, err := parseSelector(query)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	results := s.ledger.query(selector, bookmark, int(pageSize)+1)
+
+	return paginate(results, pageSize)
+}
+
+func (s *mockStub) GetHistoryForKey(key string) (shim.HistoryQueryIteratorInterface, error) {
+	modifications := s.ledger.history[key]
+
+	// Peers return the newest modification first.
+	results := make([]*queryresult.KeyModification, 0, len(modifications))
+	for i := len(modifications) - 1; i >= 0; i-- {
+		results = append(results, modifications[i])
+	}
+
+	return &mockHistoryIterator{results: results}, nil
+}
+
+func (s *mockStub) GetPrivateData(collection, key string) ([]byte, error) {
+	return s.ledger.private[collection][key], nil
+}
+
+func (s *mockStub) GetPrivateDataHash(collection, key string) ([]byte, error) {
+	value, ok := s.ledger.private[collection][key]
+	if !ok {
+		return nil, nil
+	}
+
+	digest := sha256.Sum256(value)
+
+	return digest[:], nil
+}
+
+func (s *mockStub) GetPrivateDataByPartialCompositeKey(collection, objectType string, keys []string) (shim.StateQueryIteratorInterface, error) {
+	prefix, err := shim.CreateCompositeKey(objectType, keys)
+	if err != nil {
+		return nil, err
+	}
+
+	return &mockStateIterator{results: scanKeys(s.ledger.private[collection], prefix, "", 0)}, nil
+}
+
+func (s *mockStub) PutPrivateData(collection, key string, value []byte) error {
+	if collection == "" {
+		return errors.New("collection must not be an empty string")
+	}
+	if len(value) == 0 {
+		return errors.New("value must not be empty")
+	}
+
+	s.privateWrite(collection)[key] = mockWrite{value: value}
+
+	return nil
+}
+
+func (s *mockStub) DelPrivateData(collection, key string) error {
+	s.privateWrite(collection)[key] = mockWrite{}
+
+	return nil
+}
+
+func (s *mockStub) privateWrite(collection string) map[string]mockWrite {
+	writes, ok := s.privateWrites[collection]
+	if !ok {
+		writes = make(map[string]mockWrite)
+		s.privateWrites[collection] = writes
+	}
+
+	return writes
+}
+
+// commit applies the buffered writes to the ledger and records them in the key history.
+func (s *mockStub) commit() {
+	for key, write := range s.writes {
+		if write.value == nil {
+			delete(s.ledger.state, key)
+		} else {
+			s.ledger.state[key] = write.value
+		}
+
+		s.ledger.history[key] = append(s.ledger.history[key], &queryresult.KeyModification{
+			TxId:      s.txID,
+			Value:     write.value,
+			Timestamp: timestamppb.New(s.timestamp),
+			IsDelete:  write.value == nil,
+		})
+	}
+
+	for collection, writes := range s.privateWrites {
+		if s.ledger.private[collection] == nil {
+			s.ledger.private[collection] = make(map[string][]byte)
+		}
+
+		for key, write := range writes {
+			if write.value == nil {
+				delete(s.ledger.private[collection], key)
+			} else {
+				s.ledger.private[collection][key] = write.value
+			}
+		}
+	}
+
+	for key, ep := range s.validation {
+		s.ledger.validation[key] = ep
+	}
+}
+
+// scan returns the committed keys with the given prefix in key order, starting
+// at bookmark when it is set. A positive limit caps the number of results.
+func (l *mockLedger) scan(prefix, bookmark string, limit int) []*queryresult.KV {
+	return scanKeys(l.state, prefix, bookmark, limit)
+}
+
+// scanKeys returns the entries of values with the given prefix in key order, as scan does.
+func scanKeys(values map[string][]byte, prefix, bookmark string, limit int) []*queryresult.KV {
+	keys := make([]string, 0)
+	for key := range values {
+		if strings.HasPrefix(key, prefix) && key >= bookmark {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	if limit > 0 && len(keys) > limit {
+		keys = keys[:limit]
+	}
+
+	results := make([]*queryresult.KV, 0, len(keys))
+	for _, key := range keys {
+		results = append(results, &queryresult.KV{Key: key, Value: values[key]})
+	}
+
+	return results
+}
+
+// query returns the committed JSON documents whose top-level fields equal every selector value.
+func (l *mockLedger) query(selector map[string]interface{}, bookmark string, limit int) []*queryresult.KV {
+	results := make([]*queryresult.KV, 0)
+	for _, kv := range l.scan("", bookmark, 0) {
+		var document map[string]interface{}
+		if json.Unmarshal(kv.Value, &document) != nil {
+			continue
+		}
+
+		matches := true
+		for field, want := range selector {
+			if fmt.Sprint(document[field]) != fmt.Sprint(want) {
+				matches = false
+				break
+			}
+		}
+
+		if matches {
+			results = append(results, kv)
+		}
+		if limit > 0 && len(results) == limit {
+			break
+		}
+	}
+
+	return results
+}
+
+func parseSelector(query string) (map[string]interface{}, error) {
+	var parsed struct {
+		Selector map[string]interface{} `json:"selector"`
+	}
+	if err := json.Unmarshal([]byte(query), &parsed); err != nil {
+		return nil, fmt.Errorf("invalid query %q: %w", query, err)
+	}
+
+	return parsed.Selector, nil
+}
+
+// paginate splits pageSize+1 results into a page and the bookmark of the next page.
+func paginate(results []*queryresult.KV, pageSize int32) (shim.StateQueryIteratorInterface, *peer.QueryResponseMetadata, error) {
+	bookmark := ""
+	if len(results) > int(pageSize) {
+		bookmark = results[pageSize].Key
+		results = results[:pageSize]
+	}
+
+	metadata := &peer.QueryResponseMetadata{FetchedRecordsCount: int32(len(results)), Bookmark: bookmark}
+
+	return &mockStateIterator{results: results}, metadata, nil
+}
+
+type mockStateIterator struct {
+	results []*queryresult.KV
+}
+
+func (it *mockStateIterator) HasNext() bool {
+	return len(it.results) > 0
+}
+
+func (it *mockStateIterator) Next() (*queryresult.KV, error) {
+	if len(it.results) == 0 {
+		return nil, errors.New("no more results")
+	}
+
+	next := it.results[0]
+	it.results = it.results[1:]
+
+	return next, nil
+}
+
+func (it *mockStateIterator) Close() error {
+	return nil
+}
+
+type mockHistoryIterator struct {
+	results []*queryresult.KeyModification
+}
+
+func (it *mockHistoryIterator) HasNext() bool {
+	return len(it.results) > 0
+}
+
+func (it *mockHistoryIterator) Next() (*queryresult.KeyModification, error) {
+	if len(it.results) == 0 {
+		return nil, errors.New("no more results")
+	}
+
+	next := it.results[0]
+	it.results = it.results[1:]
+
+	return next, nil
+}
+
+func (it *mockHistoryIterator) Close() error {
+	return nil
+}
+
+// testEnv runs transactions of one contract instance against one ledger.
+type testEnv struct {
+	t      *testing.T
+	sc     *FabricVulnBenchmark
+	ledger *mockLedger
+
+	// lastStub is the stub of the most recent transaction, committed or not.
+	lastStub *mockStub
+}
+
+// txOption customizes the transaction built by testEnv.invoke.
+type txOption func(*mockStub, *mockContext)
+
+// as runs the transaction with the given client identity. The default is adminIdentity.
+func as(identity *mockIdentity) txOption {
+	return func(_ *mockStub, ctx *mockContext) {
+		ctx.identity = identity
+	}
+}
+
+// withTransient passes alternating key and value strings as the transient map.
+func withTransient(keyValues ...string) txOption {
+	return func(stub *mockStub, _ *mockContext) {
+		for i := 0; i+1 < len(keyValues); i += 2 {
+			stub.transient[keyValues[i]] = []byte(keyValues[i+1])
+		}
+	}
+}
+
+// withTxID overrides the transaction ID.
+func withTxID(txID string) txOption {
+	return func(stub *mockStub, _ *mockContext) {
+		stub.txID = txID
+	}
+}
+
+// withFunction sets the function name and arguments reported by GetFunctionAndParameters.
+func withFunction(name string, args ...string) txOption {
+	return func(stub *mockStub, _ *mockContext) {
+		stub.function = name
+		stub.args = args
+	}
+}
+
+// withTimestamp overrides the transaction timestamp.
+func withTimestamp(timestamp time.Time) txOption {
+	return func(stub *mockStub, _ *mockContext) {
+		stub.timestamp = timestamp
+	}
+}
+
+// withInvoke handles InvokeChaincode calls made by the transaction.
+func withInvoke(invoke func(chaincodeName string, args [][]byte, channel string) *peer.Response) txOption {
+	return func(stub *mockStub, _ *mockContext) {
+		stub.invoke = invoke
+	}
+}
+
+// withGetStateError makes every GetState call of the transaction fail with err.
+func withGetStateError(err error) txOption {
+	return func(stub *mockStub, _ *mockContext) {
+		stub.getStateErr = err
+	}
+}
+
+// testAssetTypes are registered by newTestEnv.
+var testAssetTypes = []string{"gold", "silver", "copper", "platinum"}
+
+// newTestEnv returns an environment whose contract has been initialized by an admin
+// and whose testAssetTypes are registered.
+func newTestEnv(t *testing.T) *testEnv {
+	t.Helper()
+	t.Setenv("CORE_PEER_LOCALMSPID", testPeerMSPID)
+
+	previousLogger := logger
+	logger = slog.New(slog.NewJSONHandler(io.Discard, nil))
+	t.Cleanup(func() { logger = previousLogger })
+
+	env := &testEnv{t: t, sc: new(FabricVulnBenchmark), ledger: newMockLedger()}
+	env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+		return env.sc.InitContract(ctx, false)
+	})
+	// Assets can only use registered types, so the types most tests use are registered up front.
+	for _, assetType := range testAssetTypes {
+		assetType := assetType
+		env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+			return env.sc.RegisterAssetType(ctx, assetType)
+		})
+	}
+
+	return env
+}
+
+// invoke runs fn as one transaction and commits its writes when fn succeeds.
+// Each transaction gets a new transaction ID and a timestamp one minute after the previous one.
+func (env *testEnv) invoke(fn func(ctx contractapi.TransactionContextInterface) error, opts ...txOption) error {
+	env.ledger.txSeq++
+	env.ledger.clock = env.ledger.clock.Add(time.Minute)
+
+	stub := &mockStub{
+		ledger:        env.ledger,
+		txID:          fmt.Sprintf("tx%04d", env.ledger.txSeq),
+		timestamp:     env.ledger.clock,
+		transient:     make(map[string][]byte),
+		events:        make(map[string][]byte),
+		writes:        make(map[string]mockWrite),
+		privateWrites: make(map[string]map[string]mockWrite),
+		validation:    make(map[string][]byte),
+	}
+	ctx := &mockContext{stub: stub, identity: adminIdentity}
+	for _, opt := range opts {
+		opt(stub, ctx)
+	}
+	env.lastStub = stub
+
+	err := fn(ctx)
+	if err == nil {
+		stub.commit()
+	}
+
+	return err
+}
+
+// mustInvoke is invoke failing the test on error.
+func (env *testEnv) mustInvoke(fn func(ctx contractapi.TransactionContextInterface) error, opts ...txOption) {
+	env.t.Helper()
+
+	if err := env.invoke(fn, opts...); err != nil {
+		env.t.Fatalf("transaction failed: %v", err)
+	}
+}
+
+// createOwner creates an owner bound to identity and returns the owner ID.
+func (env *testEnv) createOwner(identity *mockIdentity, name, documentNumber, age string) string {
+	env.t.Helper()
+
+	var owner *Owner
+	env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+		var err error
+		owner, err = env.sc.createOwner(ctx, name, documentNumber)
+		return err
+	}, as(identity), withTransient("ownerAge", age))
+
+	return fmt.Sprint(owner.ID)
+}
+
+// createAsset creates an asset with the given amount as an admin.
+func (env *testEnv) createAsset(assetID, assetType, ownerID string, amount int32) {
+	env.t.Helper()
+
+	env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+		return env.sc.CreateAssetWithAmount(ctx, assetID, "description of "+assetID, assetType, ownerID, amount)
+	})
+}
+
+// createAssetsBatch submits inputs to CreateAssetsBatch as an admin.
+func (env *testEnv) createAssetsBatch(inputs ...AssetInput) error {
+	env.t.Helper()
+
+	inputsJSON, err := json.Marshal(inputs)
+	if err != nil {
+		env.t.Fatalf("unable to marshal batch: %v", err)
+	}
+
+	return env.invoke(func(ctx contractapi.TransactionContextInterface) error {
+		_, err := env.sc.CreateAssetsBatch(ctx, string(inputsJSON))
+		return err
+	})
+}
+
+// readAsset reads an asset from the committed state.
+func (env *testEnv) readAsset(assetID string) *Asset {
+	env.t.Helper()
+
+	var asset *Asset
+	env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+		var err error
+		asset, err = env.sc.ReadAsset(ctx, assetID)
+		return err
+	})
+
+	return asset
+}
+
+// assetExists reports whether an asset is in the committed state.
+func (env *testEnv) assetExists(assetID string) bool {
+	env.t.Helper()
+
+	var found bool
+	env.mustInvoke(func(ctx contractapi.TransactionContextInterface) error {
+		var err error
+		_, found, err = tryReadAsset(ctx, assetID)
+		return err
+	})
+
+	return found
+}
+
+// plantAsset writes an asset straight into the committed state, bypassing contract validation.
+func (env *testEnv) plantAsset(asset Asset) {
+	env.t.Helper()
+
+	assetJSON, err := json.Marshal(asset)
+	if err != nil {
+		env.t.Fatal(err)
+	}
+
+	env.ledger.state[compositeKey(env.t, "asset", asset.ID)] = assetJSON
+}
+
+// compositeKey builds a composite key the way the contract does.
+func compositeKey(t *testing.T, objectType string, attributes ...string) string {
+	t.Helper()
+
+	key, err := shim.CreateCompositeKey(objectType, attributes)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return key
+}
+
+// expectError fails the test unless err is non-nil and contains substr.
+func expectError(t *testing.T, err error, substr string) {
+	t.Helper()
+
+	if err == nil {
+		t.Fatalf("expected an error containing %q, got nil", substr)
+	}
+	if !strings.Contains(err.Error(), substr) {
+		t.Fatalf("expected an error containing %q, got %q", substr, err.Error())
+	}
+}
+
+// expectValidationCode fails the test unless err is a ValidationError with the given code.
+func expectValidationCode(t *testing.T, err error, code ValidationCode) {
+	t.Helper()
+
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("expected a %s validation error, got %v", code, err)
+	}
+	if validationErr.Code != code {
+		t.Fatalf("expected validation code %s, got %s (%s)", code, validationErr.Code, validationErr.Message)
+	}
+}
+
+// expectAssetIDs fails the test unless assets holds exactly the given IDs in order.
+func expectAssetIDs(t *testing.T, assets []Asset, want ...string) {
+	t.Helper()
+
+	got := make([]string, 0, len(assets))
+	for _, asset := range assets {
+		got = append(got, asset.ID)
+	}
+
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("got assets %v, want %v", got, want)
+	}
+}
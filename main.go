@@ -2,12 +2,10 @@ package main
 
 import (
 	"github.com/c-alchini/fabric-vuln-benchmark/chaincode"
-
-	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
 )
 
 func main() {
-	cc, err := contractapi.NewChaincode(&chaincode.FabricVulnBenchmark{})
+	cc, err := chaincode.NewLoggingChaincode(&chaincode.FabricVulnBenchmark{})
 
 	if err != nil {
 		panic(err.Error())